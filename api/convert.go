@@ -0,0 +1,219 @@
+package api
+
+import (
+	"github.com/gerrittrigger/events/events"
+)
+
+// NewEvent converts the event to its protobuf message, leaving the empty fields unset
+func NewEvent(e *events.Event) *Event {
+	if e == nil {
+		return nil
+	}
+
+	return &Event{
+		Type:           e.Type,
+		Change:         newChange(&e.Change),
+		PatchSet:       newPatchSet(&e.PatchSet),
+		Approvals:      newApprovals(e.Approvals),
+		Abandoner:      newAccount(&e.Abandoner),
+		Adder:          newAccount(&e.Adder),
+		Changer:        newAccount(&e.Changer),
+		Deleter:        newAccount(&e.Deleter),
+		Submitter:      newAccount(&e.Submitter),
+		Remover:        newAccount(&e.Remover),
+		Restorer:       newAccount(&e.Restorer),
+		Author:         newAccount(&e.Author),
+		Uploader:       newAccount(&e.Uploader),
+		Editor:         newAccount(&e.Editor),
+		Reviewer:       newAccount(&e.Reviewer),
+		NewRev:         e.NewRev,
+		OldAssignee:    newAccount(&e.OldAssignee),
+		OldTopic:       e.OldTopic,
+		Reason:         e.Reason,
+		Comment:        e.Comment,
+		Added:          e.Added,
+		Removed:        e.Removed,
+		Hashtags:       e.HashTags,
+		ProjectName:    e.ProjectName,
+		ProjectHead:    e.ProjectHead,
+		OldHead:        e.OldHead,
+		NewHead:        e.NewHead,
+		Project:        e.Project,
+		RefName:        e.RefName,
+		RefUpdate:      newRefUpdate(&e.RefUpdate),
+		RefUpdates:     newRefUpdates(e.RefUpdates),
+		ChangeKey:      newChangeKey(&e.ChangeKey),
+		EventCreatedOn: e.EventCreatedOn,
+	}
+}
+
+func newChange(c *events.Change) *Change {
+	if c.Project == "" && c.ID == "" && c.Number == 0 {
+		return nil
+	}
+
+	b := &Change{
+		Project:              c.Project,
+		Branch:               c.Branch,
+		Topic:                c.Topic,
+		Id:                   c.ID,
+		Number:               int64(c.Number),
+		Subject:              c.Subject,
+		Owner:                newAccount(&c.Owner),
+		Url:                  c.URL,
+		CommitMessage:        c.CommitMessage,
+		Hashtags:             c.HashTags,
+		CreatedOn:            c.CreatedOn,
+		LastUpdated:          c.LastUpdate,
+		Open:                 c.Open,
+		Private:              c.Private,
+		Wip:                  c.WIP,
+		Assignee:             newAccount(&c.Assignee),
+		SubmitType:           c.SubmitType,
+		CherryPickOfChange:   int64(c.CherryPickOfChange),
+		CherryPickOfPatchSet: int64(c.CherryPickOfPatchSet),
+		Status:               c.Status,
+		CurrentPatchSet:      newPatchSet(&c.CurrentPatchSet),
+		DependsOn:            newDependency(&c.DependsOn),
+		NeededBy:             newDependency(&c.NeededBy),
+	}
+
+	for i := range c.Comments {
+		b.Comments = append(b.Comments, &Message{
+			Timestamp: c.Comments[i].Timestamp,
+			Reviewer:  newAccount(&c.Comments[i].Reviewer),
+			Message:   c.Comments[i].Message,
+		})
+	}
+
+	for i := range c.TrackingIDs {
+		b.TrackingIds = append(b.TrackingIds, &TrackingID{System: c.TrackingIDs[i].System, Id: c.TrackingIDs[i].ID})
+	}
+
+	for i := range c.PatchSets {
+		b.PatchSets = append(b.PatchSets, newPatchSet(&c.PatchSets[i]))
+	}
+
+	for i := range c.SubmitRecords {
+		r := &SubmitRecord{Status: c.SubmitRecords[i].Status}
+		for j := range c.SubmitRecords[i].Labels {
+			l := &c.SubmitRecords[i].Labels[j]
+			r.Labels = append(r.Labels, &Label{Label: l.Label, Status: l.Status, By: newAccount(&l.By)})
+		}
+		b.SubmitRecords = append(b.SubmitRecords, r)
+	}
+
+	for i := range c.AllReviewers {
+		b.AllReviewers = append(b.AllReviewers, newAccount(&c.AllReviewers[i]))
+	}
+
+	return b
+}
+
+func newPatchSet(p *events.PatchSet) *PatchSet {
+	if p.Number == 0 && p.Revision == "" {
+		return nil
+	}
+
+	b := &PatchSet{
+		Number:         int64(p.Number),
+		Revision:       p.Revision,
+		Parents:        p.Parents,
+		Ref:            p.Ref,
+		Uploader:       newAccount(&p.Uploader),
+		Author:         newAccount(&p.Author),
+		CreatedOn:      p.CreatedOn,
+		IsDraft:        p.IsDraft,
+		Kind:           p.Kind,
+		Approvals:      newApprovals(p.Approvals),
+		SizeInsertions: int64(p.SizeInsertions),
+		SizeDeletions:  int64(p.SizeDeletions),
+	}
+
+	for i := range p.Comments {
+		b.Comments = append(b.Comments, &PatchSetComment{
+			File:     p.Comments[i].File,
+			Line:     int64(p.Comments[i].Line),
+			Reviewer: newAccount(&p.Comments[i].Reviewer),
+			Message:  p.Comments[i].Message,
+		})
+	}
+
+	for i := range p.Files {
+		b.Files = append(b.Files, &File{
+			File:       p.Files[i].File,
+			FileOld:    p.Files[i].FileOld,
+			Type:       p.Files[i].Type,
+			Insertions: int64(p.Files[i].Insertions),
+			Deletions:  int64(p.Files[i].Deletions),
+		})
+	}
+
+	return b
+}
+
+func newApprovals(a []events.Approval) []*Approval {
+	var b []*Approval
+
+	for i := range a {
+		b = append(b, &Approval{
+			Type:        a[i].Type,
+			Description: a[i].Description,
+			Value:       a[i].Value,
+			OldValue:    a[i].OldValue,
+			GrantedOn:   a[i].GrantedOn,
+			Author:      newAccount(&a[i].Author),
+			By:          newAccount(&a[i].By),
+		})
+	}
+
+	return b
+}
+
+func newAccount(a *events.Account) *Account {
+	if *a == (events.Account{}) {
+		return nil
+	}
+
+	return &Account{Name: a.Name, Email: a.Email, Username: a.Username}
+}
+
+func newRefUpdate(r *events.RefUpdate) *RefUpdate {
+	if *r == (events.RefUpdate{}) {
+		return nil
+	}
+
+	return &RefUpdate{OldRev: r.OldRev, NewRev: r.NewRev, RefName: r.RefName, Project: r.Project}
+}
+
+func newRefUpdates(r []events.RefUpdate) []*RefUpdate {
+	var b []*RefUpdate
+
+	for i := range r {
+		b = append(b, &RefUpdate{OldRev: r[i].OldRev, NewRev: r[i].NewRev, RefName: r[i].RefName, Project: r[i].Project})
+	}
+
+	return b
+}
+
+func newDependency(d *events.Dependency) *Dependency {
+	if *d == (events.Dependency{}) {
+		return nil
+	}
+
+	return &Dependency{
+		Id:                d.ID,
+		Number:            d.Number,
+		Revision:          d.Revision,
+		Ref:               d.Ref,
+		IsCurrentPatchSet: d.IsCurrentPatchSet,
+	}
+}
+
+func newChangeKey(c *events.ChangeKey) *ChangeKey {
+	if c.Id == "" {
+		return nil
+	}
+
+	return &ChangeKey{Id: c.Id}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/events"
+)
+
+func TestNewEvent(t *testing.T) {
+	assert.Equal(t, (*Event)(nil), NewEvent(nil))
+
+	buf, err := os.ReadFile("../events/testdata/patchset-created.json")
+	assert.Equal(t, nil, err)
+
+	e := events.Event{}
+	_ = json.Unmarshal(buf, &e)
+
+	b := NewEvent(&e)
+	assert.Equal(t, events.EVENTS_PATCHSET_CREATED, b.GetType())
+	assert.Equal(t, "platform/build", b.GetChange().GetProject())
+	assert.Equal(t, int64(12345), b.GetChange().GetNumber())
+	assert.Equal(t, "owner", b.GetChange().GetOwner().GetUsername())
+	assert.Equal(t, int64(1672567100), b.GetChange().GetLastUpdated())
+	assert.Equal(t, int64(12000), b.GetChange().GetCherryPickOfChange())
+	assert.Equal(t, []string{"ci"}, b.GetChange().GetHashtags())
+	assert.Equal(t, int64(2), b.GetPatchSet().GetNumber())
+	assert.Equal(t, 2, len(b.GetPatchSet().GetFiles()))
+	assert.Equal(t, "build/rule.bzl", b.GetPatchSet().GetFiles()[1].GetFile())
+	assert.Equal(t, int64(-1), b.GetPatchSet().GetSizeDeletions())
+	assert.Equal(t, "uploader", b.GetUploader().GetUsername())
+	assert.Equal(t, "I0123456789abcdef0123456789abcdef01234567", b.GetChangeKey().GetId())
+	assert.Equal(t, int64(1672567200), b.GetEventCreatedOn())
+
+	assert.Equal(t, (*Account)(nil), b.GetAbandoner())
+	assert.Equal(t, (*RefUpdate)(nil), b.GetRefUpdate())
+	assert.Equal(t, (*PatchSet)(nil), b.GetChange().GetCurrentPatchSet())
+
+	buf, _ = os.ReadFile("../events/testdata/batch-ref-updated.json")
+
+	e = events.Event{}
+	_ = json.Unmarshal(buf, &e)
+
+	b = NewEvent(&e)
+	assert.Equal(t, (*Change)(nil), b.GetChange())
+	assert.Equal(t, len(e.RefUpdates), len(b.GetRefUpdates()))
+	assert.Equal(t, e.RefUpdates[0].RefName, b.GetRefUpdates()[0].GetRefName())
+}
@@ -0,0 +1,2212 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        (unknown)
+// source: api/events.proto
+
+package api
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type QueryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Unix time in seconds
+	Since  int64   `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	Until  int64   `protobuf:"varint,2,opt,name=until,proto3" json:"until,omitempty"`
+	Filter *Filter `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Defaults to 100, which is also the maximum
+	PageSize  int32  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_api_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *QueryRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *QueryRequest) GetUntil() int64 {
+	if x != nil {
+		return x.Until
+	}
+	return 0
+}
+
+func (x *QueryRequest) GetFilter() *Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *QueryRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type QueryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Records []*Record `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	// Empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+}
+
+func (x *QueryResponse) Reset() {
+	*x = QueryResponse{}
+	mi := &file_api_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryResponse) ProtoMessage() {}
+
+func (x *QueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
+func (*QueryResponse) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *QueryResponse) GetRecords() []*Record {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *QueryResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *Filter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Resume after the record with this ID (0: new events only)
+	Cursor uint64 `protobuf:"varint,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_api_events_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubscribeRequest) GetFilter() *Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetCursor() uint64 {
+	if x != nil {
+		return x.Cursor
+	}
+	return 0
+}
+
+// Filter - All the conditions set have to match
+type Filter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Types    []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	Projects []string `protobuf:"bytes,2,rep,name=projects,proto3" json:"projects,omitempty"`
+	// Name of the trigger rule to match
+	Trigger string `protobuf:"bytes,3,opt,name=trigger,proto3" json:"trigger,omitempty"`
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_api_events_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Filter) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *Filter) GetProjects() []string {
+	if x != nil {
+		return x.Projects
+	}
+	return nil
+}
+
+func (x *Filter) GetTrigger() string {
+	if x != nil {
+		return x.Trigger
+	}
+	return ""
+}
+
+type Record struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// ID of the stored event, used as a cursor
+	Id    uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Event *Event `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	// Event as sent by Gerrit, in JSON
+	Raw []byte `protobuf:"bytes,3,opt,name=raw,proto3" json:"raw,omitempty"`
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	mi := &file_api_events_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Record) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Record) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Record) GetRaw() []byte {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+// Event - Gerrit event
+// https://gerrit-review.googlesource.com/Documentation/cmd-stream-events.html#events
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type           string       `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Change         *Change      `protobuf:"bytes,2,opt,name=change,proto3" json:"change,omitempty"`
+	PatchSet       *PatchSet    `protobuf:"bytes,3,opt,name=patch_set,json=patchSet,proto3" json:"patch_set,omitempty"`
+	Approvals      []*Approval  `protobuf:"bytes,4,rep,name=approvals,proto3" json:"approvals,omitempty"`
+	Abandoner      *Account     `protobuf:"bytes,5,opt,name=abandoner,proto3" json:"abandoner,omitempty"`
+	Adder          *Account     `protobuf:"bytes,6,opt,name=adder,proto3" json:"adder,omitempty"`
+	Changer        *Account     `protobuf:"bytes,7,opt,name=changer,proto3" json:"changer,omitempty"`
+	Deleter        *Account     `protobuf:"bytes,8,opt,name=deleter,proto3" json:"deleter,omitempty"`
+	Submitter      *Account     `protobuf:"bytes,9,opt,name=submitter,proto3" json:"submitter,omitempty"`
+	Remover        *Account     `protobuf:"bytes,10,opt,name=remover,proto3" json:"remover,omitempty"`
+	Restorer       *Account     `protobuf:"bytes,11,opt,name=restorer,proto3" json:"restorer,omitempty"`
+	Author         *Account     `protobuf:"bytes,12,opt,name=author,proto3" json:"author,omitempty"`
+	Uploader       *Account     `protobuf:"bytes,13,opt,name=uploader,proto3" json:"uploader,omitempty"`
+	Editor         *Account     `protobuf:"bytes,14,opt,name=editor,proto3" json:"editor,omitempty"`
+	Reviewer       *Account     `protobuf:"bytes,15,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	NewRev         string       `protobuf:"bytes,16,opt,name=new_rev,json=newRev,proto3" json:"new_rev,omitempty"`
+	OldAssignee    *Account     `protobuf:"bytes,17,opt,name=old_assignee,json=oldAssignee,proto3" json:"old_assignee,omitempty"`
+	OldTopic       string       `protobuf:"bytes,18,opt,name=old_topic,json=oldTopic,proto3" json:"old_topic,omitempty"`
+	Reason         string       `protobuf:"bytes,19,opt,name=reason,proto3" json:"reason,omitempty"`
+	Comment        string       `protobuf:"bytes,20,opt,name=comment,proto3" json:"comment,omitempty"`
+	Added          []string     `protobuf:"bytes,21,rep,name=added,proto3" json:"added,omitempty"`
+	Removed        []string     `protobuf:"bytes,22,rep,name=removed,proto3" json:"removed,omitempty"`
+	Hashtags       []string     `protobuf:"bytes,23,rep,name=hashtags,proto3" json:"hashtags,omitempty"`
+	ProjectName    string       `protobuf:"bytes,24,opt,name=project_name,json=projectName,proto3" json:"project_name,omitempty"`
+	ProjectHead    string       `protobuf:"bytes,25,opt,name=project_head,json=projectHead,proto3" json:"project_head,omitempty"`
+	OldHead        string       `protobuf:"bytes,26,opt,name=old_head,json=oldHead,proto3" json:"old_head,omitempty"`
+	NewHead        string       `protobuf:"bytes,27,opt,name=new_head,json=newHead,proto3" json:"new_head,omitempty"`
+	Project        string       `protobuf:"bytes,28,opt,name=project,proto3" json:"project,omitempty"`
+	RefName        string       `protobuf:"bytes,29,opt,name=ref_name,json=refName,proto3" json:"ref_name,omitempty"`
+	RefUpdate      *RefUpdate   `protobuf:"bytes,30,opt,name=ref_update,json=refUpdate,proto3" json:"ref_update,omitempty"`
+	RefUpdates     []*RefUpdate `protobuf:"bytes,31,rep,name=ref_updates,json=refUpdates,proto3" json:"ref_updates,omitempty"`
+	ChangeKey      *ChangeKey   `protobuf:"bytes,32,opt,name=change_key,json=changeKey,proto3" json:"change_key,omitempty"`
+	EventCreatedOn int64        `protobuf:"varint,33,opt,name=event_created_on,json=eventCreatedOn,proto3" json:"event_created_on,omitempty"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_api_events_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetChange() *Change {
+	if x != nil {
+		return x.Change
+	}
+	return nil
+}
+
+func (x *Event) GetPatchSet() *PatchSet {
+	if x != nil {
+		return x.PatchSet
+	}
+	return nil
+}
+
+func (x *Event) GetApprovals() []*Approval {
+	if x != nil {
+		return x.Approvals
+	}
+	return nil
+}
+
+func (x *Event) GetAbandoner() *Account {
+	if x != nil {
+		return x.Abandoner
+	}
+	return nil
+}
+
+func (x *Event) GetAdder() *Account {
+	if x != nil {
+		return x.Adder
+	}
+	return nil
+}
+
+func (x *Event) GetChanger() *Account {
+	if x != nil {
+		return x.Changer
+	}
+	return nil
+}
+
+func (x *Event) GetDeleter() *Account {
+	if x != nil {
+		return x.Deleter
+	}
+	return nil
+}
+
+func (x *Event) GetSubmitter() *Account {
+	if x != nil {
+		return x.Submitter
+	}
+	return nil
+}
+
+func (x *Event) GetRemover() *Account {
+	if x != nil {
+		return x.Remover
+	}
+	return nil
+}
+
+func (x *Event) GetRestorer() *Account {
+	if x != nil {
+		return x.Restorer
+	}
+	return nil
+}
+
+func (x *Event) GetAuthor() *Account {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *Event) GetUploader() *Account {
+	if x != nil {
+		return x.Uploader
+	}
+	return nil
+}
+
+func (x *Event) GetEditor() *Account {
+	if x != nil {
+		return x.Editor
+	}
+	return nil
+}
+
+func (x *Event) GetReviewer() *Account {
+	if x != nil {
+		return x.Reviewer
+	}
+	return nil
+}
+
+func (x *Event) GetNewRev() string {
+	if x != nil {
+		return x.NewRev
+	}
+	return ""
+}
+
+func (x *Event) GetOldAssignee() *Account {
+	if x != nil {
+		return x.OldAssignee
+	}
+	return nil
+}
+
+func (x *Event) GetOldTopic() string {
+	if x != nil {
+		return x.OldTopic
+	}
+	return ""
+}
+
+func (x *Event) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Event) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *Event) GetAdded() []string {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *Event) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *Event) GetHashtags() []string {
+	if x != nil {
+		return x.Hashtags
+	}
+	return nil
+}
+
+func (x *Event) GetProjectName() string {
+	if x != nil {
+		return x.ProjectName
+	}
+	return ""
+}
+
+func (x *Event) GetProjectHead() string {
+	if x != nil {
+		return x.ProjectHead
+	}
+	return ""
+}
+
+func (x *Event) GetOldHead() string {
+	if x != nil {
+		return x.OldHead
+	}
+	return ""
+}
+
+func (x *Event) GetNewHead() string {
+	if x != nil {
+		return x.NewHead
+	}
+	return ""
+}
+
+func (x *Event) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Event) GetRefName() string {
+	if x != nil {
+		return x.RefName
+	}
+	return ""
+}
+
+func (x *Event) GetRefUpdate() *RefUpdate {
+	if x != nil {
+		return x.RefUpdate
+	}
+	return nil
+}
+
+func (x *Event) GetRefUpdates() []*RefUpdate {
+	if x != nil {
+		return x.RefUpdates
+	}
+	return nil
+}
+
+func (x *Event) GetChangeKey() *ChangeKey {
+	if x != nil {
+		return x.ChangeKey
+	}
+	return nil
+}
+
+func (x *Event) GetEventCreatedOn() int64 {
+	if x != nil {
+		return x.EventCreatedOn
+	}
+	return 0
+}
+
+// Change - The Gerrit change being reviewed, or that was already reviewed
+// https://gerrit-review.googlesource.com/Documentation/json.html#change
+type Change struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Project              string          `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Branch               string          `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`
+	Topic                string          `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`
+	Id                   string          `protobuf:"bytes,4,opt,name=id,proto3" json:"id,omitempty"`
+	Number               int64           `protobuf:"varint,5,opt,name=number,proto3" json:"number,omitempty"`
+	Subject              string          `protobuf:"bytes,6,opt,name=subject,proto3" json:"subject,omitempty"`
+	Owner                *Account        `protobuf:"bytes,7,opt,name=owner,proto3" json:"owner,omitempty"`
+	Url                  string          `protobuf:"bytes,8,opt,name=url,proto3" json:"url,omitempty"`
+	CommitMessage        string          `protobuf:"bytes,9,opt,name=commit_message,json=commitMessage,proto3" json:"commit_message,omitempty"`
+	Hashtags             []string        `protobuf:"bytes,10,rep,name=hashtags,proto3" json:"hashtags,omitempty"`
+	CreatedOn            int64           `protobuf:"varint,11,opt,name=created_on,json=createdOn,proto3" json:"created_on,omitempty"`
+	LastUpdated          int64           `protobuf:"varint,12,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	Open                 bool            `protobuf:"varint,13,opt,name=open,proto3" json:"open,omitempty"`
+	Private              bool            `protobuf:"varint,14,opt,name=private,proto3" json:"private,omitempty"`
+	Wip                  bool            `protobuf:"varint,15,opt,name=wip,proto3" json:"wip,omitempty"`
+	Assignee             *Account        `protobuf:"bytes,16,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	SubmitType           string          `protobuf:"bytes,17,opt,name=submit_type,json=submitType,proto3" json:"submit_type,omitempty"`
+	CherryPickOfChange   int64           `protobuf:"varint,18,opt,name=cherry_pick_of_change,json=cherryPickOfChange,proto3" json:"cherry_pick_of_change,omitempty"`
+	CherryPickOfPatchSet int64           `protobuf:"varint,19,opt,name=cherry_pick_of_patch_set,json=cherryPickOfPatchSet,proto3" json:"cherry_pick_of_patch_set,omitempty"`
+	Status               string          `protobuf:"bytes,20,opt,name=status,proto3" json:"status,omitempty"`
+	Comments             []*Message      `protobuf:"bytes,21,rep,name=comments,proto3" json:"comments,omitempty"`
+	TrackingIds          []*TrackingID   `protobuf:"bytes,22,rep,name=tracking_ids,json=trackingIds,proto3" json:"tracking_ids,omitempty"`
+	CurrentPatchSet      *PatchSet       `protobuf:"bytes,23,opt,name=current_patch_set,json=currentPatchSet,proto3" json:"current_patch_set,omitempty"`
+	PatchSets            []*PatchSet     `protobuf:"bytes,24,rep,name=patch_sets,json=patchSets,proto3" json:"patch_sets,omitempty"`
+	DependsOn            *Dependency     `protobuf:"bytes,25,opt,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	NeededBy             *Dependency     `protobuf:"bytes,26,opt,name=needed_by,json=neededBy,proto3" json:"needed_by,omitempty"`
+	SubmitRecords        []*SubmitRecord `protobuf:"bytes,27,rep,name=submit_records,json=submitRecords,proto3" json:"submit_records,omitempty"`
+	AllReviewers         []*Account      `protobuf:"bytes,28,rep,name=all_reviewers,json=allReviewers,proto3" json:"all_reviewers,omitempty"`
+}
+
+func (x *Change) Reset() {
+	*x = Change{}
+	mi := &file_api_events_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Change) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Change) ProtoMessage() {}
+
+func (x *Change) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Change.ProtoReflect.Descriptor instead.
+func (*Change) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Change) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Change) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *Change) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Change) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Change) GetNumber() int64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Change) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Change) GetOwner() *Account {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+func (x *Change) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Change) GetCommitMessage() string {
+	if x != nil {
+		return x.CommitMessage
+	}
+	return ""
+}
+
+func (x *Change) GetHashtags() []string {
+	if x != nil {
+		return x.Hashtags
+	}
+	return nil
+}
+
+func (x *Change) GetCreatedOn() int64 {
+	if x != nil {
+		return x.CreatedOn
+	}
+	return 0
+}
+
+func (x *Change) GetLastUpdated() int64 {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return 0
+}
+
+func (x *Change) GetOpen() bool {
+	if x != nil {
+		return x.Open
+	}
+	return false
+}
+
+func (x *Change) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *Change) GetWip() bool {
+	if x != nil {
+		return x.Wip
+	}
+	return false
+}
+
+func (x *Change) GetAssignee() *Account {
+	if x != nil {
+		return x.Assignee
+	}
+	return nil
+}
+
+func (x *Change) GetSubmitType() string {
+	if x != nil {
+		return x.SubmitType
+	}
+	return ""
+}
+
+func (x *Change) GetCherryPickOfChange() int64 {
+	if x != nil {
+		return x.CherryPickOfChange
+	}
+	return 0
+}
+
+func (x *Change) GetCherryPickOfPatchSet() int64 {
+	if x != nil {
+		return x.CherryPickOfPatchSet
+	}
+	return 0
+}
+
+func (x *Change) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Change) GetComments() []*Message {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+func (x *Change) GetTrackingIds() []*TrackingID {
+	if x != nil {
+		return x.TrackingIds
+	}
+	return nil
+}
+
+func (x *Change) GetCurrentPatchSet() *PatchSet {
+	if x != nil {
+		return x.CurrentPatchSet
+	}
+	return nil
+}
+
+func (x *Change) GetPatchSets() []*PatchSet {
+	if x != nil {
+		return x.PatchSets
+	}
+	return nil
+}
+
+func (x *Change) GetDependsOn() *Dependency {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+func (x *Change) GetNeededBy() *Dependency {
+	if x != nil {
+		return x.NeededBy
+	}
+	return nil
+}
+
+func (x *Change) GetSubmitRecords() []*SubmitRecord {
+	if x != nil {
+		return x.SubmitRecords
+	}
+	return nil
+}
+
+func (x *Change) GetAllReviewers() []*Account {
+	if x != nil {
+		return x.AllReviewers
+	}
+	return nil
+}
+
+// TrackingID - A link to an issue tracking system
+// https://gerrit-review.googlesource.com/Documentation/json.html#trackingid
+type TrackingID struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	System string `protobuf:"bytes,1,opt,name=system,proto3" json:"system,omitempty"`
+	Id     string `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *TrackingID) Reset() {
+	*x = TrackingID{}
+	mi := &file_api_events_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackingID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackingID) ProtoMessage() {}
+
+func (x *TrackingID) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackingID.ProtoReflect.Descriptor instead.
+func (*TrackingID) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TrackingID) GetSystem() string {
+	if x != nil {
+		return x.System
+	}
+	return ""
+}
+
+func (x *TrackingID) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Account - A user account
+// https://gerrit-review.googlesource.com/Documentation/json.html#account
+type Account struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email    string `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_api_events_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Account) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Account) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Account) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// PatchSet - Refers to a specific patchset within a change
+// https://gerrit-review.googlesource.com/Documentation/json.html#patchSet
+type PatchSet struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Number         int64              `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Revision       string             `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	Parents        []string           `protobuf:"bytes,3,rep,name=parents,proto3" json:"parents,omitempty"`
+	Ref            string             `protobuf:"bytes,4,opt,name=ref,proto3" json:"ref,omitempty"`
+	Uploader       *Account           `protobuf:"bytes,5,opt,name=uploader,proto3" json:"uploader,omitempty"`
+	Author         *Account           `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
+	CreatedOn      int64              `protobuf:"varint,7,opt,name=created_on,json=createdOn,proto3" json:"created_on,omitempty"`
+	IsDraft        bool               `protobuf:"varint,8,opt,name=is_draft,json=isDraft,proto3" json:"is_draft,omitempty"`
+	Kind           string             `protobuf:"bytes,9,opt,name=kind,proto3" json:"kind,omitempty"`
+	Approvals      []*Approval        `protobuf:"bytes,10,rep,name=approvals,proto3" json:"approvals,omitempty"`
+	Comments       []*PatchSetComment `protobuf:"bytes,11,rep,name=comments,proto3" json:"comments,omitempty"`
+	Files          []*File            `protobuf:"bytes,12,rep,name=files,proto3" json:"files,omitempty"`
+	SizeInsertions int64              `protobuf:"varint,13,opt,name=size_insertions,json=sizeInsertions,proto3" json:"size_insertions,omitempty"`
+	SizeDeletions  int64              `protobuf:"varint,14,opt,name=size_deletions,json=sizeDeletions,proto3" json:"size_deletions,omitempty"`
+}
+
+func (x *PatchSet) Reset() {
+	*x = PatchSet{}
+	mi := &file_api_events_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchSet) ProtoMessage() {}
+
+func (x *PatchSet) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchSet.ProtoReflect.Descriptor instead.
+func (*PatchSet) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PatchSet) GetNumber() int64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *PatchSet) GetRevision() string {
+	if x != nil {
+		return x.Revision
+	}
+	return ""
+}
+
+func (x *PatchSet) GetParents() []string {
+	if x != nil {
+		return x.Parents
+	}
+	return nil
+}
+
+func (x *PatchSet) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *PatchSet) GetUploader() *Account {
+	if x != nil {
+		return x.Uploader
+	}
+	return nil
+}
+
+func (x *PatchSet) GetAuthor() *Account {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *PatchSet) GetCreatedOn() int64 {
+	if x != nil {
+		return x.CreatedOn
+	}
+	return 0
+}
+
+func (x *PatchSet) GetIsDraft() bool {
+	if x != nil {
+		return x.IsDraft
+	}
+	return false
+}
+
+func (x *PatchSet) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *PatchSet) GetApprovals() []*Approval {
+	if x != nil {
+		return x.Approvals
+	}
+	return nil
+}
+
+func (x *PatchSet) GetComments() []*PatchSetComment {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+func (x *PatchSet) GetFiles() []*File {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *PatchSet) GetSizeInsertions() int64 {
+	if x != nil {
+		return x.SizeInsertions
+	}
+	return 0
+}
+
+func (x *PatchSet) GetSizeDeletions() int64 {
+	if x != nil {
+		return x.SizeDeletions
+	}
+	return 0
+}
+
+// Approval - Records the code review approval granted to a patch set
+// https://gerrit-review.googlesource.com/Documentation/json.html#approval
+type Approval struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type        string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Description string   `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Value       string   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	OldValue    string   `protobuf:"bytes,4,opt,name=old_value,json=oldValue,proto3" json:"old_value,omitempty"`
+	GrantedOn   int64    `protobuf:"varint,5,opt,name=granted_on,json=grantedOn,proto3" json:"granted_on,omitempty"`
+	Author      *Account `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
+	By          *Account `protobuf:"bytes,7,opt,name=by,proto3" json:"by,omitempty"`
+}
+
+func (x *Approval) Reset() {
+	*x = Approval{}
+	mi := &file_api_events_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Approval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Approval) ProtoMessage() {}
+
+func (x *Approval) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Approval.ProtoReflect.Descriptor instead.
+func (*Approval) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Approval) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Approval) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Approval) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Approval) GetOldValue() string {
+	if x != nil {
+		return x.OldValue
+	}
+	return ""
+}
+
+func (x *Approval) GetGrantedOn() int64 {
+	if x != nil {
+		return x.GrantedOn
+	}
+	return 0
+}
+
+func (x *Approval) GetAuthor() *Account {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *Approval) GetBy() *Account {
+	if x != nil {
+		return x.By
+	}
+	return nil
+}
+
+// RefUpdate - Information about a ref that was updated
+// https://gerrit-review.googlesource.com/Documentation/json.html#refUpdate
+type RefUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OldRev  string `protobuf:"bytes,1,opt,name=old_rev,json=oldRev,proto3" json:"old_rev,omitempty"`
+	NewRev  string `protobuf:"bytes,2,opt,name=new_rev,json=newRev,proto3" json:"new_rev,omitempty"`
+	RefName string `protobuf:"bytes,3,opt,name=ref_name,json=refName,proto3" json:"ref_name,omitempty"`
+	Project string `protobuf:"bytes,4,opt,name=project,proto3" json:"project,omitempty"`
+}
+
+func (x *RefUpdate) Reset() {
+	*x = RefUpdate{}
+	mi := &file_api_events_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefUpdate) ProtoMessage() {}
+
+func (x *RefUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefUpdate.ProtoReflect.Descriptor instead.
+func (*RefUpdate) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RefUpdate) GetOldRev() string {
+	if x != nil {
+		return x.OldRev
+	}
+	return ""
+}
+
+func (x *RefUpdate) GetNewRev() string {
+	if x != nil {
+		return x.NewRev
+	}
+	return ""
+}
+
+func (x *RefUpdate) GetRefName() string {
+	if x != nil {
+		return x.RefName
+	}
+	return ""
+}
+
+func (x *RefUpdate) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+// SubmitRecord - Information about the submit status of a change
+// https://gerrit-review.googlesource.com/Documentation/json.html#submitRecord
+type SubmitRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status string   `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Labels []*Label `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty"`
+}
+
+func (x *SubmitRecord) Reset() {
+	*x = SubmitRecord{}
+	mi := &file_api_events_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitRecord) ProtoMessage() {}
+
+func (x *SubmitRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitRecord.ProtoReflect.Descriptor instead.
+func (*SubmitRecord) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SubmitRecord) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SubmitRecord) GetLabels() []*Label {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// Label - Information about a code review label for a change
+// https://gerrit-review.googlesource.com/Documentation/json.html#label
+type Label struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Label  string   `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Status string   `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	By     *Account `protobuf:"bytes,3,opt,name=by,proto3" json:"by,omitempty"`
+}
+
+func (x *Label) Reset() {
+	*x = Label{}
+	mi := &file_api_events_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Label) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Label) ProtoMessage() {}
+
+func (x *Label) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Label.ProtoReflect.Descriptor instead.
+func (*Label) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Label) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Label) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Label) GetBy() *Account {
+	if x != nil {
+		return x.By
+	}
+	return nil
+}
+
+// Dependency - Information about a change or patchset dependency
+// https://gerrit-review.googlesource.com/Documentation/json.html#dependency
+type Dependency struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id                string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Number            string `protobuf:"bytes,2,opt,name=number,proto3" json:"number,omitempty"`
+	Revision          string `protobuf:"bytes,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	Ref               string `protobuf:"bytes,4,opt,name=ref,proto3" json:"ref,omitempty"`
+	IsCurrentPatchSet bool   `protobuf:"varint,5,opt,name=is_current_patch_set,json=isCurrentPatchSet,proto3" json:"is_current_patch_set,omitempty"`
+}
+
+func (x *Dependency) Reset() {
+	*x = Dependency{}
+	mi := &file_api_events_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Dependency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Dependency) ProtoMessage() {}
+
+func (x *Dependency) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Dependency.ProtoReflect.Descriptor instead.
+func (*Dependency) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Dependency) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Dependency) GetNumber() string {
+	if x != nil {
+		return x.Number
+	}
+	return ""
+}
+
+func (x *Dependency) GetRevision() string {
+	if x != nil {
+		return x.Revision
+	}
+	return ""
+}
+
+func (x *Dependency) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *Dependency) GetIsCurrentPatchSet() bool {
+	if x != nil {
+		return x.IsCurrentPatchSet
+	}
+	return false
+}
+
+// Message - Comment added on a change by a reviewer
+// https://gerrit-review.googlesource.com/Documentation/json.html#message
+type Message struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp string   `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Reviewer  *Account `protobuf:"bytes,2,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	Message   string   `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_api_events_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Message) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *Message) GetReviewer() *Account {
+	if x != nil {
+		return x.Reviewer
+	}
+	return nil
+}
+
+func (x *Message) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// PatchSetComment - Comment added on a patchset by a reviewer
+// https://gerrit-review.googlesource.com/Documentation/json.html#patchsetcomment
+type PatchSetComment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	File     string   `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Line     int64    `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Reviewer *Account `protobuf:"bytes,3,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	Message  string   `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *PatchSetComment) Reset() {
+	*x = PatchSetComment{}
+	mi := &file_api_events_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchSetComment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchSetComment) ProtoMessage() {}
+
+func (x *PatchSetComment) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchSetComment.ProtoReflect.Descriptor instead.
+func (*PatchSetComment) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PatchSetComment) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *PatchSetComment) GetLine() int64 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *PatchSetComment) GetReviewer() *Account {
+	if x != nil {
+		return x.Reviewer
+	}
+	return nil
+}
+
+func (x *PatchSetComment) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// File - Information about a patch on a file
+// https://gerrit-review.googlesource.com/Documentation/json.html#file
+type File struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	File       string `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	FileOld    string `protobuf:"bytes,2,opt,name=file_old,json=fileOld,proto3" json:"file_old,omitempty"`
+	Type       string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Insertions int64  `protobuf:"varint,4,opt,name=insertions,proto3" json:"insertions,omitempty"`
+	Deletions  int64  `protobuf:"varint,5,opt,name=deletions,proto3" json:"deletions,omitempty"`
+}
+
+func (x *File) Reset() {
+	*x = File{}
+	mi := &file_api_events_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*File) ProtoMessage() {}
+
+func (x *File) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use File.ProtoReflect.Descriptor instead.
+func (*File) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *File) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *File) GetFileOld() string {
+	if x != nil {
+		return x.FileOld
+	}
+	return ""
+}
+
+func (x *File) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *File) GetInsertions() int64 {
+	if x != nil {
+		return x.Insertions
+	}
+	return 0
+}
+
+func (x *File) GetDeletions() int64 {
+	if x != nil {
+		return x.Deletions
+	}
+	return 0
+}
+
+// ChangeKey - Change key for a change
+type ChangeKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *ChangeKey) Reset() {
+	*x = ChangeKey{}
+	mi := &file_api_events_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeKey) ProtoMessage() {}
+
+func (x *ChangeKey) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeKey.ProtoReflect.Descriptor instead.
+func (*ChangeKey) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ChangeKey) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_api_events_proto protoreflect.FileDescriptor
+
+var file_api_events_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x61, 0x70, 0x69, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x09, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x22, 0xa1, 0x01,
+	0x0a, 0x0c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x73,
+	0x69, 0x6e, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x12, 0x29, 0x0a, 0x06, 0x66, 0x69,
+	0x6c, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69,
+	0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69,
+	0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x22, 0x64, 0x0a, 0x0d, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x2b, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12,
+	0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61,
+	0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x55, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x06, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x22, 0x54,
+	0x0a, 0x06, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x79, 0x70, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x72,
+	0x69, 0x67, 0x67, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x72, 0x69,
+	0x67, 0x67, 0x65, 0x72, 0x22, 0x52, 0x0a, 0x06, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x26,
+	0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52,
+	0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x61, 0x77, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x03, 0x72, 0x61, 0x77, 0x22, 0x92, 0x0a, 0x0a, 0x05, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x06, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x12, 0x30, 0x0a, 0x09, 0x70, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x65, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65, 0x74, 0x52, 0x08, 0x70, 0x61, 0x74, 0x63, 0x68,
+	0x53, 0x65, 0x74, 0x12, 0x31, 0x0a, 0x09, 0x61, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x61, 0x6c, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x61, 0x6c, 0x52, 0x09, 0x61, 0x70, 0x70,
+	0x72, 0x6f, 0x76, 0x61, 0x6c, 0x73, 0x12, 0x30, 0x0a, 0x09, 0x61, 0x62, 0x61, 0x6e, 0x64, 0x6f,
+	0x6e, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x09, 0x61,
+	0x62, 0x61, 0x6e, 0x64, 0x6f, 0x6e, 0x65, 0x72, 0x12, 0x28, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x65,
+	0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x05, 0x61, 0x64, 0x64,
+	0x65, 0x72, 0x12, 0x2c, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x72, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x72,
+	0x12, 0x2c, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x72, 0x12, 0x30,
+	0x0a, 0x09, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x09, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x72,
+	0x12, 0x2c, 0x0a, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x72, 0x12, 0x2e,
+	0x0a, 0x08, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x52, 0x08, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x72, 0x12, 0x2a,
+	0x0a, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x52, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x12, 0x2e, 0x0a, 0x08, 0x75, 0x70,
+	0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x52, 0x08, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72, 0x12, 0x2a, 0x0a, 0x06, 0x65, 0x64,
+	0x69, 0x74, 0x6f, 0x72, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x06,
+	0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x12, 0x2e, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x65, 0x72, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x08, 0x72, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x65, 0x77, 0x5f, 0x72, 0x65,
+	0x76, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x65, 0x77, 0x52, 0x65, 0x76, 0x12,
+	0x35, 0x0a, 0x0c, 0x6f, 0x6c, 0x64, 0x5f, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x65, 0x18,
+	0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x0b, 0x6f, 0x6c, 0x64, 0x41, 0x73,
+	0x73, 0x69, 0x67, 0x6e, 0x65, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6f, 0x6c, 0x64, 0x5f, 0x74, 0x6f,
+	0x70, 0x69, 0x63, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6f, 0x6c, 0x64, 0x54, 0x6f,
+	0x70, 0x69, 0x63, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x13, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
+	0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x18, 0x15,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x72,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x18, 0x16, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67,
+	0x73, 0x18, 0x17, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67,
+	0x73, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x5f,
+	0x68, 0x65, 0x61, 0x64, 0x18, 0x19, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x6a,
+	0x65, 0x63, 0x74, 0x48, 0x65, 0x61, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6f, 0x6c, 0x64, 0x5f, 0x68,
+	0x65, 0x61, 0x64, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6f, 0x6c, 0x64, 0x48, 0x65,
+	0x61, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x65, 0x77, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x18, 0x1b,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x65, 0x77, 0x48, 0x65, 0x61, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x72, 0x65, 0x66, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x66, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x33, 0x0a, 0x0a, 0x72, 0x65, 0x66, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x66, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x09, 0x72, 0x65,
+	0x66, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x35, 0x0a, 0x0b, 0x72, 0x65, 0x66, 0x5f, 0x75,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x73, 0x18, 0x1f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x66, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x52, 0x0a, 0x72, 0x65, 0x66, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x73, 0x12, 0x33,
+	0x0a, 0x0a, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x20, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x4b, 0x65, 0x79, 0x12, 0x28, 0x0a, 0x10, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x5f, 0x6f, 0x6e, 0x18, 0x21, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x6e, 0x22, 0xa9, 0x08,
+	0x0a, 0x06, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6a,
+	0x65, 0x63, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65,
+	0x63, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f,
+	0x70, 0x69, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x62, 0x6a,
+	0x65, 0x63, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x75, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x12, 0x28, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03,
+	0x75, 0x72, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x25,
+	0x0a, 0x0e, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67,
+	0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67,
+	0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x6f, 0x6e, 0x18,
+	0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x6e,
+	0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64,
+	0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6f, 0x70, 0x65, 0x6e, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x04, 0x6f, 0x70, 0x65, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61,
+	0x74, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x77, 0x69, 0x70, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03,
+	0x77, 0x69, 0x70, 0x12, 0x2e, 0x0a, 0x08, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x65, 0x18,
+	0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x08, 0x61, 0x73, 0x73, 0x69, 0x67,
+	0x6e, 0x65, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x74,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x31, 0x0a, 0x15, 0x63, 0x68, 0x65, 0x72, 0x72, 0x79, 0x5f, 0x70,
+	0x69, 0x63, 0x6b, 0x5f, 0x6f, 0x66, 0x5f, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x12, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x12, 0x63, 0x68, 0x65, 0x72, 0x72, 0x79, 0x50, 0x69, 0x63, 0x6b, 0x4f,
+	0x66, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x36, 0x0a, 0x18, 0x63, 0x68, 0x65, 0x72, 0x72,
+	0x79, 0x5f, 0x70, 0x69, 0x63, 0x6b, 0x5f, 0x6f, 0x66, 0x5f, 0x70, 0x61, 0x74, 0x63, 0x68, 0x5f,
+	0x73, 0x65, 0x74, 0x18, 0x13, 0x20, 0x01, 0x28, 0x03, 0x52, 0x14, 0x63, 0x68, 0x65, 0x72, 0x72,
+	0x79, 0x50, 0x69, 0x63, 0x6b, 0x4f, 0x66, 0x50, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2e, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x65,
+	0x6e, 0x74, 0x73, 0x18, 0x15, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x08, 0x63,
+	0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x38, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x63, 0x6b,
+	0x69, 0x6e, 0x67, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x16, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x69,
+	0x6e, 0x67, 0x49, 0x44, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x49, 0x64,
+	0x73, 0x12, 0x3f, 0x0a, 0x11, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x70, 0x61, 0x74,
+	0x63, 0x68, 0x5f, 0x73, 0x65, 0x74, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65,
+	0x74, 0x52, 0x0f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x50, 0x61, 0x74, 0x63, 0x68, 0x53,
+	0x65, 0x74, 0x12, 0x32, 0x0a, 0x0a, 0x70, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x65, 0x74, 0x73,
+	0x18, 0x18, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65, 0x74, 0x52, 0x09, 0x70, 0x61, 0x74,
+	0x63, 0x68, 0x53, 0x65, 0x74, 0x73, 0x12, 0x34, 0x0a, 0x0a, 0x64, 0x65, 0x70, 0x65, 0x6e, 0x64,
+	0x73, 0x5f, 0x6f, 0x6e, 0x18, 0x19, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63,
+	0x79, 0x52, 0x09, 0x64, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x73, 0x4f, 0x6e, 0x12, 0x32, 0x0a, 0x09,
+	0x6e, 0x65, 0x65, 0x64, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x70, 0x65,
+	0x6e, 0x64, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x08, 0x6e, 0x65, 0x65, 0x64, 0x65, 0x64, 0x42, 0x79,
+	0x12, 0x3e, 0x0a, 0x0e, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x73, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x52, 0x0d, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73,
+	0x12, 0x37, 0x0a, 0x0d, 0x61, 0x6c, 0x6c, 0x5f, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72,
+	0x73, 0x18, 0x1c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x0c, 0x61, 0x6c, 0x6c,
+	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x73, 0x22, 0x34, 0x0a, 0x0a, 0x54, 0x72, 0x61,
+	0x63, 0x6b, 0x69, 0x6e, 0x67, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x73, 0x74, 0x65,
+	0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22,
+	0x4f, 0x0a, 0x07, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65,
+	0x6d, 0x61, 0x69, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65,
+	0x22, 0xf6, 0x03, 0x0a, 0x08, 0x50, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x72,
+	0x65, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x72, 0x65, 0x66, 0x12, 0x2e, 0x0a,
+	0x08, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x52, 0x08, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72, 0x12, 0x2a, 0x0a,
+	0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x52, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x5f, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x73, 0x5f, 0x64,
+	0x72, 0x61, 0x66, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x44, 0x72,
+	0x61, 0x66, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x31, 0x0a, 0x09, 0x61, 0x70, 0x70, 0x72, 0x6f,
+	0x76, 0x61, 0x6c, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x61, 0x6c, 0x52,
+	0x09, 0x61, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x61, 0x6c, 0x73, 0x12, 0x36, 0x0a, 0x08, 0x63, 0x6f,
+	0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65,
+	0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e,
+	0x74, 0x73, 0x12, 0x25, 0x0a, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0f, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69,
+	0x6c, 0x65, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x73, 0x69, 0x7a,
+	0x65, 0x5f, 0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0e, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x69, 0x7a, 0x65, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x73, 0x69, 0x7a, 0x65,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xe2, 0x01, 0x0a, 0x08, 0x41, 0x70,
+	0x70, 0x72, 0x6f, 0x76, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6f, 0x6c, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6f, 0x6c, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12,
+	0x1d, 0x0a, 0x0a, 0x67, 0x72, 0x61, 0x6e, 0x74, 0x65, 0x64, 0x5f, 0x6f, 0x6e, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x67, 0x72, 0x61, 0x6e, 0x74, 0x65, 0x64, 0x4f, 0x6e, 0x12, 0x2a,
+	0x0a, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x52, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x12, 0x22, 0x0a, 0x02, 0x62, 0x79,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x02, 0x62, 0x79, 0x22, 0x72,
+	0x0a, 0x09, 0x52, 0x65, 0x66, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x6f,
+	0x6c, 0x64, 0x5f, 0x72, 0x65, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x6c,
+	0x64, 0x52, 0x65, 0x76, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x65, 0x77, 0x5f, 0x72, 0x65, 0x76, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x65, 0x77, 0x52, 0x65, 0x76, 0x12, 0x19, 0x0a,
+	0x08, 0x72, 0x65, 0x66, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x72, 0x65, 0x66, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6a,
+	0x65, 0x63, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65,
+	0x63, 0x74, 0x22, 0x50, 0x0a, 0x0c, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x28, 0x0a, 0x06, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x52, 0x06, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x22, 0x59, 0x0a, 0x05, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x14, 0x0a,
+	0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x22, 0x0a, 0x02, 0x62,
+	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x02, 0x62, 0x79, 0x22,
+	0x93, 0x01, 0x0a, 0x0a, 0x44, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x72, 0x65, 0x66, 0x12, 0x2f, 0x0a, 0x14, 0x69, 0x73, 0x5f, 0x63, 0x75, 0x72, 0x72, 0x65,
+	0x6e, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x65, 0x74, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x11, 0x69, 0x73, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x50, 0x61, 0x74,
+	0x63, 0x68, 0x53, 0x65, 0x74, 0x22, 0x71, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x2e,
+	0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x83, 0x01, 0x0a, 0x0f, 0x50, 0x61, 0x74,
+	0x63, 0x68, 0x53, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
+	0x6c, 0x69, 0x6e, 0x65, 0x12, 0x2e, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x87,
+	0x01, 0x0a, 0x04, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x66,
+	0x69, 0x6c, 0x65, 0x5f, 0x6f, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x66,
+	0x69, 0x6c, 0x65, 0x4f, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e,
+	0x73, 0x65, 0x72, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a,
+	0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x64,
+	0x65, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x1b, 0x0a, 0x09, 0x43, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x4b, 0x65, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x32, 0x83, 0x01, 0x0a, 0x06, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x12, 0x3a, 0x0a, 0x05, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x17, 0x2e, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x18, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x51,
+	0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x09,
+	0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x1b, 0x2e, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x30, 0x01, 0x42, 0x25, 0x5a, 0x23, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x65, 0x72, 0x72, 0x69, 0x74,
+	0x74, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x61,
+	0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_api_events_proto_rawDescOnce sync.Once
+	file_api_events_proto_rawDescData = file_api_events_proto_rawDesc
+)
+
+func file_api_events_proto_rawDescGZIP() []byte {
+	file_api_events_proto_rawDescOnce.Do(func() {
+		file_api_events_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_events_proto_rawDescData)
+	})
+	return file_api_events_proto_rawDescData
+}
+
+var file_api_events_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_api_events_proto_goTypes = []any{
+	(*QueryRequest)(nil),     // 0: events.v1.QueryRequest
+	(*QueryResponse)(nil),    // 1: events.v1.QueryResponse
+	(*SubscribeRequest)(nil), // 2: events.v1.SubscribeRequest
+	(*Filter)(nil),           // 3: events.v1.Filter
+	(*Record)(nil),           // 4: events.v1.Record
+	(*Event)(nil),            // 5: events.v1.Event
+	(*Change)(nil),           // 6: events.v1.Change
+	(*TrackingID)(nil),       // 7: events.v1.TrackingID
+	(*Account)(nil),          // 8: events.v1.Account
+	(*PatchSet)(nil),         // 9: events.v1.PatchSet
+	(*Approval)(nil),         // 10: events.v1.Approval
+	(*RefUpdate)(nil),        // 11: events.v1.RefUpdate
+	(*SubmitRecord)(nil),     // 12: events.v1.SubmitRecord
+	(*Label)(nil),            // 13: events.v1.Label
+	(*Dependency)(nil),       // 14: events.v1.Dependency
+	(*Message)(nil),          // 15: events.v1.Message
+	(*PatchSetComment)(nil),  // 16: events.v1.PatchSetComment
+	(*File)(nil),             // 17: events.v1.File
+	(*ChangeKey)(nil),        // 18: events.v1.ChangeKey
+}
+var file_api_events_proto_depIdxs = []int32{
+	3,  // 0: events.v1.QueryRequest.filter:type_name -> events.v1.Filter
+	4,  // 1: events.v1.QueryResponse.records:type_name -> events.v1.Record
+	3,  // 2: events.v1.SubscribeRequest.filter:type_name -> events.v1.Filter
+	5,  // 3: events.v1.Record.event:type_name -> events.v1.Event
+	6,  // 4: events.v1.Event.change:type_name -> events.v1.Change
+	9,  // 5: events.v1.Event.patch_set:type_name -> events.v1.PatchSet
+	10, // 6: events.v1.Event.approvals:type_name -> events.v1.Approval
+	8,  // 7: events.v1.Event.abandoner:type_name -> events.v1.Account
+	8,  // 8: events.v1.Event.adder:type_name -> events.v1.Account
+	8,  // 9: events.v1.Event.changer:type_name -> events.v1.Account
+	8,  // 10: events.v1.Event.deleter:type_name -> events.v1.Account
+	8,  // 11: events.v1.Event.submitter:type_name -> events.v1.Account
+	8,  // 12: events.v1.Event.remover:type_name -> events.v1.Account
+	8,  // 13: events.v1.Event.restorer:type_name -> events.v1.Account
+	8,  // 14: events.v1.Event.author:type_name -> events.v1.Account
+	8,  // 15: events.v1.Event.uploader:type_name -> events.v1.Account
+	8,  // 16: events.v1.Event.editor:type_name -> events.v1.Account
+	8,  // 17: events.v1.Event.reviewer:type_name -> events.v1.Account
+	8,  // 18: events.v1.Event.old_assignee:type_name -> events.v1.Account
+	11, // 19: events.v1.Event.ref_update:type_name -> events.v1.RefUpdate
+	11, // 20: events.v1.Event.ref_updates:type_name -> events.v1.RefUpdate
+	18, // 21: events.v1.Event.change_key:type_name -> events.v1.ChangeKey
+	8,  // 22: events.v1.Change.owner:type_name -> events.v1.Account
+	8,  // 23: events.v1.Change.assignee:type_name -> events.v1.Account
+	15, // 24: events.v1.Change.comments:type_name -> events.v1.Message
+	7,  // 25: events.v1.Change.tracking_ids:type_name -> events.v1.TrackingID
+	9,  // 26: events.v1.Change.current_patch_set:type_name -> events.v1.PatchSet
+	9,  // 27: events.v1.Change.patch_sets:type_name -> events.v1.PatchSet
+	14, // 28: events.v1.Change.depends_on:type_name -> events.v1.Dependency
+	14, // 29: events.v1.Change.needed_by:type_name -> events.v1.Dependency
+	12, // 30: events.v1.Change.submit_records:type_name -> events.v1.SubmitRecord
+	8,  // 31: events.v1.Change.all_reviewers:type_name -> events.v1.Account
+	8,  // 32: events.v1.PatchSet.uploader:type_name -> events.v1.Account
+	8,  // 33: events.v1.PatchSet.author:type_name -> events.v1.Account
+	10, // 34: events.v1.PatchSet.approvals:type_name -> events.v1.Approval
+	16, // 35: events.v1.PatchSet.comments:type_name -> events.v1.PatchSetComment
+	17, // 36: events.v1.PatchSet.files:type_name -> events.v1.File
+	8,  // 37: events.v1.Approval.author:type_name -> events.v1.Account
+	8,  // 38: events.v1.Approval.by:type_name -> events.v1.Account
+	13, // 39: events.v1.SubmitRecord.labels:type_name -> events.v1.Label
+	8,  // 40: events.v1.Label.by:type_name -> events.v1.Account
+	8,  // 41: events.v1.Message.reviewer:type_name -> events.v1.Account
+	8,  // 42: events.v1.PatchSetComment.reviewer:type_name -> events.v1.Account
+	0,  // 43: events.v1.Events.Query:input_type -> events.v1.QueryRequest
+	2,  // 44: events.v1.Events.Subscribe:input_type -> events.v1.SubscribeRequest
+	1,  // 45: events.v1.Events.Query:output_type -> events.v1.QueryResponse
+	4,  // 46: events.v1.Events.Subscribe:output_type -> events.v1.Record
+	45, // [45:47] is the sub-list for method output_type
+	43, // [43:45] is the sub-list for method input_type
+	43, // [43:43] is the sub-list for extension type_name
+	43, // [43:43] is the sub-list for extension extendee
+	0,  // [0:43] is the sub-list for field type_name
+}
+
+func init() { file_api_events_proto_init() }
+func file_api_events_proto_init() {
+	if File_api_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_api_events_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_events_proto_goTypes,
+		DependencyIndexes: file_api_events_proto_depIdxs,
+		MessageInfos:      file_api_events_proto_msgTypes,
+	}.Build()
+	File_api_events_proto = out.File
+	file_api_events_proto_rawDesc = nil
+	file_api_events_proto_goTypes = nil
+	file_api_events_proto_depIdxs = nil
+}
@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" // nolint:gosec
+	"crypto/subtle"
+	"encoding/base64"
+	nethttp "net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/gerrittrigger/events/config"
+)
+
+const (
+	ScopeAdmin = "admin"
+	ScopeRead  = "read"
+
+	bearerPrefix = "Bearer "
+	shaPrefix    = "{SHA}"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+type Auth interface {
+	Init(context.Context) error
+	Deinit(context.Context) error
+	Authenticate(context.Context, *nethttp.Request) (*Identity, error)
+	Enabled(context.Context) bool
+	Reload(context.Context, *config.Config) error
+}
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+// Identity - Caller authenticated by token, basic auth or client certificate
+type Identity struct {
+	Name   string
+	Scopes []string
+}
+
+type auth struct {
+	cfg   *Config
+	mutex sync.RWMutex
+	users map[string]string
+}
+
+func New(_ context.Context, cfg *Config) Auth {
+	return &auth{
+		cfg:   cfg,
+		users: map[string]string{},
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// HasScope reports whether the identity is granted the scope, admin being granted all scopes
+func (i *Identity) HasScope(scope string) bool {
+	for _, item := range i.Scopes {
+		if item == scope || item == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *auth) Init(_ context.Context) error {
+	a.cfg.Logger.Debug("auth: Init")
+
+	users, err := a.load(&a.cfg.Config)
+	if err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	a.users = users
+	a.mutex.Unlock()
+
+	return nil
+}
+
+func (a *auth) Deinit(_ context.Context) error {
+	a.cfg.Logger.Debug("auth: Deinit")
+
+	return nil
+}
+
+// Reload loads the tokens, users and clients of the config, and keeps the previous ones if invalid
+func (a *auth) Reload(_ context.Context, cfg *config.Config) error {
+	a.cfg.Logger.Debug("auth: Reload")
+
+	users, err := a.load(cfg)
+	if err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	a.cfg.Config.Spec.Server.Auth = cfg.Spec.Server.Auth
+	a.cfg.Config.Spec.Server.Tls.Clients = cfg.Spec.Server.Tls.Clients
+	a.users = users
+	a.mutex.Unlock()
+
+	return nil
+}
+
+func (a *auth) load(cfg *config.Config) (map[string]string, error) {
+	for _, item := range cfg.Spec.Server.Auth.Tokens {
+		if item.Name == "" || item.Token == "" {
+			return nil, errors.New("invalid token")
+		}
+	}
+
+	users := map[string]string{}
+
+	if cfg.Spec.Server.Auth.Htpasswd.File != "" {
+		if err := a.loadHtpasswd(cfg.Spec.Server.Auth.Htpasswd.File, users); err != nil {
+			return nil, errors.Wrap(err, "failed to load htpasswd")
+		}
+	}
+
+	return users, nil
+}
+
+func (a *auth) Enabled(_ context.Context) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return len(a.cfg.Config.Spec.Server.Auth.Tokens) != 0 || a.cfg.Config.Spec.Server.Auth.Htpasswd.File != "" ||
+		len(a.cfg.Config.Spec.Server.Tls.Clients) != 0
+}
+
+func (a *auth) Authenticate(_ context.Context, req *nethttp.Request) (*Identity, error) {
+	a.cfg.Logger.Debug("auth: Authenticate")
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if h := req.Header.Get("Authorization"); strings.HasPrefix(h, bearerPrefix) {
+		return a.authenticateToken(strings.TrimSpace(strings.TrimPrefix(h, bearerPrefix)))
+	}
+
+	if user, pass, ok := req.BasicAuth(); ok {
+		return a.authenticateBasic(user, pass)
+	}
+
+	if req.TLS != nil && len(req.TLS.VerifiedChains) != 0 && len(req.TLS.VerifiedChains[0]) != 0 {
+		return a.authenticateClient(req.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}
+
+	return nil, ErrUnauthorized
+}
+
+// authenticateClient maps the common name of a client certificate verified by mTLS to its scopes
+func (a *auth) authenticateClient(name string) (*Identity, error) {
+	for _, item := range a.cfg.Config.Spec.Server.Tls.Clients {
+		if item.CommonName == name {
+			return &Identity{Name: name, Scopes: item.Scopes}, nil
+		}
+	}
+
+	return nil, ErrUnauthorized
+}
+
+func (a *auth) authenticateToken(token string) (*Identity, error) {
+	for _, item := range a.cfg.Config.Spec.Server.Auth.Tokens {
+		if subtle.ConstantTimeCompare([]byte(item.Token), []byte(token)) == 1 {
+			return &Identity{Name: item.Name, Scopes: item.Scopes}, nil
+		}
+	}
+
+	return nil, ErrUnauthorized
+}
+
+func (a *auth) authenticateBasic(user, pass string) (*Identity, error) {
+	hash, ok := a.users[user]
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if strings.HasPrefix(hash, shaPrefix) {
+		sum := sha1.Sum([]byte(pass)) // nolint:gosec
+		if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(hash, shaPrefix)), []byte(base64.StdEncoding.EncodeToString(sum[:]))) != 1 {
+			return nil, ErrUnauthorized
+		}
+	} else if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)); err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	return &Identity{Name: user, Scopes: a.cfg.Config.Spec.Server.Auth.Htpasswd.Scopes}, nil
+}
+
+// loadHtpasswd reads users hashed with bcrypt or SHA1, as created by htpasswd -B or -s
+func (a *auth) loadHtpasswd(name string, users map[string]string) error {
+	fi, err := os.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to open")
+	}
+
+	defer func() {
+		_ = fi.Close()
+	}()
+
+	scan := bufio.NewScanner(fi)
+
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return errors.New("invalid line")
+		}
+		if !strings.HasPrefix(hash, shaPrefix) && !strings.HasPrefix(hash, "$2") {
+			a.cfg.Logger.Warn("auth: unsupported hash, user skipped", "user", user)
+			continue
+		}
+		users[user] = hash
+	}
+
+	return scan.Err()
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha1" // nolint:gosec
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/gerrittrigger/events/config"
+)
+
+func initAuth(t *testing.T) *auth {
+	b, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	sum := sha1.Sum([]byte("pass")) // nolint:gosec
+
+	name := filepath.Join(t.TempDir(), ".htpasswd")
+	_ = os.WriteFile(name, []byte("# comment\nbcrypt:"+string(b)+"\nsha:{SHA}"+base64.StdEncoding.EncodeToString(sum[:])+
+		"\nmd5:$apr1$salt$hash\n"), 0o600)
+
+	a := New(context.Background(), DefaultConfig()).(*auth)
+
+	a.cfg.Config.Spec.Server.Auth = config.Auth{
+		Htpasswd: config.Htpasswd{File: name, Scopes: []string{ScopeRead}},
+		Tokens: []config.Token{
+			{Name: "ci", Token: "token", Scopes: []string{ScopeRead}},
+			{Name: "ops", Token: "admin", Scopes: []string{ScopeAdmin}},
+		},
+	}
+
+	a.cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "auth",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return a
+}
+
+func TestInit(t *testing.T) {
+	ctx := context.Background()
+	a := initAuth(t)
+
+	err := a.Init(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(a.users))
+	assert.Equal(t, true, a.Enabled(ctx))
+
+	a.cfg.Config.Spec.Server.Auth.Htpasswd.File = "invalid"
+	err = a.Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	a.cfg.Config.Spec.Server.Auth.Tokens = []config.Token{{Name: "empty"}}
+	err = a.Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	a.cfg.Config.Spec.Server.Auth = config.Auth{}
+	assert.Equal(t, false, a.Enabled(ctx))
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	a := initAuth(t)
+	_ = a.Init(ctx)
+
+	c := config.New()
+	c.Spec.Server.Auth.Htpasswd.File = "invalid"
+
+	err := a.Reload(ctx, c)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 2, len(a.users))
+
+	c.Spec.Server.Auth = config.Auth{Tokens: []config.Token{{Name: "bot", Token: "rotated", Scopes: []string{ScopeRead}}}}
+
+	err = a.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(a.users))
+
+	req, _ := nethttp.NewRequest("GET", "/", nethttp.NoBody)
+	req.Header.Set("Authorization", "Bearer token")
+
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	req.Header.Set("Authorization", "Bearer rotated")
+
+	id, err := a.Authenticate(ctx, req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "bot", id.Name)
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	a := initAuth(t)
+	_ = a.Init(ctx)
+
+	req, _ := nethttp.NewRequest("GET", "/", nethttp.NoBody)
+
+	_, err := a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	req.Header.Set("Authorization", "Bearer invalid")
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	req.Header.Set("Authorization", "Bearer token")
+	id, err := a.Authenticate(ctx, req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "ci", id.Name)
+	assert.Equal(t, true, id.HasScope(ScopeRead))
+	assert.Equal(t, false, id.HasScope(ScopeAdmin))
+
+	req.Header.Set("Authorization", "Bearer admin")
+	id, _ = a.Authenticate(ctx, req)
+	assert.Equal(t, true, id.HasScope(ScopeRead))
+
+	req.Header.Del("Authorization")
+
+	req.SetBasicAuth("bcrypt", "pass")
+	id, err = a.Authenticate(ctx, req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "bcrypt", id.Name)
+	assert.Equal(t, true, id.HasScope(ScopeRead))
+
+	req.SetBasicAuth("sha", "pass")
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, nil, err)
+
+	req.SetBasicAuth("sha", "invalid")
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	req.SetBasicAuth("bcrypt", "invalid")
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	req.SetBasicAuth("md5", "pass")
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	req, _ = nethttp.NewRequest("GET", "/", nethttp.NoBody)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "ci"}}}}}
+
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	a.cfg.Config.Spec.Server.Tls.Clients = []config.Client{{CommonName: "ci", Scopes: []string{ScopeRead}}}
+	id, err = a.Authenticate(ctx, req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "ci", id.Name)
+	assert.Equal(t, true, id.HasScope(ScopeRead))
+
+	req.TLS.VerifiedChains[0][0].Subject.CommonName = "unknown"
+	_, err = a.Authenticate(ctx, req)
+	assert.Equal(t, ErrUnauthorized, err)
+}
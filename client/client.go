@@ -0,0 +1,430 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/events"
+)
+
+const (
+	queryLayout = "2006-01-02 15:04:05"
+
+	lastEventID = "Last-Event-ID"
+	streamData  = "data:"
+	streamID    = "id:"
+	streamLine  = 1 << 22
+
+	defaultAttempts   = 3
+	defaultBackoff    = time.Second
+	defaultMaxBackoff = 30 * time.Second
+	defaultWindow     = time.Hour
+)
+
+type Client interface {
+	Query(context.Context, time.Time, time.Time) ([]Event, error)
+	Iterate(context.Context, time.Time, time.Time, func(*Event) error) error
+	Triggers(context.Context) ([]string, error)
+	QueryTrigger(context.Context, string, time.Time, time.Time) ([]Event, error)
+	Subscribe(context.Context, string, func(*Event) error) error
+}
+
+type Config struct {
+	// URL of the server (e.g., http://localhost:8080)
+	Url string
+
+	// Bearer token, or username and password for basic auth
+	Token    string
+	Username string
+	Password string
+
+	HttpClient *nethttp.Client
+	Logger     hclog.Logger
+
+	// Location in which the server parses the query
+	Location *time.Location
+
+	// Window of the queries sent by Iterate
+	Window time.Duration
+
+	Retry Retry
+}
+
+// Retry - Exponential backoff of the requests failed with a network error, 429 or 5xx
+type Retry struct {
+	// Attempts per request, or in a row for Subscribe (0: no limit)
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Event - Event returned by the server, decoded
+type Event struct {
+	// ID of the stored event, only set by Subscribe
+	ID uint64
+
+	CreatedOn int64
+	Event     events.Event
+	Raw       []byte
+}
+
+// StatusError - Request failed with a non-2xx status
+type StatusError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.Code, e.Message)
+}
+
+type result struct {
+	EventBase64    string `json:"eventBase64"`
+	EventCreatedOn int64  `json:"eventCreatedOn"`
+}
+
+type client struct {
+	cfg *Config
+}
+
+func New(_ context.Context, cfg *Config) Client {
+	return &client{
+		cfg: cfg,
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		HttpClient: &nethttp.Client{},
+		Logger:     hclog.NewNullLogger(),
+		Location:   time.Local,
+		Window:     defaultWindow,
+		Retry: Retry{
+			Attempts:   defaultAttempts,
+			Backoff:    defaultBackoff,
+			MaxBackoff: defaultMaxBackoff,
+		},
+	}
+}
+
+// Query returns the events created between since (inclusive) and until (exclusive) in one request
+func (c *client) Query(ctx context.Context, since, until time.Time) ([]Event, error) {
+	c.cfg.Logger.Debug("client: Query")
+
+	return c.query(ctx, "/events/", since, until)
+}
+
+// Iterate calls fn with the events created between since and until, queried window by window
+func (c *client) Iterate(ctx context.Context, since, until time.Time, fn func(*Event) error) error {
+	c.cfg.Logger.Debug("client: Iterate")
+
+	if c.cfg.Window < time.Second {
+		return errors.New("invalid window")
+	}
+
+	for s := since; s.Before(until); s = s.Add(c.cfg.Window) {
+		u := s.Add(c.cfg.Window)
+		if u.After(until) {
+			u = until
+		}
+		b, err := c.query(ctx, "/events/", s, u)
+		if err != nil {
+			return errors.Wrap(err, "failed to query")
+		}
+		for i := range b {
+			if err := fn(&b[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *client) Triggers(ctx context.Context) ([]string, error) {
+	c.cfg.Logger.Debug("client: Triggers")
+
+	var b []string
+
+	rsp, err := c.do(ctx, "/triggers/", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(rsp.Body).Decode(&b); err != nil {
+		return nil, errors.Wrap(err, "failed to decode")
+	}
+
+	return b, nil
+}
+
+func (c *client) QueryTrigger(ctx context.Context, name string, since, until time.Time) ([]Event, error) {
+	c.cfg.Logger.Debug("client: QueryTrigger")
+
+	return c.query(ctx, "/triggers/"+url.PathEscape(name)+"/events/", since, until)
+}
+
+// Subscribe calls fn with the events matched by the rule, or all of them if empty, reconnecting and resuming
+// after the last event received until the context is done, the retry attempts are exhausted or fn fails
+func (c *client) Subscribe(ctx context.Context, name string, fn func(*Event) error) error {
+	c.cfg.Logger.Debug("client: Subscribe")
+
+	var last uint64
+
+	attempt := 0
+
+	for {
+		received, err := c.stream(ctx, name, &last, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var se *StatusError
+		if errors.As(err, &se) && !retryable(se.Code) {
+			return err
+		}
+		var ce *callbackError
+		if errors.As(err, &ce) {
+			return ce.err
+		}
+		if received {
+			attempt = 0
+		}
+		attempt++
+		if c.cfg.Retry.Attempts != 0 && attempt >= c.cfg.Retry.Attempts {
+			return errors.Wrap(err, "failed to subscribe")
+		}
+		c.cfg.Logger.Warn("client: stream closed, reconnecting", "last", last, "error", err)
+		if err := c.wait(ctx, attempt); err != nil {
+			return nil
+		}
+	}
+}
+
+// callbackError - Error returned by the callback of Subscribe, which stops reconnecting
+type callbackError struct {
+	err error
+}
+
+func (e *callbackError) Error() string {
+	return e.err.Error()
+}
+
+// stream reads the events of one connection, and reports whether any was received
+func (c *client) stream(ctx context.Context, name string, last *uint64, fn func(*Event) error) (bool, error) {
+	h := nethttp.Header{}
+
+	if *last != 0 {
+		h.Set(lastEventID, strconv.FormatUint(*last, 10))
+	}
+
+	path := "/events/stream"
+	if name != "" {
+		path = "/triggers/" + url.PathEscape(name) + "/stream"
+	}
+
+	rsp, err := c.send(ctx, path, "", h)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	received := false
+
+	// Events are base64 encoded on one line, e.g., beyond 64KB with large comments
+	scan := bufio.NewScanner(rsp.Body)
+	scan.Buffer(make([]byte, bufio.MaxScanTokenSize), streamLine)
+
+	var id uint64
+
+	for scan.Scan() {
+		line := scan.Text()
+		switch {
+		case strings.HasPrefix(line, streamID):
+			id, _ = strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, streamID)), 10, 64)
+		case strings.HasPrefix(line, streamData):
+			r := result{}
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, streamData))), &r); err != nil {
+				return received, errors.Wrap(err, "failed to unmarshal")
+			}
+			e, err := decode(&r)
+			if err != nil {
+				return received, err
+			}
+			e.ID = id
+			if err := fn(e); err != nil {
+				return received, &callbackError{err: err}
+			}
+			received = true
+			*last = id
+		}
+	}
+
+	if err := scan.Err(); err != nil {
+		return received, errors.Wrap(err, "failed to read")
+	}
+
+	return received, io.EOF
+}
+
+func (c *client) query(ctx context.Context, path string, since, until time.Time) ([]Event, error) {
+	loc := c.cfg.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	q := "since:" + since.In(loc).Format(queryLayout) + " until:" + until.In(loc).Format(queryLayout)
+
+	rsp, err := c.do(ctx, path, url.Values{"q": []string{q}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	var r []result
+
+	if err := json.NewDecoder(rsp.Body).Decode(&r); err != nil {
+		return nil, errors.Wrap(err, "failed to decode")
+	}
+
+	b := make([]Event, 0, len(r))
+
+	for i := range r {
+		e, err := decode(&r[i])
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, *e)
+	}
+
+	return b, nil
+}
+
+// do sends the request with retries
+func (c *client) do(ctx context.Context, path, query string, header nethttp.Header) (*nethttp.Response, error) {
+	var err error
+	var rsp *nethttp.Response
+
+	for attempt := 1; ; attempt++ {
+		rsp, err = c.send(ctx, path, query, header)
+		if err == nil {
+			return rsp, nil
+		}
+		var se *StatusError
+		if errors.As(err, &se) && !retryable(se.Code) {
+			return nil, err
+		}
+		if c.cfg.Retry.Attempts != 0 && attempt >= c.cfg.Retry.Attempts {
+			return nil, err
+		}
+		if e := c.wait(ctx, attempt); e != nil {
+			return nil, e
+		}
+	}
+}
+
+func (c *client) send(ctx context.Context, path, query string, header nethttp.Header) (*nethttp.Response, error) {
+	u := strings.TrimSuffix(c.cfg.Url, "/") + path
+	if query != "" {
+		u += "?" + query
+	}
+
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, u, nethttp.NoBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+
+	for key, val := range header {
+		req.Header[key] = val
+	}
+
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	} else if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	h := c.cfg.HttpClient
+	if h == nil {
+		h = nethttp.DefaultClient
+	}
+
+	rsp, err := h.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send request")
+	}
+
+	if rsp.StatusCode < nethttp.StatusOK || rsp.StatusCode >= nethttp.StatusMultipleChoices {
+		defer func() {
+			_ = rsp.Body.Close()
+		}()
+		e := &StatusError{}
+		_ = json.NewDecoder(rsp.Body).Decode(e)
+		e.Code = rsp.StatusCode
+		return nil, e
+	}
+
+	return rsp, nil
+}
+
+func (c *client) wait(ctx context.Context, attempt int) error {
+	d := c.cfg.Retry.Backoff
+
+	for i := 1; i < attempt && d < c.cfg.Retry.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if c.cfg.Retry.MaxBackoff != 0 && d > c.cfg.Retry.MaxBackoff {
+		d = c.cfg.Retry.MaxBackoff
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func retryable(code int) bool {
+	return code == nethttp.StatusTooManyRequests || code >= nethttp.StatusInternalServerError
+}
+
+func decode(r *result) (*Event, error) {
+	buf, err := base64.StdEncoding.DecodeString(r.EventBase64)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode base64")
+	}
+
+	e := &Event{CreatedOn: r.EventCreatedOn, Raw: buf}
+
+	if err := json.Unmarshal(buf, &e.Event); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal")
+	}
+
+	return e, nil
+}
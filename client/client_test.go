@@ -0,0 +1,328 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/connect"
+	"github.com/gerrittrigger/events/connect/gerrittest"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/queue"
+	"github.com/gerrittrigger/events/server"
+	"github.com/gerrittrigger/events/storage"
+	"github.com/gerrittrigger/events/trigger"
+	"github.com/gerrittrigger/events/watchdog"
+)
+
+const (
+	created = `{"type":"patchset-created","change":{"project":"platform/build","number":1},"eventCreatedOn":1672567201}`
+	merged  = `{"type":"ref-updated","refUpdate":{"project":"platform/build"},"eventCreatedOn":1672567202}`
+	updated = `{"type":"ref-updated","refUpdate":{"project":"platform/build"},"eventCreatedOn":1672567203}`
+	token   = "token"
+)
+
+var (
+	since = time.Unix(1672567200, 0)
+	until = time.Unix(1672567300, 0)
+)
+
+// initServer runs the server fed by a fake Gerrit, and serves its API with httptest
+func initServer(t *testing.T) (*httptest.Server, *gerrittest.Server, storage.Storage) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g, err := gerrittest.New(gerrittest.Config{})
+	assert.Equal(t, nil, err)
+
+	keyfile, _ := gerrittest.Keyfile(t.TempDir())
+
+	c := config.Config{}
+	c.Spec.Connect.Hostname = g.Hostname()
+	c.Spec.Connect.Ssh.Keyfile = keyfile
+	c.Spec.Connect.Ssh.Port = g.Port()
+	c.Spec.Connect.Ssh.Username = gerrittest.Username
+	c.Spec.Server.Auth.Tokens = []config.Token{{Name: "ci", Token: token, Scopes: []string{auth.ScopeRead}}}
+	c.Spec.Storage.Sqlite.Filename = filepath.Join(t.TempDir(), "test.db")
+	c.Spec.Trigger.Rules = []config.Rule{{Name: "merged", Events: []string{events.EVENTS_REF_UPDATED}}}
+	c.Spec.Watchdog.PeriodSeconds = 1
+	c.Spec.Watchdog.TimeoutSeconds = 1
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "client",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	ac := auth.DefaultConfig()
+	ac.Config = c
+	ac.Logger = logger
+
+	qc := queue.DefaultConfig()
+	qc.Config = c
+	qc.Logger = logger
+
+	sc := connect.DefaultSshConfig()
+	sc.Config = c
+	sc.Logger = logger
+
+	stc := storage.DefaultConfig()
+	stc.Config = c
+	stc.Logger = logger
+
+	tc := trigger.DefaultConfig()
+	tc.Config = c
+	tc.Logger = logger
+
+	wc := watchdog.DefaultConfig()
+	wc.Config = c
+	wc.Logger = logger
+
+	st := storage.New(ctx, stc)
+
+	cfg := server.DefaultConfig()
+	cfg.Auth = auth.New(ctx, ac)
+	cfg.Config = c
+	cfg.Logger = logger
+	cfg.Queue = queue.New(ctx, qc)
+	cfg.Ssh = connect.SshNew(ctx, sc)
+	cfg.Storage = st
+	cfg.Trigger = trigger.New(ctx, tc)
+	cfg.Watchdog = watchdog.New(ctx, wc)
+
+	s := server.New(ctx, cfg)
+
+	err = s.Init(ctx)
+	assert.Equal(t, nil, err)
+
+	go func() {
+		_ = s.Run(ctx)
+	}()
+
+	srv := httptest.NewServer(s.Handler())
+
+	t.Cleanup(func() {
+		srv.Close()
+		cancel()
+		_ = s.Deinit(context.Background())
+		_ = g.Close()
+	})
+
+	return srv, g, st
+}
+
+func initClient(u string) Client {
+	c := DefaultConfig()
+
+	c.Url = u
+	c.Token = token
+	c.Window = 30 * time.Second
+	c.Retry.Backoff = 10 * time.Millisecond
+	c.Retry.MaxBackoff = 50 * time.Millisecond
+
+	return New(context.Background(), c)
+}
+
+func waitEvents(st storage.Storage, n int) bool {
+	for i := 0; i < 100; i++ {
+		b, _ := st.Read(context.Background(), 0, until.Unix())
+		if len(b) == n {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func waitStreams(g *gerrittest.Server) bool {
+	for i := 0; i < 100; i++ {
+		if g.Streams() == 1 {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	srv, g, st := initServer(t)
+
+	assert.Equal(t, true, waitStreams(g))
+	g.Push(created)
+	g.Push(merged)
+	assert.Equal(t, true, waitEvents(st, 2))
+
+	c := initClient(srv.URL)
+
+	b, err := c.Query(ctx, since, until)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(b))
+	assert.Equal(t, events.EVENTS_PATCHSET_CREATED, b[0].Event.Type)
+	assert.Equal(t, 1, b[0].Event.Change.Number)
+	assert.Equal(t, int64(1672567201), b[0].CreatedOn)
+	assert.Equal(t, created, string(b[0].Raw))
+
+	b, err = c.Query(ctx, since, time.Unix(1672567202, 0))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+
+	n := 0
+	err = c.Iterate(ctx, since, until, func(e *Event) error {
+		n++
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, n)
+
+	r, err := c.Triggers(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"merged"}, r)
+
+	b, err = c.QueryTrigger(ctx, "merged", since, until)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, events.EVENTS_REF_UPDATED, b[0].Event.Type)
+
+	_, err = c.QueryTrigger(ctx, "invalid", since, until)
+	assert.Equal(t, nethttp.StatusNotFound, err.(*StatusError).Code)
+
+	d := DefaultConfig()
+	d.Url = srv.URL
+
+	_, err = New(ctx, d).Query(ctx, since, until)
+	assert.Equal(t, nethttp.StatusUnauthorized, err.(*StatusError).Code)
+	assert.Equal(t, "unauthorized", err.(*StatusError).Message)
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	srv, g, st := initServer(t)
+	assert.Equal(t, true, waitStreams(g))
+
+	c := initClient(srv.URL)
+	received := make(chan *Event, 10)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- c.Subscribe(ctx, "merged", func(e *Event) error {
+			received <- e
+			return nil
+		})
+	}()
+
+	// Wait until subscribed, as events published before are not streamed, and identical events stored once
+	var e *Event
+	for i := 0; e == nil; i++ {
+		g.Push(fmt.Sprintf(`{"type":"ref-updated","refUpdate":{"project":"platform/build"},"eventCreatedOn":%d}`, 1672567210+i))
+		select {
+		case e = <-received:
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	assert.Equal(t, events.EVENTS_REF_UPDATED, e.Event.Type)
+	assert.NotEqual(t, uint64(0), e.ID)
+
+	// Events stored while disconnected are replayed on reconnect
+	srv.CloseClientConnections()
+	b, _ := st.Read(ctx, 0, until.Unix())
+	g.Push(created)
+	g.Push(updated)
+	assert.Equal(t, true, waitEvents(st, len(b)+2))
+
+	var r *Event
+	for r == nil || r.CreatedOn != 1672567203 {
+		select {
+		case r = <-received:
+			assert.Equal(t, events.EVENTS_REF_UPDATED, r.Event.Type)
+		case <-ctx.Done():
+			t.Fatal("timeout")
+		}
+	}
+
+	cancel()
+	assert.Equal(t, nil, <-done)
+}
+
+func TestSubscribeLarge(t *testing.T) {
+	ctx := context.Background()
+
+	large := `{"type":"comment-added","comment":"` + strings.Repeat("a", 100000) + `","eventCreatedOn":1672567200}`
+	data, _ := json.Marshal(map[string]any{"eventBase64": base64.StdEncoding.EncodeToString([]byte(large)), "eventCreatedOn": 1672567200})
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		_, _ = fmt.Fprintf(w, "id:1\nevent:message\ndata:%s\n\n", data)
+	}))
+
+	defer srv.Close()
+
+	c := initClient(srv.URL)
+	stop := errors.New("stop")
+
+	var e *Event
+
+	err := c.Subscribe(ctx, "merged", func(r *Event) error {
+		e = r
+		return stop
+	})
+
+	assert.Equal(t, stop, err)
+	assert.Equal(t, uint64(1), e.ID)
+	assert.Equal(t, events.EVENTS_COMMENT_ADDED, e.Event.Type)
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	srv, g, st := initServer(t)
+
+	assert.Equal(t, true, waitStreams(g))
+	g.Push(merged)
+	assert.Equal(t, true, waitEvents(st, 1))
+
+	var n int32
+
+	proxy := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if atomic.AddInt32(&n, 1) <= 2 {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+			return
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	b, err := initClient(proxy.URL).Query(ctx, since, until)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&n))
+
+	atomic.StoreInt32(&n, 0)
+
+	c := DefaultConfig()
+	c.Url = proxy.URL
+	c.Token = token
+	c.Retry = Retry{Attempts: 2, Backoff: time.Millisecond}
+
+	_, err = New(ctx, c).Query(ctx, since, until)
+	assert.Equal(t, nethttp.StatusServiceUnavailable, err.(*StatusError).Code)
+
+	c.Window = 0
+	err = New(ctx, c).Iterate(ctx, since, until, func(*Event) error { return nil })
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/server"
+	"github.com/gerrittrigger/events/sink/file"
+)
+
+const (
+	archiveVersion = "v1"
+	formatGzip     = "ndjson+gzip"
+	formatNdjson   = "ndjson"
+	gzipSuffix     = ".gz"
+	manifestSuffix = ".manifest.json"
+)
+
+// manifest - Description of an archive, written next to it
+type manifest struct {
+	Version   string `json:"version"`
+	Format    string `json:"format"`
+	CreatedOn int64  `json:"createdOn"`
+
+	// Query and filter of the export
+	Since    int64    `json:"since"`
+	Until    int64    `json:"until"`
+	Projects []string `json:"projects,omitempty"`
+	Trigger  string   `json:"trigger,omitempty"`
+	Types    []string `json:"types,omitempty"`
+
+	// Number of records, and SHA-256 of the archive file
+	Count  int    `json:"count"`
+	Sha256 string `json:"sha256"`
+}
+
+// record - Line of an archive, in the format of the API
+type record struct {
+	EventBase64    string `json:"eventBase64"`
+	EventCreatedOn int64  `json:"eventCreatedOn"`
+}
+
+// runExport writes the events of the database matched by the query and the filter to the archive,
+// compressed if its name ends with .gz, and then its manifest
+func runExport(ctx context.Context, logger hclog.Logger, opts *queryOptions, name string) error {
+	logger.Debug("cmd: runExport")
+
+	since, until, err := server.ParseQuery(opts.Query)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse query")
+	}
+
+	m := manifest{
+		Version:   archiveVersion,
+		Format:    formatNdjson,
+		CreatedOn: time.Now().Unix(),
+		Since:     since,
+		Until:     until,
+		Projects:  opts.Projects,
+		Trigger:   opts.Trigger,
+		Types:     opts.Types,
+	}
+
+	if strings.HasSuffix(name, gzipSuffix) {
+		m.Format = formatGzip
+	}
+
+	if err := writeArchive(ctx, logger, opts, name, &m); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal")
+	}
+
+	if err := os.WriteFile(name+manifestSuffix, append(buf, '\n'), 0o600); err != nil {
+		return errors.Wrap(err, "failed to write manifest")
+	}
+
+	logger.Info("cmd: runExport: exported", "count", m.Count, "file", name)
+
+	return nil
+}
+
+func writeArchive(ctx context.Context, logger hclog.Logger, opts *queryOptions, name string, m *manifest) error {
+	fi, err := os.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to create")
+	}
+
+	defer func() {
+		_ = fi.Close()
+	}()
+
+	h := sha256.New()
+
+	var w io.Writer = io.MultiWriter(fi, h)
+	var gz *gzip.Writer
+
+	if m.Format == formatGzip {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	enc := json.NewEncoder(w)
+
+	read := readLocal
+	if opts.Archive != "" {
+		read = readFiles
+	}
+
+	err = read(ctx, logger, opts, m.Since, m.Until, func(e *queryEvent) error {
+		if !matchEvent(e, opts) {
+			return nil
+		}
+		m.Count++
+		return enc.Encode(record{EventBase64: base64.StdEncoding.EncodeToString(e.Raw), EventCreatedOn: e.CreatedOn})
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "failed to export")
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return errors.Wrap(err, "failed to compress")
+		}
+	}
+
+	if err := fi.Sync(); err != nil {
+		return errors.Wrap(err, "failed to sync")
+	}
+
+	m.Sha256 = hex.EncodeToString(h.Sum(nil))
+
+	return nil
+}
+
+// readFiles calls fn with the events of the files of the file sink created between since and until, found by its index,
+// and matched by the trigger rule if set
+func readFiles(ctx context.Context, logger hclog.Logger, opts *queryOptions, since, until int64, fn func(*queryEvent) error) error {
+	logger.Debug("cmd: readFiles")
+
+	var err error
+
+	cfg := config.New()
+
+	if opts.ConfigFile != "" {
+		if cfg, err = initConfig(ctx, logger, opts.ConfigFile); err != nil {
+			return errors.Wrap(err, "failed to init config")
+		}
+	}
+
+	tr, _ := initTrigger(ctx, logger, cfg)
+	if err = tr.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init trigger")
+	}
+
+	if opts.Trigger != "" && !contains(tr.Rules(ctx), opts.Trigger) {
+		return errors.New("invalid rule " + opts.Trigger)
+	}
+
+	return file.Read(opts.Archive, since, until, func(buf []byte) error {
+		e := queryEvent{Raw: buf}
+		if err := json.Unmarshal(buf, &e.Event); err != nil {
+			logger.Warn("cmd: readFiles: invalid event")
+			return nil
+		}
+		e.CreatedOn = e.Event.EventCreatedOn
+		if opts.Trigger != "" {
+			if ok, _ := tr.Match(ctx, opts.Trigger, &e.Event); !ok {
+				return nil
+			}
+		}
+		return fn(&e)
+	})
+}
+
+// verifyArchive checks the records, the number and the checksum of the archive against its manifest
+func verifyArchive(name string) (*manifest, error) {
+	m, err := readManifest(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, count, err := readArchive(name, m.Format, func(*record) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+
+	if sum != m.Sha256 {
+		return nil, errors.New("invalid checksum")
+	}
+
+	if count != m.Count {
+		return nil, errors.New("invalid count")
+	}
+
+	return m, nil
+}
+
+func readManifest(name string) (*manifest, error) {
+	buf, err := os.ReadFile(name + manifestSuffix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+
+	m := &manifest{}
+
+	if err := json.Unmarshal(buf, m); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal manifest")
+	}
+
+	if m.Version != archiveVersion {
+		return nil, errors.New("invalid version " + m.Version)
+	}
+
+	if m.Format != formatNdjson && m.Format != formatGzip {
+		return nil, errors.New("invalid format " + m.Format)
+	}
+
+	return m, nil
+}
+
+// readArchive calls fn with the valid records of the archive, and returns its SHA-256 and number of records
+func readArchive(name, format string, fn func(*record) error) (sum string, count int, err error) {
+	fi, err := os.Open(name)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to open")
+	}
+
+	defer func() {
+		_ = fi.Close()
+	}()
+
+	h := sha256.New()
+
+	var r io.Reader = io.TeeReader(fi, h)
+
+	if format == formatGzip {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return "", 0, errors.Wrap(err, "failed to decompress")
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		r = gz
+	}
+
+	dec := json.NewDecoder(r)
+
+	for {
+		b := record{}
+		if err := dec.Decode(&b); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", 0, errors.Wrap(err, "failed to decode record "+strconv.Itoa(count+1))
+		}
+		if _, err := base64.StdEncoding.DecodeString(b.EventBase64); err != nil || b.EventCreatedOn < 0 {
+			return "", 0, errors.New("invalid record " + strconv.Itoa(count+1))
+		}
+		count++
+		if err := fn(&b); err != nil {
+			return "", 0, err
+		}
+	}
+
+	// Trailing bytes of the file not read by the decoder
+	if _, err := io.Copy(h, fi); err != nil {
+		return "", 0, errors.Wrap(err, "failed to read")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), count, nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/sink"
+)
+
+func countEvents(t *testing.T, name string) int {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+
+	cfg := config.New()
+	cfg.Spec.Storage.Sqlite.Filename = name
+
+	st, _ := initStorage(ctx, logger, cfg)
+	assert.Equal(t, nil, st.Init(ctx))
+
+	defer func() {
+		_ = st.Deinit(ctx)
+	}()
+
+	b, err := st.Read(ctx, 0, math.MaxInt64)
+	assert.Equal(t, nil, err)
+
+	return len(b)
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	dir := t.TempDir()
+	name := initQueryDatabase(t)
+
+	for _, file := range []string{filepath.Join(dir, "events.ndjson"), filepath.Join(dir, "events.ndjson.gz")} {
+		err := runExport(ctx, logger, &queryOptions{Database: name, Query: queryRange}, file)
+		assert.Equal(t, nil, err)
+
+		m, err := readManifest(file)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, m.Count)
+		assert.Equal(t, 64, len(m.Sha256))
+
+		database := filepath.Join(t.TempDir(), "test.db")
+
+		err = runImport(ctx, logger, &importOptions{Database: database, File: file})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, countEvents(t, database))
+
+		// Importing again is a no-op
+		err = runImport(ctx, logger, &importOptions{Database: database, File: file})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, countEvents(t, database))
+	}
+
+	m, _ := readManifest(filepath.Join(dir, "events.ndjson"))
+	n, _ := readManifest(filepath.Join(dir, "events.ndjson.gz"))
+	assert.Equal(t, formatNdjson, m.Format)
+	assert.Equal(t, formatGzip, n.Format)
+
+	file := filepath.Join(dir, "filtered.ndjson")
+
+	err := runExport(ctx, logger, &queryOptions{Database: name, Query: queryRange, Types: []string{events.EVENTS_REF_UPDATED}}, file)
+	assert.Equal(t, nil, err)
+
+	m, _ = readManifest(file)
+	assert.Equal(t, 1, m.Count)
+	assert.Equal(t, []string{events.EVENTS_REF_UPDATED}, m.Types)
+
+	// Events already stored are skipped
+	err = runImport(ctx, logger, &importOptions{Database: name, File: file})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, countEvents(t, name))
+}
+
+func TestExportFiles(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	dir := t.TempDir()
+
+	cfg := config.New()
+	cfg.Spec.File.Compress = true
+	cfg.Spec.File.Directory = filepath.Join(dir, "sink")
+	cfg.Spec.File.RotateBytes = 1
+
+	s, _ := initFile(ctx, logger, cfg)
+	assert.Equal(t, nil, s.Init(ctx))
+
+	// Rotated before each event but the first
+	for _, item := range []string{queryCreated, queryUpdated, queryCreated} {
+		assert.Equal(t, nil, s.Deliver(ctx, &sink.Event{Data: []byte(item)}))
+	}
+
+	assert.Equal(t, nil, s.Deinit(ctx))
+
+	file := filepath.Join(dir, "events.ndjson")
+
+	err := runExport(ctx, logger, &queryOptions{Archive: cfg.Spec.File.Directory, Query: queryRange}, file)
+	assert.Equal(t, nil, err)
+
+	m, _ := readManifest(file)
+	assert.Equal(t, 3, m.Count)
+
+	opts := queryOptions{Archive: cfg.Spec.File.Directory, Query: queryRange, Types: []string{events.EVENTS_REF_UPDATED}}
+
+	err = runExport(ctx, logger, &opts, file)
+	assert.Equal(t, nil, err)
+
+	m, _ = readManifest(file)
+	assert.Equal(t, 1, m.Count)
+
+	database := filepath.Join(dir, "test.db")
+
+	err = runImport(ctx, logger, &importOptions{Database: database, File: file})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, countEvents(t, database))
+
+	err = runExport(ctx, logger, &queryOptions{Archive: cfg.Spec.File.Directory, Query: queryRange, Trigger: "invalid"}, file)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestImportInvalid(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	dir := t.TempDir()
+	name := initQueryDatabase(t)
+	file := filepath.Join(dir, "events.ndjson")
+	database := filepath.Join(dir, "test.db")
+
+	err := runImport(ctx, logger, &importOptions{Database: database, File: file})
+	assert.NotEqual(t, nil, err)
+
+	err = runExport(ctx, logger, &queryOptions{Database: name, Query: queryRange}, file)
+	assert.Equal(t, nil, err)
+
+	buf, _ := os.ReadFile(file)
+	_ = os.WriteFile(file, append(buf, buf...), 0o600)
+
+	err = runImport(ctx, logger, &importOptions{Database: database, File: file})
+	assert.Equal(t, "invalid checksum", err.Error())
+
+	_ = os.WriteFile(file, []byte("{\"eventBase64\":\"!\",\"eventCreatedOn\":1}\n"), 0o600)
+
+	err = runImport(ctx, logger, &importOptions{Database: database, File: file})
+	assert.NotEqual(t, nil, err)
+
+	err = runExport(ctx, logger, &queryOptions{Database: name, Query: "invalid"}, file)
+	assert.NotEqual(t, nil, err)
+
+	err = runExport(ctx, logger, &queryOptions{Database: filepath.Join(dir, "invalid.db"), Query: queryRange}, file)
+	assert.NotEqual(t, nil, err)
+
+	_, err = os.Stat(file)
+	assert.Equal(t, true, os.IsNotExist(err))
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"os/signal"
@@ -12,11 +13,20 @@ import (
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
+	"github.com/gerrittrigger/events/auth"
 	"github.com/gerrittrigger/events/config"
 	"github.com/gerrittrigger/events/connect"
+	"github.com/gerrittrigger/events/leader"
 	"github.com/gerrittrigger/events/queue"
 	"github.com/gerrittrigger/events/server"
+	"github.com/gerrittrigger/events/sink"
+	"github.com/gerrittrigger/events/sink/exec"
+	"github.com/gerrittrigger/events/sink/file"
+	"github.com/gerrittrigger/events/sink/nats"
+	"github.com/gerrittrigger/events/sink/redis"
 	"github.com/gerrittrigger/events/storage"
+	"github.com/gerrittrigger/events/trigger"
+	"github.com/gerrittrigger/events/visibility"
 	"github.com/gerrittrigger/events/watchdog"
 )
 
@@ -26,20 +36,92 @@ const (
 )
 
 var (
-	app        = kingpin.New(name, "gerrit events").Version(config.Version + "-build-" + config.Build)
-	configFile = app.Flag("config-file", "Config file (.yml)").Required().String()
-	listenPort = app.Flag("listen-port", "Listen port").Default("8080").Int()
-	logLevel   = app.Flag("log-level", "Log level (DEBUG|INFO|WARN|ERROR)").Default(level).String()
+	app      = kingpin.New(name, "gerrit events").Version(config.Version + "-build-" + config.Build)
+	logLevel = app.Flag("log-level", "Log level (DEBUG|INFO|WARN|ERROR)").Default(level).String()
+
+	serveCommand = app.Command("serve", "Run the server").Default()
+	configFile   = serveCommand.Flag("config-file", "Config file (.yml)").Required().String()
+	grpcPort     = serveCommand.Flag("grpc-port", "gRPC listen port (0: turn off)").Default("0").Int()
+	listenPort   = serveCommand.Flag("listen-port", "Listen port").Default("8080").Int()
+
+	queryCommand    = app.Command("query", "Query events from the local database or a remote server")
+	queryConfigFile = queryCommand.Flag("config-file", "Config file (.yml) of the database and the trigger rules").String()
+	queryDatabase   = queryCommand.Flag("database", "SQLite file (default: spec.storage.sqlite.filename)").String()
+	queryUrl        = queryCommand.Flag("url", "URL of a remote server (e.g., http://host:8080)").String()
+	queryToken      = queryCommand.Flag("token", "Bearer token of the remote server").String()
+	queryUsername   = queryCommand.Flag("username", "Username of the remote server").String()
+	queryPassword   = queryCommand.Flag("password", "Password of the remote server").String()
+	queryOutput     = queryCommand.Flag("output", "Output format (table|json|ndjson)").Default(outputTable).Enum(outputs...)
+	queryProjects   = queryCommand.Flag("project", "Project of the events (repeatable)").Strings()
+	queryTrigger    = queryCommand.Flag("trigger", "Trigger rule matching the events").String()
+	queryTypes      = queryCommand.Flag("type", "Type of the events (repeatable)").Strings()
+	queryString     = queryCommand.Arg("query", "Query of the API (since:'TIME' until:'TIME')").Required().String()
+
+	exportCommand    = app.Command("export", "Export events from the local database, or the files of the file sink, to an archive")
+	exportArchive    = exportCommand.Flag("archive", "Directory of the file sink to read instead of the database").String()
+	exportConfigFile = exportCommand.Flag("config-file", "Config file (.yml) of the database and the trigger rules").String()
+	exportDatabase   = exportCommand.Flag("database", "SQLite file (default: spec.storage.sqlite.filename)").String()
+	exportFile       = exportCommand.Flag("file", "Archive file (.ndjson, or .ndjson.gz to compress)").Required().String()
+	exportProjects   = exportCommand.Flag("project", "Project of the events (repeatable)").Strings()
+	exportTrigger    = exportCommand.Flag("trigger", "Trigger rule matching the events").String()
+	exportTypes      = exportCommand.Flag("type", "Type of the events (repeatable)").Strings()
+	exportString     = exportCommand.Arg("query", "Query of the API (since:'TIME' until:'TIME')").Required().String()
+
+	importCommand    = app.Command("import", "Import events from an archive to the local database")
+	importConfigFile = importCommand.Flag("config-file", "Config file (.yml) of the database and the events-log plugin").String()
+	importDatabase   = importCommand.Flag("database", "SQLite file (default: spec.storage.sqlite.filename)").String()
+	importSource     = importCommand.Flag("source", "Source (archive|capture|events-log|rest)").Default(sourceArchive).Enum(sources...)
+	importUrl        = importCommand.Flag("url", "URL of the events-log REST API (default: spec.connect.rest.url)").String()
+	importUsername   = importCommand.Flag("username", "Username of the events-log REST API").String()
+	importPassword   = importCommand.Flag("password", "Password of the events-log REST API").String()
+	importQuery      = importCommand.Flag("query", "Query of the events-log REST API (since:'TIME' until:'TIME')").String()
+	importFile       = importCommand.Arg("file", "Archive next to its manifest, stream-events capture or events-log CSV dump").String()
+
+	tailCommand    = app.Command("tail", "Print events streamed by Gerrit or a remote server")
+	tailColor      = tailCommand.Flag("color", "Color per event type (auto|always|never)").Default(colorAuto).Enum(colors...)
+	tailConfigFile = tailCommand.Flag("config-file", "Config file (.yml) of Gerrit, the database and the trigger rules").String()
+	tailDatabase   = tailCommand.Flag("database", "SQLite file of the history (default: spec.storage.sqlite.filename)").String()
+	tailUrl        = tailCommand.Flag("url", "URL of a remote server (e.g., http://host:8080)").String()
+	tailToken      = tailCommand.Flag("token", "Bearer token of the remote server").String()
+	tailUsername   = tailCommand.Flag("username", "Username of the remote server").String()
+	tailPassword   = tailCommand.Flag("password", "Password of the remote server").String()
+	tailOutput     = tailCommand.Flag("output", "Output format (line|json|ndjson)").Default(outputLine).Enum(tailOutputs...)
+	tailProjects   = tailCommand.Flag("project", "Project of the events (repeatable)").Strings()
+	tailSince      = tailCommand.Flag("since", "Print history first, since a duration (e.g., 1h) or 'TIME'").String()
+	tailTrigger    = tailCommand.Flag("trigger", "Trigger rule matching the events").String()
+	tailTypes      = tailCommand.Flag("type", "Type of the events (repeatable)").Strings()
+
+	configCommand      = app.Command("config", "Manage the config file")
+	configCheckCommand = configCommand.Command("check", "Validate the config file, reporting all the invalid fields")
+	configCheckDump    = configCheckCommand.Flag("dump", "Print the effective config, with secrets masked").Bool()
+	configCheckFile    = configCheckCommand.Arg("file", "Config file (.yml)").Required().String()
 )
 
 func Run(ctx context.Context) error {
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
 
 	logger, err := initLogger(ctx, *logLevel)
 	if err != nil {
 		return errors.Wrap(err, "failed to init logger")
 	}
 
+	switch command {
+	case queryCommand.FullCommand():
+		return commandQuery(ctx, logger)
+	case exportCommand.FullCommand():
+		return commandExport(ctx, logger)
+	case importCommand.FullCommand():
+		return commandImport(ctx, logger)
+	case tailCommand.FullCommand():
+		return commandTail(ctx, logger)
+	case configCheckCommand.FullCommand():
+		return commandConfigCheck(ctx, logger, os.Stdout)
+	default:
+		return serve(ctx, logger)
+	}
+}
+
+func serve(ctx context.Context, logger hclog.Logger) error {
 	cfg, err := initConfig(ctx, logger, *configFile)
 	if err != nil {
 		return errors.Wrap(err, "failed to init config")
@@ -60,7 +142,7 @@ func Run(ctx context.Context) error {
 		return errors.Wrap(err, "failed to init watchdog")
 	}
 
-	s, err := initServer(ctx, logger, cfg, *listenPort, mq, st, wd)
+	s, err := initServer(ctx, logger, cfg, *listenPort, *grpcPort, mq, st, wd)
 	if err != nil {
 		return errors.Wrap(err, "failed to init server")
 	}
@@ -72,6 +154,115 @@ func Run(ctx context.Context) error {
 	return nil
 }
 
+func commandQuery(ctx context.Context, logger hclog.Logger) error {
+	opts := queryOptions{
+		ConfigFile: *queryConfigFile,
+		Database:   *queryDatabase,
+		Url:        *queryUrl,
+		Token:      *queryToken,
+		Username:   *queryUsername,
+		Password:   *queryPassword,
+		Output:     *queryOutput,
+		Projects:   *queryProjects,
+		Query:      *queryString,
+		Trigger:    *queryTrigger,
+		Types:      *queryTypes,
+	}
+
+	if err := runQuery(ctx, logger, &opts, os.Stdout); err != nil {
+		return errors.Wrap(err, "failed to run query")
+	}
+
+	return nil
+}
+
+func commandExport(ctx context.Context, logger hclog.Logger) error {
+	opts := queryOptions{
+		Archive:    *exportArchive,
+		ConfigFile: *exportConfigFile,
+		Database:   *exportDatabase,
+		Projects:   *exportProjects,
+		Query:      *exportString,
+		Trigger:    *exportTrigger,
+		Types:      *exportTypes,
+	}
+
+	if err := runExport(ctx, logger, &opts, *exportFile); err != nil {
+		return errors.Wrap(err, "failed to run export")
+	}
+
+	return nil
+}
+
+func commandImport(ctx context.Context, logger hclog.Logger) error {
+	opts := importOptions{
+		ConfigFile: *importConfigFile,
+		Database:   *importDatabase,
+		File:       *importFile,
+		Source:     *importSource,
+		Url:        *importUrl,
+		Username:   *importUsername,
+		Password:   *importPassword,
+		Query:      *importQuery,
+	}
+
+	if err := runImport(ctx, logger, &opts); err != nil {
+		return errors.Wrap(err, "failed to run import")
+	}
+
+	return nil
+}
+
+func commandTail(ctx context.Context, logger hclog.Logger) error {
+	opts := tailOptions{
+		queryOptions: queryOptions{
+			ConfigFile: *tailConfigFile,
+			Database:   *tailDatabase,
+			Url:        *tailUrl,
+			Token:      *tailToken,
+			Username:   *tailUsername,
+			Password:   *tailPassword,
+			Output:     *tailOutput,
+			Projects:   *tailProjects,
+			Trigger:    *tailTrigger,
+			Types:      *tailTypes,
+		},
+		Color: *tailColor,
+		Since: *tailSince,
+	}
+
+	// Streamed until interrupted
+	c, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := runTail(c, logger, &opts, os.Stdout); err != nil {
+		return errors.Wrap(err, "failed to run tail")
+	}
+
+	return nil
+}
+
+func commandConfigCheck(ctx context.Context, logger hclog.Logger, w io.Writer) error {
+	cfg, err := initConfig(ctx, logger, *configCheckFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to check config")
+	}
+
+	if !*configCheckDump {
+		_, _ = fmt.Fprintln(w, *configCheckFile+": ok")
+		return nil
+	}
+
+	buf, err := yaml.Marshal(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal config")
+	}
+
+	_, err = w.Write(buf)
+
+	return err
+}
+
 func initLogger(_ context.Context, level string) (hclog.Logger, error) {
 	return hclog.New(&hclog.LoggerOptions{
 		Name:  name,
@@ -82,24 +273,31 @@ func initLogger(_ context.Context, level string) (hclog.Logger, error) {
 func initConfig(_ context.Context, logger hclog.Logger, name string) (*config.Config, error) {
 	logger.Debug("cmd: initConfig")
 
-	c := config.New()
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return config.New(), errors.Wrap(err, "failed to open")
+	}
 
-	fi, err := os.Open(name)
+	c, err := config.Load(buf)
 	if err != nil {
-		return c, errors.Wrap(err, "failed to open")
+		return c, errors.Wrap(err, "invalid config "+name)
 	}
 
-	defer func() {
-		_ = fi.Close()
-	}()
+	return c, nil
+}
 
-	buf, _ := io.ReadAll(fi)
+func initAuth(ctx context.Context, logger hclog.Logger, cfg *config.Config) (auth.Auth, error) {
+	logger.Debug("cmd: initAuth")
 
-	if err := yaml.Unmarshal(buf, c); err != nil {
-		return c, errors.Wrap(err, "failed to unmarshal")
+	c := auth.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
 	}
 
-	return c, nil
+	c.Config = *cfg
+	c.Logger = logger
+
+	return auth.New(ctx, c), nil
 }
 
 func initConnect(ctx context.Context, logger hclog.Logger, cfg *config.Config) (connect.Ssh, error) {
@@ -116,6 +314,20 @@ func initConnect(ctx context.Context, logger hclog.Logger, cfg *config.Config) (
 	return connect.SshNew(ctx, c), nil
 }
 
+func initRest(ctx context.Context, logger hclog.Logger, cfg *config.Config) (connect.Rest, error) {
+	logger.Debug("cmd: initRest")
+
+	c := connect.DefaultRestConfig()
+	if c == nil {
+		return nil, errors.New("failed to config rest")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return connect.RestNew(ctx, c), nil
+}
+
 func initQueue(ctx context.Context, logger hclog.Logger, cfg *config.Config) (queue.Queue, error) {
 	logger.Debug("cmd: initQueue")
 
@@ -144,6 +356,152 @@ func initStorage(ctx context.Context, logger hclog.Logger, cfg *config.Config) (
 	return storage.New(ctx, c), nil
 }
 
+func initTrigger(ctx context.Context, logger hclog.Logger, cfg *config.Config) (trigger.Trigger, error) {
+	logger.Debug("cmd: initTrigger")
+
+	c := trigger.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return trigger.New(ctx, c), nil
+}
+
+func initVisibility(ctx context.Context, logger hclog.Logger, cfg *config.Config) (visibility.Visibility, error) {
+	logger.Debug("cmd: initVisibility")
+
+	c := visibility.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return visibility.New(ctx, c), nil
+}
+
+func initLeader(ctx context.Context, logger hclog.Logger, cfg *config.Config) (leader.Leader, error) {
+	logger.Debug("cmd: initLeader")
+
+	c := leader.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return leader.New(ctx, c), nil
+}
+
+func initExec(ctx context.Context, logger hclog.Logger, cfg *config.Config) (sink.Sink, error) {
+	logger.Debug("cmd: initExec")
+
+	c := exec.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return exec.New(ctx, c), nil
+}
+
+func initFile(ctx context.Context, logger hclog.Logger, cfg *config.Config) (sink.Sink, error) {
+	logger.Debug("cmd: initFile")
+
+	c := file.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return file.New(ctx, c), nil
+}
+
+func initNats(ctx context.Context, logger hclog.Logger, cfg *config.Config) (sink.Sink, error) {
+	logger.Debug("cmd: initNats")
+
+	c := nats.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return nats.New(ctx, c), nil
+}
+
+func initRedis(ctx context.Context, logger hclog.Logger, cfg *config.Config) (sink.Sink, error) {
+	logger.Debug("cmd: initRedis")
+
+	c := redis.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+
+	return redis.New(ctx, c), nil
+}
+
+// initSink fans out the events to the sinks which are set
+func initSink(ctx context.Context, logger hclog.Logger, cfg *config.Config, tr trigger.Trigger) (sink.Dispatcher, error) {
+	logger.Debug("cmd: initSink")
+
+	c := sink.DefaultConfig()
+	if c == nil {
+		return nil, errors.New("failed to config")
+	}
+
+	c.Config = *cfg
+	c.Logger = logger
+	c.Trigger = tr
+
+	if len(cfg.Spec.Exec.Command) != 0 {
+		s, err := initExec(ctx, logger, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init exec")
+		}
+		c.Sinks = append(c.Sinks, sink.Target{Delivery: cfg.Spec.Exec.Delivery, Name: "exec", Sink: s})
+	}
+
+	if cfg.Spec.File.Directory != "" {
+		s, err := initFile(ctx, logger, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init file")
+		}
+		c.Sinks = append(c.Sinks, sink.Target{Delivery: cfg.Spec.File.Delivery, Name: "file", Sink: s})
+	}
+
+	if cfg.Spec.Nats.Url != "" {
+		s, err := initNats(ctx, logger, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init nats")
+		}
+		c.Sinks = append(c.Sinks, sink.Target{Delivery: cfg.Spec.Nats.Delivery, Name: "nats", Sink: s})
+	}
+
+	if cfg.Spec.Redis.Url != "" {
+		s, err := initRedis(ctx, logger, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init redis")
+		}
+		c.Sinks = append(c.Sinks, sink.Target{Delivery: cfg.Spec.Redis.Delivery, Name: "redis", Sink: s})
+	}
+
+	return sink.New(ctx, c), nil
+}
+
 func initWatchdog(ctx context.Context, logger hclog.Logger, cfg *config.Config) (watchdog.Watchdog, error) {
 	logger.Debug("cmd: initWatchdog")
 
@@ -158,8 +516,8 @@ func initWatchdog(ctx context.Context, logger hclog.Logger, cfg *config.Config)
 	return watchdog.New(ctx, c), nil
 }
 
-func initServer(ctx context.Context, logger hclog.Logger, cfg *config.Config, port int, mq queue.Queue, st storage.Storage,
-	wd watchdog.Watchdog) (server.Server, error) {
+func initServer(ctx context.Context, logger hclog.Logger, cfg *config.Config, port, grpcPort int, mq queue.Queue,
+	st storage.Storage, wd watchdog.Watchdog) (server.Server, error) {
 	logger.Debug("cmd: initServer")
 
 	var err error
@@ -170,17 +528,51 @@ func initServer(ctx context.Context, logger hclog.Logger, cfg *config.Config, po
 	}
 
 	c.Config = *cfg
+	c.GrpcPort = grpcPort
+	c.Load = func(ctx context.Context) (*config.Config, error) {
+		return initConfig(ctx, logger, *configFile)
+	}
 	c.Logger = logger
 	c.Port = port
 	c.Queue = mq
 	c.Storage = st
 	c.Watchdog = wd
 
+	c.Auth, err = initAuth(ctx, logger, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init auth")
+	}
+
+	c.Leader, err = initLeader(ctx, logger, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init leader")
+	}
+
 	c.Ssh, err = initConnect(ctx, logger, cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to init connect")
 	}
 
+	c.Rest, err = initRest(ctx, logger, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init rest")
+	}
+
+	c.Trigger, err = initTrigger(ctx, logger, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init trigger")
+	}
+
+	c.Sink, err = initSink(ctx, logger, cfg, c.Trigger)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init sink")
+	}
+
+	c.Visibility, err = initVisibility(ctx, logger, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init visibility")
+	}
+
 	return server.New(ctx, c), nil
 }
 
@@ -197,21 +589,45 @@ func runServer(ctx context.Context, logger hclog.Logger, srv server.Server) erro
 	// kill (no param) default send syscanll.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can"t be caught, so don't need add it
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	// kill -1 is syscall.SIGHUP to reload the config
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 
 	go func(c context.Context, s server.Server) {
 		logger.Debug("cmd: runServer: Run")
-		_ = s.Run(c)
+		if err := s.Run(c); err != nil {
+			logger.Error("cmd: runServer: failed to run", "error", err)
+		}
 	}(ctx, srv)
 
 	go func(ctx context.Context, srv server.Server, sig chan os.Signal) {
-		logger.Debug("cmd: runServer: Deinit")
-		<-sig
-		_ = srv.Deinit(ctx)
-		done <- true
+		for s := range sig {
+			if s == syscall.SIGHUP {
+				reloadServer(ctx, logger, srv)
+				continue
+			}
+			logger.Debug("cmd: runServer: Deinit")
+			_ = srv.Deinit(ctx)
+			done <- true
+			return
+		}
 	}(ctx, srv, sig)
 
 	<-done
 
 	return nil
 }
+
+func reloadServer(ctx context.Context, logger hclog.Logger, srv server.Server) {
+	logger.Debug("cmd: reloadServer")
+
+	cfg, err := initConfig(ctx, logger, *configFile)
+	if err != nil {
+		logger.Error("cmd: failed to reload config, keeping the previous one", "error", err)
+		return
+	}
+
+	if err := srv.Reload(ctx, cfg); err != nil {
+		logger.Error("cmd: failed to reload config, keeping the previous one", "error", err)
+		return
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,6 +55,50 @@ func TestInitConfig(t *testing.T) {
 	assert.Equal(t, nil, err)
 }
 
+func TestCommandConfigCheck(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	ctx := context.Background()
+
+	var w strings.Builder
+
+	*configCheckFile = "../test/config/config.yml"
+
+	err := commandConfigCheck(ctx, logger, &w)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "../test/config/config.yml: ok\n", w.String())
+
+	name := filepath.Join(t.TempDir(), "config.yml")
+	_ = os.WriteFile(name, []byte("apiVersion: v1\nkind: events\nspec:\n  storage:\n    autoclean: \"@yearly\"\n    sqllite: {}\n"), 0o600)
+
+	*configCheckFile = name
+
+	err = commandConfigCheck(ctx, logger, &w)
+	assert.Equal(t, true, strings.HasSuffix(err.Error(), "line 6: spec.storage.sqllite: unknown field"))
+
+	t.Setenv("EVENTS_SPEC_CONNECT_REST_PASSWORD", "s3cret")
+
+	w.Reset()
+	*configCheckDump = true
+	*configCheckFile = "../test/config/config.yml"
+
+	defer func() {
+		*configCheckDump = false
+	}()
+
+	err = commandConfigCheck(ctx, logger, &w)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(w.String(), "password: '******'"))
+	assert.Equal(t, false, strings.Contains(w.String(), "s3cret"))
+}
+
+func TestInitAuth(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initAuth(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
 func TestInitConnect(t *testing.T) {
 	logger, _ := initLogger(context.Background(), level)
 	cfg := testInitConfig()
@@ -61,6 +107,14 @@ func TestInitConnect(t *testing.T) {
 	assert.Equal(t, nil, err)
 }
 
+func TestInitRest(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initRest(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
 func TestInitQueue(t *testing.T) {
 	logger, _ := initLogger(context.Background(), level)
 	cfg := testInitConfig()
@@ -77,6 +131,66 @@ func TestInitStorage(t *testing.T) {
 	assert.Equal(t, nil, err)
 }
 
+func TestInitTrigger(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initTrigger(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
+func TestInitVisibility(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initVisibility(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
+func TestInitExec(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initExec(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
+func TestInitLeader(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initLeader(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
+func TestInitNats(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initNats(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
+func TestInitRedis(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+
+	_, err := initRedis(context.Background(), logger, cfg)
+	assert.Equal(t, nil, err)
+}
+
+func TestInitSink(t *testing.T) {
+	logger, _ := initLogger(context.Background(), level)
+	cfg := testInitConfig()
+	cfg.Spec.Exec.Command = []string{"/path/to/hook.sh"}
+	cfg.Spec.File.Directory = t.TempDir()
+	cfg.Spec.Nats.Url = "nats://localhost:4222"
+	cfg.Spec.Redis.Url = "redis://localhost:6379"
+
+	_, err := initSink(context.Background(), logger, cfg, nil)
+	assert.Equal(t, nil, err)
+}
+
 func TestInitWatchdog(t *testing.T) {
 	logger, _ := initLogger(context.Background(), level)
 	cfg := testInitConfig()
@@ -89,6 +203,6 @@ func TestInitServer(t *testing.T) {
 	logger, _ := initLogger(context.Background(), level)
 	cfg := testInitConfig()
 
-	_, err := initServer(context.Background(), logger, cfg, port, nil, nil, nil)
+	_, err := initServer(context.Background(), logger, cfg, port, 0, nil, nil, nil)
 	assert.Equal(t, nil, err)
 }
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/server"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	sourceArchive   = "archive"
+	sourceCapture   = "capture"
+	sourceEventsLog = "events-log"
+	sourceRest      = "rest"
+
+	captureLine    = 1 << 22
+	eventsLogEvent = "event"
+)
+
+var (
+	sources = []string{sourceArchive, sourceCapture, sourceEventsLog, sourceRest}
+)
+
+type importOptions struct {
+	// Local database
+	ConfigFile string
+	Database   string
+
+	// File of the archive, capture or events-log dump
+	File   string
+	Source string
+
+	// events-log REST API, defaults to spec.connect.rest
+	Url      string
+	Username string
+	Password string
+	Query    string
+}
+
+// runImport stores the events of the source missing in the database
+func runImport(ctx context.Context, logger hclog.Logger, opts *importOptions) error {
+	logger.Debug("cmd: runImport")
+
+	cfg, err := localConfig(ctx, logger, opts.ConfigFile, opts.Database)
+	if err != nil {
+		return err
+	}
+
+	var read func(func(*record) error) error
+
+	switch opts.Source {
+	case sourceArchive, "":
+		m, err := verifyArchive(opts.File)
+		if err != nil {
+			return err
+		}
+		read = func(fn func(*record) error) error {
+			_, _, err := readArchive(opts.File, m.Format, fn)
+			return err
+		}
+	case sourceCapture:
+		read = func(fn func(*record) error) error {
+			return readCapture(logger, opts.File, fn)
+		}
+	case sourceEventsLog:
+		read = func(fn func(*record) error) error {
+			return readEventsLog(logger, opts.File, fn)
+		}
+	case sourceRest:
+		if opts.Url != "" {
+			cfg.Spec.Connect.Rest.Url = opts.Url
+			cfg.Spec.Connect.Rest.Username = opts.Username
+			cfg.Spec.Connect.Rest.Password = config.Secret(opts.Password)
+		}
+		read = func(fn func(*record) error) error {
+			return readRest(ctx, logger, cfg, opts.Query, fn)
+		}
+	default:
+		return errors.New("invalid source " + opts.Source)
+	}
+
+	st, _ := initStorage(ctx, logger, cfg)
+	if err = st.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init storage")
+	}
+
+	defer func() {
+		_ = st.Deinit(ctx)
+	}()
+
+	var batch []record
+
+	count, imported := 0, 0
+
+	flush := func() error {
+		n, err := importBatch(ctx, st, batch)
+		imported += n
+		batch = batch[:0]
+		return err
+	}
+
+	err = read(func(r *record) error {
+		count++
+		batch = append(batch, *r)
+		if len(batch) < storage.BatchSize {
+			return nil
+		}
+		return flush()
+	})
+
+	if err == nil && len(batch) != 0 {
+		err = flush()
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to import")
+	}
+
+	logger.Info("cmd: runImport: imported", "source", opts.Source, "count", imported, "skipped", count-imported)
+
+	return nil
+}
+
+// readCapture reads the output of ssh stream-events saved to a file, one event per line
+func readCapture(logger hclog.Logger, name string, fn func(*record) error) error {
+	fi, err := os.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to open")
+	}
+
+	defer func() {
+		_ = fi.Close()
+	}()
+
+	scan := bufio.NewScanner(fi)
+	scan.Buffer(make([]byte, bufio.MaxScanTokenSize), captureLine)
+
+	for n := 1; scan.Scan(); n++ {
+		line := bytes.TrimSpace(scan.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := readEvent(logger, line, fn); err != nil {
+			return errors.Wrap(err, "failed to read line "+strconv.Itoa(n))
+		}
+	}
+
+	if err := scan.Err(); err != nil {
+		return errors.Wrap(err, "failed to scan")
+	}
+
+	return nil
+}
+
+// readEventsLog reads a CSV dump of the table of the events-log plugin, with a header naming the column of the event
+func readEventsLog(logger hclog.Logger, name string, fn func(*record) error) error {
+	fi, err := os.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to open")
+	}
+
+	defer func() {
+		_ = fi.Close()
+	}()
+
+	r := csv.NewReader(fi)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return errors.Wrap(err, "failed to read header")
+	}
+
+	column := -1
+
+	for i := range header {
+		if strings.EqualFold(strings.TrimSpace(header[i]), eventsLogEvent) {
+			column = i
+		}
+	}
+
+	if column < 0 {
+		return errors.New("missing column " + eventsLogEvent)
+	}
+
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read row")
+		}
+		if column >= len(row) {
+			return errors.New("invalid row")
+		}
+		if err := readEvent(logger, []byte(row[column]), fn); err != nil {
+			return errors.Wrap(err, "failed to read row")
+		}
+	}
+}
+
+// readRest fetches the events matched by the query from the REST API of the events-log plugin
+func readRest(ctx context.Context, logger hclog.Logger, cfg *config.Config, query string, fn func(*record) error) error {
+	since, until, err := server.ParseQuery(query)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse query")
+	}
+
+	if cfg.Spec.Connect.Rest.Url == "" {
+		return errors.New("missing url")
+	}
+
+	r, _ := initRest(ctx, logger, cfg)
+	if err := r.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init rest")
+	}
+
+	defer func() {
+		_ = r.Deinit(ctx)
+	}()
+
+	b, err := r.Events(ctx, since, until)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch")
+	}
+
+	for _, item := range b {
+		if err := readEvent(logger, []byte(item), fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readEvent calls fn with the event, skipping the lines which are not events and the dropped output notices
+func readEvent(logger hclog.Logger, data []byte, fn func(*record) error) error {
+	e := events.Event{}
+
+	if err := json.Unmarshal(data, &e); err != nil || e.Type == "" {
+		logger.Warn("cmd: readEvent: invalid event", "size", len(data))
+		return nil
+	}
+
+	if e.Type == events.EVENTS_DROPPED_OUTPUT {
+		return nil
+	}
+
+	return fn(&record{EventBase64: base64.StdEncoding.EncodeToString(data), EventCreatedOn: e.EventCreatedOn})
+}
+
+// importBatch stores the records not stored yet, compared by event ID, or by creation time and content for those stored
+// without, and returns their number
+func importBatch(ctx context.Context, st storage.Storage, batch []record) (int, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	since, until := batch[0].EventCreatedOn, batch[0].EventCreatedOn
+
+	for i := range batch {
+		since = min(since, batch[i].EventCreatedOn)
+		until = max(until, batch[i].EventCreatedOn)
+	}
+
+	b, err := st.Read(ctx, since, until+1)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read")
+	}
+
+	stored := map[string]bool{}
+
+	// Identified by the event ID, or by the content if invalid
+	for i := range b {
+		k := b[i].EventID
+		if k == "" {
+			k = eventKey(b[i].EventCreatedOn, b[i].EventBase64)
+		}
+		stored[k] = true
+	}
+
+	var data []storage.Model
+
+	for i := range batch {
+		id := storage.EventID(batch[i].EventBase64)
+		k := id
+		if k == "" {
+			k = eventKey(batch[i].EventCreatedOn, batch[i].EventBase64)
+		}
+		if stored[k] {
+			continue
+		}
+		stored[k] = true
+		data = append(data, storage.Model{EventBase64: batch[i].EventBase64, EventCreatedOn: batch[i].EventCreatedOn, EventID: id})
+	}
+
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	if err := st.Create(ctx, data); err != nil {
+		return 0, errors.Wrap(err, "failed to create")
+	}
+
+	return len(data), nil
+}
+
+// eventKey identifies an event by its creation time and content, regardless of the formatting of its JSON,
+// as the events-log plugin and stream-events may not serialize the same event alike
+func eventKey(createdOn int64, data string) string {
+	buf, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return strconv.FormatInt(createdOn, 10) + ":" + data
+	}
+
+	var v any
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			buf = b
+		}
+	}
+
+	return strconv.FormatInt(createdOn, 10) + ":" + string(buf)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	importMerged = `{"type":"change-merged","change":{"project":"platform/build","number":2},"eventCreatedOn":1672567203}`
+	// Same event as queryCreated, serialized with other key order and spacing
+	importCreated = `{"eventCreatedOn": 1672567201, "type": "patchset-created",` +
+		` "change": {"subject": "Fix", "number": 1, "branch": "main", "project": "platform/build"}}`
+	importDropped = `{"type":"dropped-output","eventCreatedOn":1672567204}`
+)
+
+func TestImportCapture(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	name := initQueryDatabase(t)
+	file := filepath.Join(t.TempDir(), "capture.json")
+
+	capture := strings.Join([]string{importCreated, "", "Connection to gerrit closed.", importDropped, importMerged, importMerged}, "\n")
+	_ = os.WriteFile(file, []byte(capture+"\n"), 0o600)
+
+	err := runImport(ctx, logger, &importOptions{Database: name, File: file, Source: sourceCapture})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, countEvents(t, name))
+
+	err = runImport(ctx, logger, &importOptions{Database: name, File: file, Source: sourceCapture})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, countEvents(t, name))
+
+	err = runImport(ctx, logger, &importOptions{Database: name, File: file + ".invalid", Source: sourceCapture})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestImportEventID(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	name := initQueryDatabase(t)
+	file := filepath.Join(t.TempDir(), "capture.json")
+
+	_ = os.WriteFile(file, []byte(importMerged+"\n"), 0o600)
+
+	err := runImport(ctx, logger, &importOptions{Database: name, File: file, Source: sourceCapture})
+	assert.Equal(t, nil, err)
+
+	cfg := config.New()
+	cfg.Spec.Storage.Sqlite.Filename = name
+
+	st, _ := initStorage(ctx, logger, cfg)
+	assert.Equal(t, nil, st.Init(ctx))
+
+	defer func() {
+		_ = st.Deinit(ctx)
+	}()
+
+	// The live copy of the imported event is not stored again
+	data := base64.StdEncoding.EncodeToString([]byte(importMerged))
+
+	ok, err := st.Insert(ctx, &storage.Model{EventBase64: data, EventCreatedOn: 1672567203, EventID: storage.EventID(data)})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, 3, countEvents(t, name))
+}
+
+func TestImportEventsLog(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	name := filepath.Join(t.TempDir(), "test.db")
+	file := filepath.Join(t.TempDir(), "events-log.csv")
+
+	fi, _ := os.Create(file)
+	w := csv.NewWriter(fi)
+	_ = w.Write([]string{"ID", "PROJECT", "DATE_CREATED", "EVENT"})
+	_ = w.Write([]string{"1", "platform/build", "2023-01-01 10:00:01", queryCreated})
+	_ = w.Write([]string{"2", "platform/build", "2023-01-01 10:00:03", importMerged})
+	w.Flush()
+	_ = fi.Close()
+
+	err := runImport(ctx, logger, &importOptions{Database: name, File: file, Source: sourceEventsLog})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, countEvents(t, name))
+
+	_ = os.WriteFile(file, []byte("ID,PROJECT\n1,platform/build\n"), 0o600)
+
+	err = runImport(ctx, logger, &importOptions{Database: name, File: file, Source: sourceEventsLog})
+	assert.Equal(t, "failed to import: missing column event", err.Error())
+}
+
+func TestImportRest(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	name := initQueryDatabase(t)
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" || r.URL.Path != "/a/plugins/events-log/events/" {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s\n%s\n", queryCreated, importMerged)
+	}))
+	defer srv.Close()
+
+	opts := &importOptions{Database: name, Source: sourceRest, Url: srv.URL, Username: "user", Password: "pass", Query: queryRange}
+
+	err := runImport(ctx, logger, opts)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, countEvents(t, name))
+
+	opts.Password = "invalid"
+	err = runImport(ctx, logger, opts)
+	assert.NotEqual(t, nil, err)
+
+	opts.Url = ""
+	err = runImport(ctx, logger, opts)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestEventKey(t *testing.T) {
+	encode := func(data string) string {
+		return base64.StdEncoding.EncodeToString([]byte(data))
+	}
+
+	assert.Equal(t, eventKey(1672567201, encode(queryCreated)), eventKey(1672567201, encode(importCreated)))
+	assert.NotEqual(t, eventKey(1672567201, encode(queryCreated)), eventKey(1672567202, encode(importCreated)))
+	assert.NotEqual(t, eventKey(1672567203, encode(importMerged)), eventKey(1672567203, encode(importDropped)))
+	assert.Equal(t, "1:!", eventKey(1, "!"))
+}
@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/client"
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/server"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	outputJson   = "json"
+	outputNdjson = "ndjson"
+	outputTable  = "table"
+
+	tableLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	outputs = []string{outputTable, outputJson, outputNdjson}
+)
+
+type queryOptions struct {
+	// Directory of the file sink, read by export instead of the database
+	Archive string
+
+	// Local database, unless Url is set
+	ConfigFile string
+	Database   string
+
+	// Remote server
+	Url      string
+	Token    string
+	Username string
+	Password string
+
+	Output string
+
+	// Filter, as in the API
+	Projects []string
+	Query    string
+	Trigger  string
+	Types    []string
+}
+
+// queryEvent - Event found by the query, decoded
+type queryEvent struct {
+	CreatedOn int64
+	Event     events.Event
+	Raw       []byte
+}
+
+func runQuery(ctx context.Context, logger hclog.Logger, opts *queryOptions, w io.Writer) error {
+	logger.Debug("cmd: runQuery")
+
+	since, until, err := server.ParseQuery(opts.Query)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse query")
+	}
+
+	var b []queryEvent
+
+	if opts.Url != "" {
+		b, err = queryRemote(ctx, logger, opts, since, until)
+	} else {
+		b, err = queryLocal(ctx, logger, opts, since, until)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return printEvents(w, opts.Output, filterEvents(b, opts))
+}
+
+func queryLocal(ctx context.Context, logger hclog.Logger, opts *queryOptions, since, until int64) ([]queryEvent, error) {
+	logger.Debug("cmd: queryLocal")
+
+	var b []queryEvent
+
+	err := readLocal(ctx, logger, opts, since, until, func(e *queryEvent) error {
+		b = append(b, *e)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// readLocal calls fn with the events of the database created between since and until, ordered by ID,
+// and matched by the trigger rule if set
+func readLocal(ctx context.Context, logger hclog.Logger, opts *queryOptions, since, until int64, fn func(*queryEvent) error) error {
+	logger.Debug("cmd: readLocal")
+
+	cfg, err := localConfig(ctx, logger, opts.ConfigFile, opts.Database)
+	if err != nil {
+		return err
+	}
+
+	// Opening a missing file would create an empty database
+	if _, err = os.Stat(cfg.Spec.Storage.Sqlite.Filename); err != nil {
+		return errors.Wrap(err, "failed to find database")
+	}
+
+	st, _ := initStorage(ctx, logger, cfg)
+	if err = st.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init storage")
+	}
+
+	defer func() {
+		_ = st.Deinit(ctx)
+	}()
+
+	tr, _ := initTrigger(ctx, logger, cfg)
+	if err = tr.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init trigger")
+	}
+
+	if opts.Trigger != "" && !contains(tr.Rules(ctx), opts.Trigger) {
+		return errors.New("invalid rule " + opts.Trigger)
+	}
+
+	var after uint
+
+	for {
+		m, err := st.ReadPage(ctx, since, until, after, storage.BatchSize)
+		if err != nil {
+			return errors.Wrap(err, "failed to read")
+		}
+		for i := range m {
+			after = m[i].ID
+			buf, err := base64.StdEncoding.DecodeString(m[i].EventBase64)
+			if err != nil {
+				return errors.Wrap(err, "failed to decode base64")
+			}
+			e := queryEvent{CreatedOn: m[i].EventCreatedOn, Raw: buf}
+			if err := json.Unmarshal(buf, &e.Event); err != nil {
+				logger.Warn("cmd: readLocal: invalid event", "id", m[i].ID)
+				continue
+			}
+			if opts.Trigger != "" {
+				if ok, _ := tr.Match(ctx, opts.Trigger, &e.Event); !ok {
+					continue
+				}
+			}
+			if err := fn(&e); err != nil {
+				return err
+			}
+		}
+		if len(m) < storage.BatchSize {
+			return nil
+		}
+	}
+}
+
+// localConfig loads the config file if set, overriding its SQLite file with database if set
+func localConfig(ctx context.Context, logger hclog.Logger, configFile, database string) (*config.Config, error) {
+	var err error
+
+	cfg := config.New()
+
+	if configFile != "" {
+		if cfg, err = initConfig(ctx, logger, configFile); err != nil {
+			return nil, errors.Wrap(err, "failed to init config")
+		}
+	}
+
+	if database != "" {
+		cfg.Spec.Storage.Sqlite.Filename = database
+	}
+
+	if cfg.Spec.Storage.Sqlite.Filename == "" {
+		return nil, errors.New("missing database")
+	}
+
+	// Commands never clean the database of the server
+	cfg.Spec.Storage.Autoclean = ""
+
+	return cfg, nil
+}
+
+func queryRemote(ctx context.Context, logger hclog.Logger, opts *queryOptions, since, until int64) ([]queryEvent, error) {
+	logger.Debug("cmd: queryRemote")
+
+	c := client.DefaultConfig()
+	c.Url = opts.Url
+	c.Token = opts.Token
+	c.Username = opts.Username
+	c.Password = opts.Password
+	c.Logger = logger
+
+	var err error
+	var r []client.Event
+
+	cl := client.New(ctx, c)
+
+	if opts.Trigger != "" {
+		r, err = cl.QueryTrigger(ctx, opts.Trigger, time.Unix(since, 0), time.Unix(until, 0))
+	} else {
+		r, err = cl.Query(ctx, time.Unix(since, 0), time.Unix(until, 0))
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query")
+	}
+
+	b := make([]queryEvent, 0, len(r))
+
+	for i := range r {
+		b = append(b, queryEvent{CreatedOn: r[i].CreatedOn, Event: r[i].Event, Raw: r[i].Raw})
+	}
+
+	return b, nil
+}
+
+// filterEvents keeps the events of the types and projects, if set
+func filterEvents(data []queryEvent, opts *queryOptions) []queryEvent {
+	var b []queryEvent
+
+	for i := range data {
+		if matchEvent(&data[i], opts) {
+			b = append(b, data[i])
+		}
+	}
+
+	return b
+}
+
+func matchEvent(event *queryEvent, opts *queryOptions) bool {
+	if len(opts.Types) != 0 && !contains(opts.Types, event.Event.Type) {
+		return false
+	}
+
+	if len(opts.Projects) != 0 {
+		t, err := events.Decode(event.Raw)
+		if err != nil || !contains(opts.Projects, t.Project()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func printEvents(w io.Writer, output string, data []queryEvent) error {
+	switch output {
+	case outputJson:
+		b := make([]json.RawMessage, 0, len(data))
+		for i := range data {
+			b = append(b, data[i].Raw)
+		}
+		buf, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal")
+		}
+		_, err = fmt.Fprintln(w, string(buf))
+		return err
+	case outputNdjson:
+		for i := range data {
+			buf := bytes.Buffer{}
+			if err := json.Compact(&buf, data[i].Raw); err != nil {
+				return errors.Wrap(err, "failed to compact")
+			}
+			if _, err := fmt.Fprintln(w, buf.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputTable, "":
+		return printTable(w, data)
+	default:
+		return errors.New("invalid output " + output)
+	}
+}
+
+func printTable(w io.Writer, data []queryEvent) error {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(t, "CREATED\tTYPE\tPROJECT\tBRANCH\tCHANGE\tSUBJECT")
+
+	for i := range data {
+		project, branch := "", ""
+		if d, err := events.Decode(data[i].Raw); err == nil {
+			project, branch = d.Project(), d.Branch()
+		}
+		change := ""
+		if data[i].Event.Change.Number != 0 {
+			change = strconv.Itoa(data[i].Event.Change.Number)
+		}
+		_, _ = fmt.Fprintf(t, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			time.Unix(data[i].CreatedOn, 0).Format(tableLayout),
+			data[i].Event.Type,
+			project,
+			branch,
+			change,
+			data[i].Event.Change.Subject)
+	}
+
+	return t.Flush()
+}
+
+func contains(items []string, name string) bool {
+	for _, item := range items {
+		if item == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	queryCreated = `{"type":"patchset-created","change":{"project":"platform/build","branch":"main","number":1,"subject":"Fix"},` +
+		`"eventCreatedOn":1672567201}`
+	queryUpdated = `{"type":"ref-updated","refUpdate":{"project":"platform/test","refName":"refs/heads/main"},"eventCreatedOn":1672567202}`
+	queryRange   = "since:2023-01-01 00:00:00 until:2023-01-02 00:00:00"
+)
+
+func initQueryDatabase(t *testing.T) string {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+
+	cfg := config.New()
+	cfg.Spec.Storage.Sqlite.Filename = filepath.Join(t.TempDir(), "test.db")
+
+	st, _ := initStorage(ctx, logger, cfg)
+	assert.Equal(t, nil, st.Init(ctx))
+
+	defer func() {
+		_ = st.Deinit(ctx)
+	}()
+
+	err := st.Create(ctx, []storage.Model{
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(queryCreated)), EventCreatedOn: 1672567201},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(queryUpdated)), EventCreatedOn: 1672567202},
+	})
+	assert.Equal(t, nil, err)
+
+	return cfg.Spec.Storage.Sqlite.Filename
+}
+
+func TestQueryLocal(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+	name := initQueryDatabase(t)
+
+	var w bytes.Buffer
+
+	opts := &queryOptions{Database: name, Output: outputNdjson, Query: queryRange}
+	err := runQuery(ctx, logger, opts, &w)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, queryCreated+"\n"+queryUpdated+"\n", w.String())
+
+	w.Reset()
+	opts.Output = outputJson
+	opts.Types = []string{events.EVENTS_REF_UPDATED}
+	err = runQuery(ctx, logger, opts, &w)
+	assert.Equal(t, nil, err)
+
+	var b []json.RawMessage
+	assert.Equal(t, nil, json.Unmarshal(w.Bytes(), &b))
+	assert.Equal(t, 1, len(b))
+
+	w.Reset()
+	opts.Output = outputTable
+	opts.Types = nil
+	opts.Projects = []string{"platform/build"}
+	err = runQuery(ctx, logger, opts, &w)
+	assert.Equal(t, nil, err)
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, []string{"CREATED", "TYPE", "PROJECT", "BRANCH", "CHANGE", "SUBJECT"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{events.EVENTS_PATCHSET_CREATED, "platform/build", "main", "1", "Fix"}, strings.Fields(lines[1])[2:])
+
+	w.Reset()
+	opts.Output = outputNdjson
+	opts.Projects = nil
+	opts.ConfigFile = "../test/config/config.yml"
+	opts.Trigger = "invalid"
+	err = runQuery(ctx, logger, opts, &w)
+	assert.NotEqual(t, nil, err)
+
+	opts.ConfigFile = ""
+	opts.Trigger = ""
+	opts.Query = "since:2023-01-01 00:00:00"
+	err = runQuery(ctx, logger, opts, &w)
+	assert.NotEqual(t, nil, err)
+
+	opts.Query = queryRange
+	opts.Database = filepath.Join(t.TempDir(), "invalid.db")
+	err = runQuery(ctx, logger, opts, &w)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestQueryRemote(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := initLogger(ctx, level)
+
+	var paths []string
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"eventBase64": base64.StdEncoding.EncodeToString([]byte(queryUpdated)), "eventCreatedOn": 1672567202},
+		})
+	}))
+	defer srv.Close()
+
+	var w bytes.Buffer
+
+	opts := &queryOptions{Url: srv.URL, Token: "token", Output: outputNdjson, Query: queryRange, Trigger: "merged"}
+	err := runQuery(ctx, logger, opts, &w)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, queryUpdated+"\n", w.String())
+	assert.Equal(t, []string{"/triggers/merged/events/"}, paths)
+
+	w.Reset()
+	opts.Trigger = ""
+	opts.Types = []string{events.EVENTS_PATCHSET_CREATED}
+	err = runQuery(ctx, logger, opts, &w)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", w.String())
+	assert.Equal(t, "/events/", paths[1])
+
+	opts.Token = ""
+	err = runQuery(ctx, logger, opts, &w)
+	assert.NotEqual(t, nil, err)
+}
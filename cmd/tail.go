@@ -0,0 +1,379 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/client"
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/trigger"
+)
+
+const (
+	colorAlways = "always"
+	colorAuto   = "auto"
+	colorNever  = "never"
+
+	outputLine = "line"
+
+	colorReset    = "\033[0m"
+	tailBuffer    = 100
+	streamCommand = "stream-events"
+	tailNoColor   = "NO_COLOR"
+	tailTypeSize  = 22
+	tailWatchdog  = 20
+)
+
+var (
+	colors      = []string{colorAuto, colorAlways, colorNever}
+	tailOutputs = []string{outputLine, outputJson, outputNdjson}
+
+	// Colors of the event types, by outcome
+	typeColors = map[string]string{
+		events.EVENTS_BATCH_REF_UPDATED:     "\033[36m",
+		events.EVENTS_CHANGE_ABANDONED:      "\033[31m",
+		events.EVENTS_CHANGE_DELETED:        "\033[31m",
+		events.EVENTS_CHANGE_MERGED:         "\033[34m",
+		events.EVENTS_CHANGE_RESTORED:       "\033[32m",
+		events.EVENTS_COMMENT_ADDED:         "\033[33m",
+		events.EVENTS_DROPPED_OUTPUT:        "\033[1;31m",
+		events.EVENTS_MERGE_FAILED:          "\033[31m",
+		events.EVENTS_PATCHSET_CREATED:      "\033[32m",
+		events.EVENTS_PRIVATE_STATE_CHANGED: "\033[90m",
+		events.EVENTS_REF_UPDATED:           "\033[36m",
+		events.EVENTS_REVIEWER_ADDED:        "\033[35m",
+		events.EVENTS_REVIEWER_DELETED:      "\033[35m",
+		events.EVENTS_VOTE_DELETED:          "\033[33m",
+		events.EVENTS_WIP_STATE_CHANGED:     "\033[90m",
+	}
+)
+
+type tailOptions struct {
+	queryOptions
+
+	Color string
+
+	// Duration (e.g., 1h) or time (YYYY-MM-DD HH:MM:SS) from which history is printed first
+	Since string
+}
+
+// runTail prints the events streamed by Gerrit, or by the server if Url is set, matched by the filter
+func runTail(ctx context.Context, logger hclog.Logger, opts *tailOptions, w io.Writer) error {
+	logger.Debug("cmd: runTail")
+
+	var since int64
+
+	if opts.Since != "" {
+		t, err := parseSince(opts.Since, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "failed to parse since")
+		}
+		since = t.Unix()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	live := make(chan queryEvent, tailBuffer)
+	done := make(chan error, 1)
+
+	// Subscribed before reading history, so that no event is missed in between
+	if opts.Url != "" {
+		go func() {
+			done <- tailRemote(ctx, logger, opts, live)
+		}()
+	} else if err := tailLocal(ctx, logger, opts, live); err != nil {
+		return err
+	}
+
+	p := printer{w: w, output: opts.Output, color: useColor(opts.Color, w)}
+
+	printed := map[string]bool{}
+
+	if opts.Since != "" {
+		if err := tailHistory(ctx, logger, opts, since, &p, printed); err != nil {
+			return errors.Wrap(err, "failed to print history")
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-done:
+			return err
+		case e := <-live:
+			// Events printed with history are skipped once streamed
+			k := eventKey(e.CreatedOn, base64.StdEncoding.EncodeToString(e.Raw))
+			if printed[k] {
+				delete(printed, k)
+				continue
+			}
+			if !matchEvent(&e, &opts.queryOptions) {
+				continue
+			}
+			if err := p.print(&e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// tailLocal streams the events of Gerrit matched by the trigger rule of the config file, if set
+func tailLocal(ctx context.Context, logger hclog.Logger, opts *tailOptions, live chan queryEvent) error {
+	logger.Debug("cmd: tailLocal")
+
+	var err error
+
+	cfg := config.New()
+
+	if opts.ConfigFile != "" {
+		if cfg, err = initConfig(ctx, logger, opts.ConfigFile); err != nil {
+			return errors.Wrap(err, "failed to init config")
+		}
+	}
+
+	tr, _ := initTrigger(ctx, logger, cfg)
+	if err = tr.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init trigger")
+	}
+
+	if opts.Trigger != "" && !contains(tr.Rules(ctx), opts.Trigger) {
+		return errors.New("invalid rule " + opts.Trigger)
+	}
+
+	// Checked anyway, as the stream would hang once the session drops
+	if cfg.Spec.Watchdog.PeriodSeconds == 0 || cfg.Spec.Watchdog.TimeoutSeconds == 0 {
+		cfg.Spec.Watchdog.PeriodSeconds = tailWatchdog
+		cfg.Spec.Watchdog.TimeoutSeconds = tailWatchdog
+	}
+
+	ssh, _ := initConnect(ctx, logger, cfg)
+	if err = ssh.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init connect")
+	}
+
+	lines := make(chan string, tailBuffer)
+
+	if err = ssh.Start(ctx, streamCommand, lines); err != nil {
+		_ = ssh.Deinit(ctx)
+		return errors.Wrap(err, "failed to start")
+	}
+
+	wd, _ := initWatchdog(ctx, logger, cfg)
+	reconn := make(chan bool, 1)
+
+	go func() {
+		_ = wd.Run(ctx, ssh, reconn, make(chan bool, 1))
+	}()
+
+	go func() {
+		defer func() {
+			_ = ssh.Deinit(context.Background())
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reconn:
+				if err := ssh.Reconnect(ctx); err != nil {
+					logger.Warn("cmd: tailLocal: failed to reconnect", "error", err)
+					continue
+				}
+				if err := ssh.Start(ctx, streamCommand, lines); err != nil {
+					logger.Warn("cmd: tailLocal: failed to start", "error", err)
+				}
+			case line := <-lines:
+				e, ok := tailEvent(ctx, logger, tr, opts.Trigger, line)
+				if !ok {
+					continue
+				}
+				select {
+				case live <- *e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func tailEvent(ctx context.Context, logger hclog.Logger, tr trigger.Trigger, name, line string) (*queryEvent, bool) {
+	e := queryEvent{Raw: []byte(line)}
+
+	if err := json.Unmarshal(e.Raw, &e.Event); err != nil || e.Event.Type == "" {
+		logger.Warn("cmd: tailEvent: invalid event", "line", line)
+		return nil, false
+	}
+
+	e.CreatedOn = e.Event.EventCreatedOn
+
+	if name != "" {
+		if ok, _ := tr.Match(ctx, name, &e.Event); !ok {
+			return nil, false
+		}
+	}
+
+	return &e, true
+}
+
+// tailRemote subscribes to the stream of the trigger rule of the server, or to all events if not set
+func tailRemote(ctx context.Context, logger hclog.Logger, opts *tailOptions, live chan queryEvent) error {
+	logger.Debug("cmd: tailRemote")
+
+	c := client.DefaultConfig()
+	c.Url = opts.Url
+	c.Token = opts.Token
+	c.Username = opts.Username
+	c.Password = opts.Password
+	c.Logger = logger
+	c.Retry.Attempts = 0
+
+	return client.New(ctx, c).Subscribe(ctx, opts.Trigger, func(e *client.Event) error {
+		select {
+		case live <- queryEvent{CreatedOn: e.CreatedOn, Event: e.Event, Raw: e.Raw}:
+		case <-ctx.Done():
+		}
+		return nil
+	})
+}
+
+// tailHistory prints the events stored since then, from the database or the server, and records them as printed
+func tailHistory(ctx context.Context, logger hclog.Logger, opts *tailOptions, since int64, p *printer, printed map[string]bool) error {
+	logger.Debug("cmd: tailHistory")
+
+	var err error
+	var b []queryEvent
+
+	until := time.Now().Unix() + 1
+
+	if opts.Url != "" {
+		b, err = queryRemote(ctx, logger, &opts.queryOptions, since, until)
+	} else {
+		b, err = queryLocal(ctx, logger, &opts.queryOptions, since, until)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range filterEvents(b, &opts.queryOptions) {
+		printed[eventKey(e.CreatedOn, base64.StdEncoding.EncodeToString(e.Raw))] = true
+		if err := p.print(&e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseSince parses a duration before now, or a time in local time
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		if d < 0 {
+			return time.Time{}, errors.New("invalid duration")
+		}
+		return now.Add(-d), nil
+	}
+
+	t, err := time.ParseInLocation(tableLayout, since, time.Local)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid format")
+	}
+
+	return t, nil
+}
+
+// useColor colors the output if always, or if auto and writing to a terminal without NO_COLOR set
+func useColor(color string, w io.Writer) bool {
+	switch color {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+
+	if _, ok := os.LookupEnv(tailNoColor); ok {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+type printer struct {
+	w      io.Writer
+	output string
+	color  bool
+}
+
+func (p *printer) print(e *queryEvent) error {
+	var buf bytes.Buffer
+
+	switch p.output {
+	case outputNdjson:
+		if err := json.Compact(&buf, e.Raw); err != nil {
+			return errors.Wrap(err, "failed to compact")
+		}
+		_, err := fmt.Fprintln(p.w, buf.String())
+		return err
+	case outputJson:
+		if err := json.Indent(&buf, e.Raw, "", "  "); err != nil {
+			return errors.Wrap(err, "failed to indent")
+		}
+		_, err := fmt.Fprintln(p.w, p.paint(e.Event.Type, buf.String()))
+		return err
+	default:
+		project, branch := "", ""
+		if d, err := events.Decode(e.Raw); err == nil {
+			project, branch = d.Project(), d.Branch()
+		}
+		change := ""
+		if e.Event.Change.Number != 0 {
+			change = strconv.Itoa(e.Event.Change.Number)
+		}
+		_, err := fmt.Fprintf(p.w, "%s %s %s %s %s %s\n",
+			time.Unix(e.CreatedOn, 0).Format(tableLayout),
+			p.paint(e.Event.Type, fmt.Sprintf("%-*s", tailTypeSize, e.Event.Type)),
+			project,
+			branch,
+			change,
+			e.Event.Change.Subject)
+		return err
+	}
+}
+
+func (p *printer) paint(name, text string) string {
+	if !p.color {
+		return text
+	}
+
+	c, ok := typeColors[name]
+	if !ok {
+		return text
+	}
+
+	return c + text + colorReset
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/connect/gerrittest"
+	"github.com/gerrittrigger/events/events"
+)
+
+type syncBuffer struct {
+	buf   bytes.Buffer
+	mutex sync.Mutex
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.buf.String()
+}
+
+func waitOutput(w *syncBuffer, lines int) bool {
+	for i := 0; i < 100; i++ {
+		if strings.Count(w.String(), "\n") >= lines {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestTailLocal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger, _ := initLogger(ctx, level)
+
+	g, err := gerrittest.New(gerrittest.Config{})
+	assert.Equal(t, nil, err)
+
+	defer func() {
+		_ = g.Close()
+	}()
+
+	keyfile, _ := gerrittest.Keyfile(t.TempDir())
+
+	c := config.New()
+	c.ApiVersion = config.ApiVersion
+	c.Kind = config.Kind
+	c.Spec.Connect.Hostname = g.Hostname()
+	c.Spec.Connect.Ssh.Keyfile = keyfile
+	c.Spec.Connect.Ssh.Port = g.Port()
+	c.Spec.Connect.Ssh.Username = gerrittest.Username
+	c.Spec.Storage.Sqlite.Filename = initQueryDatabase(t)
+	c.Spec.Trigger.Rules = []config.Rule{{Name: "build", Events: []string{events.EVENTS_PATCHSET_CREATED, events.EVENTS_CHANGE_MERGED}}}
+	c.Spec.Watchdog.PeriodSeconds = 1
+	c.Spec.Watchdog.TimeoutSeconds = 1
+
+	buf, _ := yaml.Marshal(c)
+	name := filepath.Join(t.TempDir(), "config.yml")
+	_ = os.WriteFile(name, buf, 0o600)
+
+	w := &syncBuffer{}
+	done := make(chan error, 1)
+
+	opts := &tailOptions{
+		queryOptions: queryOptions{ConfigFile: name, Output: outputNdjson, Trigger: "build"},
+		Since:        "2023-01-01 00:00:00",
+	}
+
+	go func() {
+		done <- runTail(ctx, logger, opts, w)
+	}()
+
+	for i := 0; i < 100 && g.Streams() == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	assert.Equal(t, true, waitOutput(w, 1))
+
+	// Already printed with history, not matched by the rule, and then new
+	g.Push(importCreated)
+	g.Push(queryUpdated)
+	g.Push(importMerged)
+
+	assert.Equal(t, true, waitOutput(w, 2))
+
+	// Streamed again once reconnected by the watchdog
+	g.Disconnect()
+
+	for i := 0; i < 100 && g.Streams() != 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	for i := 0; i < 100 && g.Streams() == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	merged := `{"type":"change-merged","change":{"project":"platform/build","number":3},"eventCreatedOn":1672567205}`
+	g.Push(merged)
+
+	assert.Equal(t, true, waitOutput(w, 3))
+
+	cancel()
+	assert.Equal(t, nil, <-done)
+	assert.Equal(t, queryCreated+"\n"+importMerged+"\n"+merged+"\n", w.String())
+
+	opts.Trigger = "invalid"
+	err = runTail(context.Background(), logger, opts, w)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTailRemote(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger, _ := initLogger(ctx, level)
+
+	data := func(event string) string {
+		buf, _ := json.Marshal(map[string]any{
+			"eventBase64":    base64.StdEncoding.EncodeToString([]byte(event)),
+			"eventCreatedOn": 1672567201,
+		})
+		return string(buf)
+	}
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/triggers/build/events/":
+			_, _ = fmt.Fprintf(w, "[%s]", data(queryCreated))
+		case "/triggers/build/stream":
+			_, _ = fmt.Fprintf(w, "id:1\ndata:%s\n\nid:2\ndata:%s\n\n", data(queryCreated), data(importMerged))
+			w.(nethttp.Flusher).Flush()
+			<-r.Context().Done()
+		case "/events/stream":
+			_, _ = fmt.Fprintf(w, "id:3\ndata:%s\n\n", data(importMerged))
+			w.(nethttp.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(nethttp.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	w := &syncBuffer{}
+	done := make(chan error, 1)
+
+	opts := &tailOptions{
+		queryOptions: queryOptions{Url: srv.URL, Output: outputLine, Trigger: "build"},
+		Color:        colorNever,
+		Since:        "1h",
+	}
+
+	go func() {
+		done <- runTail(ctx, logger, opts, w)
+	}()
+
+	assert.Equal(t, true, waitOutput(w, 2))
+
+	cancel()
+	assert.Equal(t, nil, <-done)
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, []string{events.EVENTS_PATCHSET_CREATED, "platform/build", "main", "1", "Fix"}, strings.Fields(lines[0])[2:])
+	assert.Equal(t, []string{events.EVENTS_CHANGE_MERGED, "platform/build", "2"}, strings.Fields(lines[1])[2:])
+
+	// Every event streamed without trigger
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	w = &syncBuffer{}
+	opts.Trigger = ""
+	opts.Since = ""
+
+	go func() {
+		done <- runTail(ctx, logger, opts, w)
+	}()
+
+	assert.Equal(t, true, waitOutput(w, 1))
+
+	cancel()
+	assert.Equal(t, nil, <-done)
+	assert.Equal(t, []string{events.EVENTS_CHANGE_MERGED, "platform/build", "2"}, strings.Fields(w.String())[2:])
+}
+
+func TestPrinter(t *testing.T) {
+	e := queryEvent{CreatedOn: 1672567201, Raw: []byte(queryCreated)}
+	_ = json.Unmarshal(e.Raw, &e.Event)
+
+	var w bytes.Buffer
+
+	p := printer{w: &w, output: outputLine, color: true}
+	assert.Equal(t, nil, p.print(&e))
+	assert.Equal(t, true, strings.Contains(w.String(), typeColors[events.EVENTS_PATCHSET_CREATED]+events.EVENTS_PATCHSET_CREATED))
+	assert.Equal(t, true, strings.Contains(w.String(), colorReset))
+
+	w.Reset()
+	p = printer{w: &w, output: outputJson}
+	assert.Equal(t, nil, p.print(&e))
+	assert.Equal(t, true, strings.HasPrefix(w.String(), "{\n  \"type\": \"patchset-created\""))
+
+	w.Reset()
+	p.output = outputNdjson
+	assert.Equal(t, nil, p.print(&e))
+	assert.Equal(t, queryCreated+"\n", w.String())
+
+	assert.Equal(t, true, useColor(colorAlways, &w))
+	assert.Equal(t, false, useColor(colorNever, os.Stdout))
+	assert.Equal(t, false, useColor(colorAuto, &w))
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Unix(1672567200, 0)
+
+	s, err := parseSince("1h", now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1672563600), s.Unix())
+
+	s, err = parseSince("2023-01-01 10:00:00", now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local).Unix(), s.Unix())
+
+	_, err = parseSince("-1h", now)
+	assert.NotEqual(t, nil, err)
+
+	_, err = parseSince("yesterday", now)
+	assert.NotEqual(t, nil, err)
+}
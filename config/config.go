@@ -13,28 +13,157 @@ type MetaData struct {
 
 type Spec struct {
 	Connect  Connect  `yaml:"connect"`
+	Exec     Exec     `yaml:"exec"`
+	File     File     `yaml:"file"`
+	Leader   Leader   `yaml:"leader"`
 	Log      Log      `yaml:"log"`
+	Nats     Nats     `yaml:"nats"`
 	Queue    Queue    `yaml:"queue"`
+	Redis    Redis    `yaml:"redis"`
+	Server   Server   `yaml:"server"`
 	Storage  Storage  `yaml:"storage"`
+	Trigger  Trigger  `yaml:"trigger"`
 	Watchdog Watchdog `yaml:"watchdog"`
 }
 
 type Connect struct {
 	Hostname string `yaml:"hostname"`
+	Rest     Rest   `yaml:"rest"`
 	Ssh      Ssh    `yaml:"ssh"`
 }
 
+type Exec struct {
+	Command        []string `yaml:"command"`
+	Delivery       Delivery `yaml:"delivery"`
+	TimeoutSeconds int      `yaml:"timeoutSeconds"`
+}
+
+type File struct {
+	Compress      bool     `yaml:"compress"`
+	Delivery      Delivery `yaml:"delivery"`
+	Directory     string   `yaml:"directory"`
+	Retention     int      `yaml:"retention"`
+	RotateBytes   int      `yaml:"rotateBytes"`
+	RotateSeconds int      `yaml:"rotateSeconds"`
+}
+
+type Leader struct {
+	Holder       string `yaml:"holder"`
+	LeaseSeconds int    `yaml:"leaseSeconds"`
+	LockFile     string `yaml:"lockFile"`
+	Name         string `yaml:"name"`
+}
+
 type Log struct {
+	Level string `yaml:"level"`
+}
+
+type Nats struct {
+	CredentialsFile string    `yaml:"credentialsFile"`
+	Delivery        Delivery  `yaml:"delivery"`
+	JetStream       JetStream `yaml:"jetStream"`
+	Server          string    `yaml:"server"`
+	Subject         string    `yaml:"subject"`
+	Token           Secret    `yaml:"token"`
+	TokenFile       string    `yaml:"tokenFile"`
+	Url             string    `yaml:"url"`
+}
+
+type Delivery struct {
+	BackoffSeconds int    `yaml:"backoffSeconds"`
+	Buffer         int    `yaml:"buffer"`
+	Concurrency    int    `yaml:"concurrency"`
+	Retries        int    `yaml:"retries"`
+	Rule           string `yaml:"rule"`
+}
+
+type JetStream struct {
+	DuplicateWindowSeconds int    `yaml:"duplicateWindowSeconds"`
+	Stream                 string `yaml:"stream"`
 }
 
 type Queue struct {
 }
 
+type Redis struct {
+	Delivery     Delivery `yaml:"delivery"`
+	Layout       string   `yaml:"layout"`
+	MaxLen       int      `yaml:"maxLen"`
+	Password     Secret   `yaml:"password"`
+	PasswordFile string   `yaml:"passwordFile"`
+	Stream       string   `yaml:"stream"`
+	Url          string   `yaml:"url"`
+}
+
+type Rest struct {
+	Password     Secret `yaml:"password"`
+	PasswordFile string `yaml:"passwordFile"`
+	Url          string `yaml:"url"`
+	Username     string `yaml:"username"`
+}
+
+type Server struct {
+	Auth       Auth       `yaml:"auth"`
+	Cors       Cors       `yaml:"cors"`
+	Tls        Tls        `yaml:"tls"`
+	Visibility Visibility `yaml:"visibility"`
+}
+
+type Auth struct {
+	Htpasswd Htpasswd `yaml:"htpasswd"`
+	Tokens   []Token  `yaml:"tokens"`
+}
+
+type Htpasswd struct {
+	File   string   `yaml:"file"`
+	Scopes []string `yaml:"scopes"`
+}
+
+type Token struct {
+	Name      string   `yaml:"name"`
+	Token     Secret   `yaml:"token"`
+	TokenFile string   `yaml:"tokenFile"`
+	Scopes    []string `yaml:"scopes"`
+}
+
+type Cors struct {
+	AllowOrigins []string `yaml:"allowOrigins"`
+}
+
+type Tls struct {
+	CertFile          string   `yaml:"certFile"`
+	KeyFile           string   `yaml:"keyFile"`
+	ClientCAFile      string   `yaml:"clientCAFile"`
+	RequireClientCert bool     `yaml:"requireClientCert"`
+	MinVersion        string   `yaml:"minVersion"`
+	Clients           []Client `yaml:"clients"`
+}
+
+type Client struct {
+	CommonName string   `yaml:"commonName"`
+	Scopes     []string `yaml:"scopes"`
+}
+
+type Visibility struct {
+	Private string  `yaml:"private"`
+	Wip     string  `yaml:"wip"`
+	Groups  []Group `yaml:"groups"`
+}
+
+type Group struct {
+	Name    string   `yaml:"name"`
+	Members []string `yaml:"members"`
+	Private string   `yaml:"private"`
+	Wip     string   `yaml:"wip"`
+}
+
 type Ssh struct {
-	Keyfile         string `yaml:"keyfile"`
-	KeyfilePassword string `yaml:"keyfilePassword"`
-	Port            int    `yaml:"port"`
-	Username        string `yaml:"username"`
+	Keyfile             string `yaml:"keyfile"`
+	KeyfilePassword     Secret `yaml:"keyfilePassword"`
+	KeyfilePasswordFile string `yaml:"keyfilePasswordFile"`
+	KnownHosts          string `yaml:"knownHosts"`
+	Port                int    `yaml:"port"`
+	Username            string `yaml:"username"`
 }
 
 type Storage struct {
@@ -46,6 +175,35 @@ type Sqlite struct {
 	Filename string `yaml:"filename"`
 }
 
+type Trigger struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+type Rule struct {
+	Name          string    `yaml:"name"`
+	Events        []string  `yaml:"events"`
+	Projects      []Project `yaml:"projects"`
+	Comment       Comment   `yaml:"comment"`
+	CommitMessage string    `yaml:"commitMessage"`
+}
+
+type Project struct {
+	Pattern  `yaml:",inline"`
+	Branches []Pattern `yaml:"branches"`
+	Files    []Pattern `yaml:"files"`
+	Topics   []Pattern `yaml:"topics"`
+}
+
+type Pattern struct {
+	Type    string `yaml:"type"`
+	Pattern string `yaml:"pattern"`
+}
+
+type Comment struct {
+	Label string `yaml:"label"`
+	Value string `yaml:"value"`
+}
+
 type Watchdog struct {
 	PeriodSeconds  int `yaml:"periodSeconds"`
 	TimeoutSeconds int `yaml:"timeoutSeconds"`
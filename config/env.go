@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvPrefix = "EVENTS"
+
+	maxEnvItems = 1000
+)
+
+var (
+	// ${NAME}, or $${NAME} for a literal ${NAME}
+	envVariable = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// interpolate replaces ${NAME} in the scalar values of the YAML node with the environment variables
+func interpolate(node *yaml.Node, path string, errs *Errors) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			interpolate(item, path, errs)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			interpolate(node.Content[i+1], joinPath(path, node.Content[i].Value), errs)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			interpolate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return
+		}
+		node.Value = envVariable.ReplaceAllStringFunc(node.Value, func(s string) string {
+			if strings.HasPrefix(s, "$$") {
+				return s[1:]
+			}
+			name := s[2 : len(s)-1]
+			val, ok := os.LookupEnv(name)
+			if !ok {
+				*errs = append(*errs, &FieldError{Line: node.Line, Path: path, Message: "undefined variable " + name})
+			}
+			return val
+		})
+		// Resolved again as if unquoted, e.g., port: "${PORT}"
+		if node.Style&yaml.TaggedStyle == 0 {
+			node.Style = 0
+			node.Tag = ""
+		}
+	}
+}
+
+// environ returns the environment variables prefixed with EVENTS_
+func environ() map[string]string {
+	env := map[string]string{}
+
+	for _, item := range os.Environ() {
+		if key, val, ok := strings.Cut(item, "="); ok && strings.HasPrefix(key, EnvPrefix+"_") {
+			env[key] = val
+		}
+	}
+
+	return env
+}
+
+// applyEnv overrides the fields with the environment variables named after their path,
+// e.g., EVENTS_SPEC_CONNECT_SSH_PORT, and returns the variables by path of the fields overridden
+func (c *Config) applyEnv(env map[string]string, errs *Errors) map[string]string {
+	set := map[string]string{}
+
+	override(reflect.ValueOf(c).Elem(), "", EnvPrefix, env, set, errs)
+
+	return set
+}
+
+// override sets the field from the variable, items of lists being indexed (e.g., _TOKENS_0_NAME) or comma-separated for strings
+func override(v reflect.Value, path, name string, env, set map[string]string, errs *Errors) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if strings.Contains(opts, "inline") {
+				override(v.Field(i), path, name, env, set, errs)
+				continue
+			}
+			if key == "-" {
+				continue
+			}
+			if key == "" {
+				key = strings.ToLower(f.Name)
+			}
+			override(v.Field(i), joinPath(path, key), name+"_"+envName(key), env, set, errs)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			overrideItems(v, path, name, env, set, errs)
+			return
+		}
+		val, ok := env[name]
+		if !ok {
+			return
+		}
+		items := []string{}
+		for _, item := range strings.Split(val, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+		set[path] = name
+	case reflect.String, reflect.Int, reflect.Bool:
+		val, ok := env[name]
+		if !ok {
+			return
+		}
+		if err := setValue(v, val); err != nil {
+			errs.add(name, "%s", err.Error())
+			return
+		}
+		set[path] = name
+	}
+}
+
+// overrideItems grows the list up to the highest index of the variables, and overrides its items
+func overrideItems(v reflect.Value, path, name string, env, set map[string]string, errs *Errors) {
+	n := v.Len()
+	prefix := name + "_"
+
+	for key := range env {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		index, _, ok := strings.Cut(key[len(prefix):], "_")
+		if !ok {
+			continue
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			continue
+		}
+		if i >= maxEnvItems {
+			errs.add(key, "index must be less than %d", maxEnvItems)
+			continue
+		}
+		n = max(n, i+1)
+	}
+
+	if n > v.Len() {
+		items := reflect.MakeSlice(v.Type(), n, n)
+		reflect.Copy(items, v)
+		v.Set(items)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		override(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fmt.Sprintf("%s_%d", name, i), env, set, errs)
+	}
+}
+
+func setValue(v reflect.Value, val string) error {
+	switch v.Kind() {
+	case reflect.Int:
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return errors.Errorf("must be an integer, got %q", val)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return errors.Errorf("must be a boolean, got %q", val)
+		}
+		v.SetBool(b)
+	default:
+		v.SetString(val)
+	}
+
+	return nil
+}
+
+// envName converts the key from camel case to upper snake case, e.g., clientCAFile to CLIENT_CA_FILE
+func envName(key string) string {
+	var b strings.Builder
+
+	r := []rune(key)
+
+	for i, c := range r {
+		if i > 0 && unicode.IsUpper(c) &&
+			(unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1]) || (i+1 < len(r) && unicode.IsLower(r[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+
+	return b.String()
+}
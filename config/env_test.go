@@ -0,0 +1,85 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("TEST_HOSTNAME", "gerrit.example.com")
+	t.Setenv("TEST_PORT", "29419")
+
+	c, err := Load([]byte(`apiVersion: v1
+kind: events
+spec:
+  connect:
+    hostname: ${TEST_HOSTNAME}
+    ssh:
+      keyfile: /path/to/.ssh/id_rsa
+      port: "${TEST_PORT}"
+      username: $${TEST_HOSTNAME}
+`))
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "gerrit.example.com", c.Spec.Connect.Hostname)
+	assert.Equal(t, 29419, c.Spec.Connect.Ssh.Port)
+	assert.Equal(t, "${TEST_HOSTNAME}", c.Spec.Connect.Ssh.Username)
+
+	_, err = Load([]byte("apiVersion: v1\nkind: events\nmetadata:\n  name: ${TEST_UNDEFINED}\n"))
+	assert.Equal(t, "line 4: metadata.name: undefined variable TEST_UNDEFINED", err.Error())
+}
+
+func TestApplyEnv(t *testing.T) {
+	c := New()
+	c.Spec.Server.Auth.Tokens = []Token{{Name: "ci", Token: "secret"}}
+
+	var errs Errors
+
+	set := c.applyEnv(map[string]string{
+		"EVENTS_API_VERSION":                             "v1",
+		"EVENTS_SPEC_CONNECT_SSH_KEYFILE_PASSWORD":       "pass",
+		"EVENTS_SPEC_CONNECT_SSH_PORT":                   "29419",
+		"EVENTS_SPEC_SERVER_AUTH_TOKENS_0_SCOPES":        "read, admin",
+		"EVENTS_SPEC_SERVER_AUTH_TOKENS_2_NAME":          "bot",
+		"EVENTS_SPEC_SERVER_TLS_CLIENT_CA_FILE":          "/path/to/ca.crt",
+		"EVENTS_SPEC_SERVER_TLS_REQUIRE_CLIENT_CERT":     "true",
+		"EVENTS_SPEC_TRIGGER_RULES_0_PROJECTS_0_PATTERN": "platform/**",
+	}, &errs)
+
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, "v1", c.ApiVersion)
+	assert.Equal(t, Secret("pass"), c.Spec.Connect.Ssh.KeyfilePassword)
+	assert.Equal(t, 29419, c.Spec.Connect.Ssh.Port)
+	assert.Equal(t, []Token{{Name: "ci", Token: "secret", Scopes: []string{"read", "admin"}}, {}, {Name: "bot"}}, c.Spec.Server.Auth.Tokens)
+	assert.Equal(t, "/path/to/ca.crt", c.Spec.Server.Tls.ClientCAFile)
+	assert.Equal(t, true, c.Spec.Server.Tls.RequireClientCert)
+	assert.Equal(t, "platform/**", c.Spec.Trigger.Rules[0].Projects[0].Pattern.Pattern)
+	assert.Equal(t, "EVENTS_SPEC_CONNECT_SSH_PORT", set["spec.connect.ssh.port"])
+
+	errs = nil
+	_ = c.applyEnv(map[string]string{
+		"EVENTS_SPEC_CONNECT_SSH_PORT":             "ssh",
+		"EVENTS_SPEC_SERVER_AUTH_TOKENS_1000_NAME": "bot",
+	}, &errs)
+
+	assert.Equal(t, `EVENTS_SPEC_CONNECT_SSH_PORT: must be an integer, got "ssh"`+"\n"+
+		"EVENTS_SPEC_SERVER_AUTH_TOKENS_1000_NAME: index must be less than 1000", errs.Error())
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("EVENTS_KIND", "event")
+	t.Setenv("EVENTS_SPEC_CONNECT_HOSTNAME", "gerrit.example.com")
+
+	_, err := Load([]byte("apiVersion: v1\nkind: events\n"))
+	assert.Equal(t, `kind: must be "events", got "event" (set by EVENTS_KIND)`+"\n"+
+		"spec.connect.ssh.keyfile: is required with spec.connect.hostname\n"+
+		"spec.connect.ssh.username: is required with spec.connect.hostname", err.Error())
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "API_VERSION", envName("apiVersion"))
+	assert.Equal(t, "CLIENT_CA_FILE", envName("clientCAFile"))
+	assert.Equal(t, "KEYFILE_PASSWORD_FILE", envName("keyfilePasswordFile"))
+	assert.Equal(t, "SQLITE", envName("sqlite"))
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	mask = "******"
+)
+
+// Secret - Value masked when printed, logged or marshaled, to be converted to string where used
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return mask
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// ResolveSecrets reads the secrets set by their file variant, e.g., keyfilePasswordFile
+func (c *Config) ResolveSecrets() error {
+	var errs Errors
+
+	resolveSecret("spec.connect.ssh.keyfilePassword", &c.Spec.Connect.Ssh.KeyfilePassword, c.Spec.Connect.Ssh.KeyfilePasswordFile, &errs)
+	resolveSecret("spec.connect.rest.password", &c.Spec.Connect.Rest.Password, c.Spec.Connect.Rest.PasswordFile, &errs)
+	resolveSecret("spec.nats.token", &c.Spec.Nats.Token, c.Spec.Nats.TokenFile, &errs)
+	resolveSecret("spec.redis.password", &c.Spec.Redis.Password, c.Spec.Redis.PasswordFile, &errs)
+
+	for i := range c.Spec.Server.Auth.Tokens {
+		item := &c.Spec.Server.Auth.Tokens[i]
+		resolveSecret(fmt.Sprintf("spec.server.auth.tokens[%d].token", i), &item.Token, item.TokenFile, &errs)
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func resolveSecret(path string, secret *Secret, file string, errs *Errors) {
+	if file == "" {
+		return
+	}
+
+	if *secret != "" {
+		errs.add(path, "must not be set with %sFile", path[strings.LastIndex(path, ".")+1:])
+		return
+	}
+
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		errs.add(path+"File", "failed to read: %s", err.Error())
+		return
+	}
+
+	*secret = Secret(strings.TrimRight(string(buf), "\r\n"))
+}
+
+// Redacted returns a copy of the config whose URLs have their userinfo masked, e.g., redis://:pass@host or nats://token@host
+func (c Config) Redacted() Config {
+	c.Spec.Connect.Rest.Url = redactURL(c.Spec.Connect.Rest.Url)
+	c.Spec.Nats.Url = redactURL(c.Spec.Nats.Url)
+	c.Spec.Redis.Url = redactURL(c.Spec.Redis.Url)
+
+	return c
+}
+
+// redactURL masks the userinfo of the URL, or of each of them if comma-separated
+func redactURL(s string) string {
+	b := strings.Split(s, ",")
+
+	for i, item := range b {
+		u, err := url.Parse(strings.TrimSpace(item))
+		if err != nil {
+			// Masked whole as the userinfo cannot be told apart
+			if strings.Contains(item, "@") {
+				b[i] = mask
+			}
+			continue
+		}
+		if u.User != nil {
+			u.User = nil
+			b[i] = u.Scheme + "://" + mask + "@" + strings.TrimPrefix(u.String(), u.Scheme+"://")
+		}
+	}
+
+	return strings.Join(b, ",")
+}
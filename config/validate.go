@@ -0,0 +1,531 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ApiVersion = "v1"
+	Kind       = "events"
+
+	defaultSshPort = 29418
+	maxPort        = 65535
+)
+
+var (
+	// Values accepted by the packages consuming the config, which import this one
+	actions      = []string{"", "hide", "redact", "show"}
+	levels       = []string{"", "DEBUG", "INFO", "WARN", "ERROR"}
+	layouts      = []string{"", "single", "project"}
+	patternTypes = []string{"", "ant", "plain", "regexp"}
+	scopes       = []string{"admin", "read"}
+	tlsVersions  = []string{"", "1.2", "1.3"}
+)
+
+// FieldError - Invalid field of the config, at the line of its key if loaded from YAML
+type FieldError struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Line == 0 {
+		return e.Path + ": " + e.Message
+	}
+
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Path, e.Message)
+}
+
+// Errors - Invalid fields of the config, all reported at once
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	b := make([]string, 0, len(e))
+
+	for _, item := range e {
+		b = append(b, item.Error())
+	}
+
+	return strings.Join(b, "\n")
+}
+
+func (e *Errors) add(path, format string, args ...any) {
+	*e = append(*e, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Load unmarshals the YAML config, rejecting unknown keys, with ${NAME} replaced by the environment variables
+// and the fields overridden by the EVENTS_ ones, and then reads the secret files, sets the defaults and validates it
+func Load(data []byte) (*Config, error) {
+	c := New()
+
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return c, errors.Wrap(err, "failed to unmarshal")
+	}
+
+	var errs Errors
+
+	checkKeys(&node, reflect.TypeOf(c), "", &errs)
+	interpolate(&node, "", &errs)
+
+	if node.Kind != 0 {
+		if err := node.Decode(c); err != nil {
+			if len(errs) != 0 {
+				return c, errs
+			}
+			return c, errors.Wrap(err, "failed to unmarshal")
+		}
+	}
+
+	set := c.applyEnv(environ(), &errs)
+
+	c.SetDefaults()
+
+	var v Errors
+
+	for _, err := range []error{c.ResolveSecrets(), c.Validate()} {
+		var e Errors
+		if errors.As(err, &e) {
+			v = append(v, e...)
+		}
+	}
+
+	lines := map[string]int{}
+	nodeLines(&node, "", lines)
+
+	for _, item := range v {
+		if name, ok := set[item.Path]; ok {
+			item.Message += " (set by " + name + ")"
+		} else {
+			item.Line = lineOf(lines, item.Path)
+		}
+	}
+
+	errs = append(errs, v...)
+
+	if len(errs) != 0 {
+		return c, errs
+	}
+
+	return c, nil
+}
+
+// SetDefaults sets the fields left unset which have a default
+func (c *Config) SetDefaults() {
+	if c.Spec.Connect.Ssh.Port == 0 {
+		c.Spec.Connect.Ssh.Port = defaultSshPort
+	}
+}
+
+// Validate returns Errors listing the invalid fields, if any
+func (c *Config) Validate() error {
+	var errs Errors
+
+	if c.ApiVersion != ApiVersion {
+		errs.add("apiVersion", "must be %q, got %q", ApiVersion, c.ApiVersion)
+	}
+
+	if c.Kind != Kind {
+		errs.add("kind", "must be %q, got %q", Kind, c.Kind)
+	}
+
+	c.Spec.Connect.validate("spec.connect", &errs)
+	c.Spec.Exec.validate("spec.exec", &errs)
+	c.Spec.File.validate("spec.file", &errs)
+	c.Spec.Leader.validate("spec.leader", &errs)
+	validateOneOf("spec.log.level", c.Spec.Log.Level, levels, &errs)
+	c.Spec.Nats.validate("spec.nats", &errs)
+	c.Spec.Redis.validate("spec.redis", &errs)
+	c.Spec.Exec.Delivery.validate("spec.exec.delivery", &c.Spec.Trigger, &errs)
+	c.Spec.File.Delivery.validate("spec.file.delivery", &c.Spec.Trigger, &errs)
+	c.Spec.Nats.Delivery.validate("spec.nats.delivery", &c.Spec.Trigger, &errs)
+	c.Spec.Redis.Delivery.validate("spec.redis.delivery", &c.Spec.Trigger, &errs)
+	c.Spec.Server.validate("spec.server", &errs)
+	c.Spec.Storage.validate("spec.storage", &errs)
+	c.Spec.Trigger.validate("spec.trigger", &errs)
+	c.Spec.Watchdog.validate("spec.watchdog", &errs)
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (c *Connect) validate(path string, errs *Errors) {
+	if c.Hostname != "" {
+		if c.Ssh.Keyfile == "" {
+			errs.add(path+".ssh.keyfile", "is required with spec.connect.hostname")
+		}
+		if c.Ssh.Username == "" {
+			errs.add(path+".ssh.username", "is required with spec.connect.hostname")
+		}
+	}
+
+	if c.Ssh.Port < 1 || c.Ssh.Port > maxPort {
+		errs.add(path+".ssh.port", "must be between 1 and %d, got %d", maxPort, c.Ssh.Port)
+	}
+
+	if c.Rest.Url != "" {
+		if u, err := url.Parse(c.Rest.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add(path+".rest.url", "must be an http(s) URL, got %q", c.Rest.Url)
+		}
+	}
+}
+
+func (e *Exec) validate(path string, errs *Errors) {
+	if len(e.Command) != 0 && e.Command[0] == "" {
+		errs.add(path+".command[0]", "is required")
+	}
+
+	if e.TimeoutSeconds < 0 {
+		errs.add(path+".timeoutSeconds", "must not be negative, got %d", e.TimeoutSeconds)
+	}
+}
+
+func (f *File) validate(path string, errs *Errors) {
+	for _, item := range []struct {
+		name  string
+		value int
+	}{
+		{"retention", f.Retention},
+		{"rotateBytes", f.RotateBytes},
+		{"rotateSeconds", f.RotateSeconds},
+	} {
+		if item.value < 0 {
+			errs.add(path+"."+item.name, "must not be negative, got %d", item.value)
+		}
+	}
+}
+
+func (l *Leader) validate(path string, errs *Errors) {
+	if l.LeaseSeconds < 0 {
+		errs.add(path+".leaseSeconds", "must not be negative, got %d", l.LeaseSeconds)
+	} else if l.LeaseSeconds == 0 && l.LockFile != "" {
+		errs.add(path+".leaseSeconds", "is required with lockFile")
+	}
+}
+
+func (d *Delivery) validate(path string, t *Trigger, errs *Errors) {
+	for _, item := range []struct {
+		name  string
+		value int
+	}{
+		{"backoffSeconds", d.BackoffSeconds},
+		{"buffer", d.Buffer},
+		{"concurrency", d.Concurrency},
+		{"retries", d.Retries},
+	} {
+		if item.value < 0 {
+			errs.add(path+"."+item.name, "must not be negative, got %d", item.value)
+		}
+	}
+
+	if d.Rule == "" {
+		return
+	}
+
+	for i := range t.Rules {
+		if t.Rules[i].Name == d.Rule {
+			return
+		}
+	}
+
+	errs.add(path+".rule", "must be a rule of spec.trigger.rules, got %q", d.Rule)
+}
+
+func (n *Nats) validate(path string, errs *Errors) {
+	if n.Url == "" {
+		return
+	}
+
+	for _, item := range strings.Split(n.Url, ",") {
+		if u, err := url.Parse(strings.TrimSpace(item)); err != nil || u.Host == "" {
+			errs.add(path+".url", "must be a NATS URL, got %q", n.Url)
+			break
+		}
+	}
+
+	if strings.ContainsAny(n.Subject, "*> \t") || strings.HasPrefix(n.Subject, ".") || strings.HasSuffix(n.Subject, ".") {
+		errs.add(path+".subject", "must be a subject without wildcards, got %q", n.Subject)
+	}
+
+	if strings.ContainsAny(n.JetStream.Stream, ".*> \t") {
+		errs.add(path+".jetStream.stream", "must be a stream name, got %q", n.JetStream.Stream)
+	}
+
+	if n.JetStream.DuplicateWindowSeconds < 0 {
+		errs.add(path+".jetStream.duplicateWindowSeconds", "must not be negative, got %d", n.JetStream.DuplicateWindowSeconds)
+	}
+}
+
+func (r *Redis) validate(path string, errs *Errors) {
+	if r.Url == "" {
+		return
+	}
+
+	if u, err := url.Parse(r.Url); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		errs.add(path+".url", "must be a redis:// or rediss:// URL, got %q", r.Url)
+	}
+
+	validateOneOf(path+".layout", r.Layout, layouts, errs)
+
+	if r.MaxLen < 0 {
+		errs.add(path+".maxLen", "must not be negative, got %d", r.MaxLen)
+	}
+}
+
+func (s *Server) validate(path string, errs *Errors) {
+	for i, item := range s.Auth.Tokens {
+		p := fmt.Sprintf("%s.auth.tokens[%d]", path, i)
+		if item.Name == "" {
+			errs.add(p+".name", "is required")
+		}
+		if item.Token == "" && item.TokenFile == "" {
+			errs.add(p+".token", "is required")
+		}
+		validateScopes(p+".scopes", item.Scopes, errs)
+	}
+
+	validateScopes(path+".auth.htpasswd.scopes", s.Auth.Htpasswd.Scopes, errs)
+
+	for i, item := range s.Cors.AllowOrigins {
+		if item == "" {
+			errs.add(fmt.Sprintf("%s.cors.allowOrigins[%d]", path, i), "must not be empty")
+		}
+	}
+
+	if (s.Tls.CertFile == "") != (s.Tls.KeyFile == "") {
+		errs.add(path+".tls", "certFile and keyFile are required together")
+	}
+
+	if s.Tls.RequireClientCert && s.Tls.ClientCAFile == "" {
+		errs.add(path+".tls.clientCAFile", "is required with requireClientCert")
+	}
+
+	validateOneOf(path+".tls.minVersion", s.Tls.MinVersion, tlsVersions, errs)
+
+	for i, item := range s.Tls.Clients {
+		p := fmt.Sprintf("%s.tls.clients[%d]", path, i)
+		if item.CommonName == "" {
+			errs.add(p+".commonName", "is required")
+		}
+		validateScopes(p+".scopes", item.Scopes, errs)
+	}
+
+	validateOneOf(path+".visibility.private", s.Visibility.Private, actions, errs)
+	validateOneOf(path+".visibility.wip", s.Visibility.Wip, actions, errs)
+
+	for i, item := range s.Visibility.Groups {
+		p := fmt.Sprintf("%s.visibility.groups[%d]", path, i)
+		if item.Name == "" {
+			errs.add(p+".name", "is required")
+		}
+		validateOneOf(p+".private", item.Private, actions, errs)
+		validateOneOf(p+".wip", item.Wip, actions, errs)
+	}
+}
+
+func (s *Storage) validate(path string, errs *Errors) {
+	if s.Autoclean != "" {
+		if _, err := cron.ParseStandard(s.Autoclean); err != nil {
+			errs.add(path+".autoclean", "invalid cron spec %q: %s", s.Autoclean, err.Error())
+		}
+	}
+}
+
+func (t *Trigger) validate(path string, errs *Errors) {
+	names := map[string]bool{}
+
+	for i := range t.Rules {
+		r := &t.Rules[i]
+		p := fmt.Sprintf("%s.rules[%d]", path, i)
+		if r.Name == "" {
+			errs.add(p+".name", "is required")
+		} else if names[r.Name] {
+			errs.add(p+".name", "duplicate rule %q", r.Name)
+		}
+		names[r.Name] = true
+		for j := range r.Projects {
+			q := fmt.Sprintf("%s.projects[%d]", p, j)
+			validatePattern(q, r.Projects[j].Pattern, errs)
+			validatePatterns(q+".branches", r.Projects[j].Branches, errs)
+			validatePatterns(q+".files", r.Projects[j].Files, errs)
+			validatePatterns(q+".topics", r.Projects[j].Topics, errs)
+		}
+		if r.CommitMessage != "" {
+			if _, err := regexp.Compile(r.CommitMessage); err != nil {
+				errs.add(p+".commitMessage", "invalid regexp: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (w *Watchdog) validate(path string, errs *Errors) {
+	if w.PeriodSeconds < 0 {
+		errs.add(path+".periodSeconds", "must not be negative, got %d", w.PeriodSeconds)
+	}
+
+	if w.TimeoutSeconds < 0 {
+		errs.add(path+".timeoutSeconds", "must not be negative, got %d", w.TimeoutSeconds)
+	}
+}
+
+func validatePatterns(path string, patterns []Pattern, errs *Errors) {
+	for i := range patterns {
+		validatePattern(fmt.Sprintf("%s[%d]", path, i), patterns[i], errs)
+	}
+}
+
+func validatePattern(path string, pattern Pattern, errs *Errors) {
+	validateOneOf(path+".type", pattern.Type, patternTypes, errs)
+
+	if pattern.Pattern == "" {
+		errs.add(path+".pattern", "is required")
+	} else if pattern.Type == "regexp" {
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			errs.add(path+".pattern", "invalid regexp: %s", err.Error())
+		}
+	}
+}
+
+func validateScopes(path string, items []string, errs *Errors) {
+	for i, item := range items {
+		validateOneOf(fmt.Sprintf("%s[%d]", path, i), item, scopes, errs)
+	}
+}
+
+func validateOneOf(path, value string, values []string, errs *Errors) {
+	for _, item := range values {
+		if value == item {
+			return
+		}
+	}
+
+	b := make([]string, 0, len(values))
+
+	for _, item := range values {
+		if item != "" {
+			b = append(b, item)
+		}
+	}
+
+	errs.add(path, "must be one of %s, got %q", strings.Join(b, "|"), value)
+}
+
+// checkKeys reports the keys of the YAML node which are not fields of the type
+func checkKeys(node *yaml.Node, t reflect.Type, path string, errs *Errors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			checkKeys(item, t, path, errs)
+		}
+	case yaml.MappingNode:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			p := joinPath(path, key.Value)
+			f, ok := fields[key.Value]
+			if !ok {
+				*errs = append(*errs, &FieldError{Line: key.Line, Path: p, Message: "unknown field"})
+				continue
+			}
+			checkKeys(node.Content[i+1], f, p, errs)
+		}
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return
+		}
+		for i, item := range node.Content {
+			checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of the struct to the types of its fields, inline ones included
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if strings.Contains(opts, "inline") {
+			for key, val := range yamlFields(f.Type) {
+				fields[key] = val
+			}
+			continue
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+
+	return fields
+}
+
+// nodeLines maps the paths of the YAML node to the lines of their keys
+func nodeLines(node *yaml.Node, path string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			nodeLines(item, path, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			p := joinPath(path, node.Content[i].Value)
+			lines[p] = node.Content[i].Line
+			nodeLines(node.Content[i+1], p, lines)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			lines[p] = item.Line
+			nodeLines(item, p, lines)
+		}
+	}
+}
+
+// lineOf returns the line of the path, or of its closest parent for fields left unset
+func lineOf(lines map[string]int, path string) int {
+	for path != "" {
+		if line, ok := lines[path]; ok {
+			return line
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			return 0
+		}
+		path = path[:i]
+	}
+
+	return 0
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	invalid = `apiVersion: v2
+kind: events
+spec:
+  connect:
+    hostname: localhost
+    ssh:
+      keyfile: /path/to/.ssh/id_rsa
+      prot: 29418
+      port: 70000
+  server:
+    tls:
+      certFile: /path/to/server.crt
+      minVersion: "1.1"
+    visibility:
+      private: secret
+  storage:
+    autoclean: "every day"
+  trigger:
+    rules:
+      - name: build
+        projects:
+          - type: glob
+            pattern: platform/**
+      - name: build
+        commitMessage: "("
+  watchdog:
+    periodSeconds: -1
+`
+)
+
+func TestLoad(t *testing.T) {
+	buf, err := os.ReadFile("config.yml")
+	assert.Equal(t, nil, err)
+
+	c, err := Load(buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "localhost", c.Spec.Connect.Hostname)
+
+	c, err = Load([]byte("apiVersion: v1\nkind: events\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, defaultSshPort, c.Spec.Connect.Ssh.Port)
+
+	_, err = Load([]byte("apiVersion: [v1"))
+	assert.NotEqual(t, nil, err)
+
+	_, err = Load([]byte("apiVersion: v1\nkind: events\nspec:\n  watchdog:\n    periodSeconds: twenty\n"))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := Load([]byte(invalid))
+
+	var errs Errors
+
+	assert.Equal(t, true, errors.As(err, &errs))
+
+	b := make([]string, 0, len(errs))
+	for _, item := range errs {
+		b = append(b, item.Error())
+	}
+
+	assert.Equal(t, []string{
+		"line 8: spec.connect.ssh.prot: unknown field",
+		`line 1: apiVersion: must be "v1", got "v2"`,
+		"line 6: spec.connect.ssh.username: is required with spec.connect.hostname",
+		"line 9: spec.connect.ssh.port: must be between 1 and 65535, got 70000",
+		"line 11: spec.server.tls: certFile and keyFile are required together",
+		`line 13: spec.server.tls.minVersion: must be one of 1.2|1.3, got "1.1"`,
+		`line 15: spec.server.visibility.private: must be one of hide|redact|show, got "secret"`,
+		`line 17: spec.storage.autoclean: invalid cron spec "every day": expected exactly 5 fields, found 2: [every day]`,
+		`line 22: spec.trigger.rules[0].projects[0].type: must be one of ant|plain|regexp, got "glob"`,
+		`line 24: spec.trigger.rules[1].name: duplicate rule "build"`,
+		"line 25: spec.trigger.rules[1].commitMessage: invalid regexp: error parsing regexp: missing closing ): `(`",
+		"line 27: spec.watchdog.periodSeconds: must not be negative, got -1",
+	}, b)
+}
+
+func TestValidate(t *testing.T) {
+	c := New()
+
+	err := c.Validate()
+	assert.Equal(t, `apiVersion: must be "v1", got ""`, err.(Errors)[0].Error())
+
+	c.ApiVersion = ApiVersion
+	c.Kind = Kind
+	c.SetDefaults()
+
+	assert.Equal(t, nil, c.Validate())
+
+	c.Spec.Server.Auth.Tokens = []Token{{Name: "ci", Scopes: []string{"write"}}}
+
+	err = c.Validate()
+	assert.Equal(t, "spec.server.auth.tokens[0].token: is required\n"+
+		`spec.server.auth.tokens[0].scopes[0]: must be one of admin|read, got "write"`, err.Error())
+
+	c.Spec.Server.Auth.Tokens = nil
+	c.Spec.Leader.LeaseSeconds = -1
+	c.Spec.Log.Level = "TRACE"
+
+	err = c.Validate()
+	assert.Equal(t, "spec.leader.leaseSeconds: must not be negative, got -1\n"+
+		`spec.log.level: must be one of DEBUG|INFO|WARN|ERROR, got "TRACE"`, err.Error())
+
+	c.Spec.Leader.LeaseSeconds = 0
+	c.Spec.Leader.LockFile = "/path/to/events.lock"
+	c.Spec.Log.Level = ""
+
+	err = c.Validate()
+	assert.Equal(t, "spec.leader.leaseSeconds: is required with lockFile", err.Error())
+
+	c.Spec.Leader.LockFile = ""
+	c.Spec.Nats = Nats{
+		JetStream: JetStream{DuplicateWindowSeconds: -1, Stream: "gerrit.events"},
+		Subject:   "gerrit.>",
+		Url:       "nats://127.0.0.1:4222,localhost",
+	}
+
+	err = c.Validate()
+	assert.Equal(t, `spec.nats.url: must be a NATS URL, got "nats://127.0.0.1:4222,localhost"`+"\n"+
+		`spec.nats.subject: must be a subject without wildcards, got "gerrit.>"`+"\n"+
+		`spec.nats.jetStream.stream: must be a stream name, got "gerrit.events"`+"\n"+
+		"spec.nats.jetStream.duplicateWindowSeconds: must not be negative, got -1", err.Error())
+
+	c.Spec.Nats = Nats{JetStream: JetStream{Stream: "GERRIT"}, Subject: "ci.gerrit", Url: "nats://127.0.0.1:4222, tls://nats.example.com"}
+
+	assert.Equal(t, nil, c.Validate())
+
+	c.Spec.Redis = Redis{Layout: "change", MaxLen: -1, Url: "http://localhost:6379"}
+
+	err = c.Validate()
+	assert.Equal(t, `spec.redis.url: must be a redis:// or rediss:// URL, got "http://localhost:6379"`+"\n"+
+		`spec.redis.layout: must be one of single|project, got "change"`+"\n"+
+		"spec.redis.maxLen: must not be negative, got -1", err.Error())
+
+	c.Spec.Redis = Redis{Layout: "project", MaxLen: 10000, Url: "rediss://localhost:6379/1"}
+
+	assert.Equal(t, nil, c.Validate())
+
+	c.Spec.Nats.Delivery = Delivery{BackoffSeconds: -1, Buffer: -1, Concurrency: -1, Retries: -1}
+	c.Spec.Redis.Delivery = Delivery{Rule: "build"}
+
+	err = c.Validate()
+	assert.Equal(t, "spec.nats.delivery.backoffSeconds: must not be negative, got -1\n"+
+		"spec.nats.delivery.buffer: must not be negative, got -1\n"+
+		"spec.nats.delivery.concurrency: must not be negative, got -1\n"+
+		"spec.nats.delivery.retries: must not be negative, got -1\n"+
+		`spec.redis.delivery.rule: must be a rule of spec.trigger.rules, got "build"`, err.Error())
+
+	c.Spec.Nats.Delivery = Delivery{}
+	c.Spec.Trigger.Rules = []Rule{{Name: "build"}}
+
+	assert.Equal(t, nil, c.Validate())
+
+	c.Spec.File = File{Directory: "/path/to/events", Retention: -1, RotateBytes: -1, RotateSeconds: -1}
+
+	err = c.Validate()
+	assert.Equal(t, "spec.file.retention: must not be negative, got -1\n"+
+		"spec.file.rotateBytes: must not be negative, got -1\n"+
+		"spec.file.rotateSeconds: must not be negative, got -1", err.Error())
+
+	c.Spec.File = File{}
+	c.Spec.Exec = Exec{Command: []string{"", "--event"}, TimeoutSeconds: -1}
+
+	err = c.Validate()
+	assert.Equal(t, "spec.exec.command[0]: is required\n"+
+		"spec.exec.timeoutSeconds: must not be negative, got -1", err.Error())
+
+	c.Spec.Exec = Exec{Command: []string{"/path/to/hook.sh"}, Delivery: Delivery{Concurrency: 4}, TimeoutSeconds: 30}
+
+	assert.Equal(t, nil, c.Validate())
+}
@@ -0,0 +1,470 @@
+// Package gerrittest provides an in-process fake Gerrit SSH server for tests,
+// with the REST API of the events-log plugin.
+package gerrittest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	cryptoSsh "golang.org/x/crypto/ssh"
+)
+
+const (
+	Username = "user"
+	Version  = "gerrit version 3.9.1"
+
+	cmdPrefix       = "gerrit "
+	cmdStreamEvents = "stream-events"
+	cmdVersion      = "version"
+
+	eventsLogLayout = "2006.01.02 15:04:05"
+	eventsLogPath   = "/a/plugins/events-log/events/"
+
+	exitFailure = 1
+	exitSuccess = 0
+)
+
+// Config to configure the fake server
+type Config struct {
+	// Events are streamed to every new stream-events session before any pushed event.
+	Events []string
+
+	// Delay is applied before answering any command.
+	Delay time.Duration
+}
+
+// Server is a fake Gerrit SSH server listening on the loopback interface.
+type Server struct {
+	cfg      Config
+	listener net.Listener
+	hostKey  cryptoSsh.Signer
+	conns    map[*cryptoSsh.ServerConn]bool
+	streams  map[cryptoSsh.Channel]*stream
+	mutex    sync.Mutex
+	wg       sync.WaitGroup
+	rest     *httptest.Server
+	logged   []string
+	failures int
+}
+
+type stream struct {
+	buf  chan string
+	done chan struct{}
+}
+
+// New starts a fake server with a random host key on a random port.
+func New(cfg Config) (*Server, error) {
+	s := &Server{
+		cfg:     cfg,
+		conns:   map[*cryptoSsh.ServerConn]bool{},
+		streams: map[cryptoSsh.Channel]*stream{},
+	}
+
+	var err error
+
+	s.hostKey, err = newSigner()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create host key")
+	}
+
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen")
+	}
+
+	s.wg.Add(1)
+
+	go s.serve()
+
+	s.rest = httptest.NewServer(http.HandlerFunc(s.handleEventsLog))
+
+	return s, nil
+}
+
+// Close stops the server and drops every connection.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.Disconnect()
+	s.wg.Wait()
+	s.rest.Close()
+
+	return err
+}
+
+// Hostname returns the host part of the listen address.
+func (s *Server) Hostname() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port part of the listen address.
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// HostKey returns the public host key currently presented to clients.
+func (s *Server) HostKey() cryptoSsh.PublicKey {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.hostKey.PublicKey()
+}
+
+// KnownHosts writes a known_hosts file trusting the current host key and returns its path.
+func (s *Server) KnownHosts(dir string) (string, error) {
+	name := filepath.Join(dir, "known_hosts")
+	line := fmt.Sprintf("[%s]:%d %s", s.Hostname(), s.Port(), cryptoSsh.MarshalAuthorizedKey(s.HostKey()))
+
+	if err := os.WriteFile(name, []byte(line), 0o600); err != nil {
+		return "", errors.Wrap(err, "failed to write file")
+	}
+
+	return name, nil
+}
+
+// RotateHostKey replaces the host key so that clients trusting the previous key are rejected.
+func (s *Server) RotateHostKey() error {
+	signer, err := newSigner()
+	if err != nil {
+		return errors.Wrap(err, "failed to create host key")
+	}
+
+	s.mutex.Lock()
+	s.hostKey = signer
+	s.mutex.Unlock()
+
+	return nil
+}
+
+// SetDelay changes the delay applied before answering any command.
+func (s *Server) SetDelay(d time.Duration) {
+	s.mutex.Lock()
+	s.cfg.Delay = d
+	s.mutex.Unlock()
+}
+
+// RestURL returns the URL of the REST API serving the events-log plugin.
+func (s *Server) RestURL() string {
+	return s.rest.URL
+}
+
+// Log records one event line in the events-log plugin only, as if dropped from the stream-events sessions.
+func (s *Server) Log(line string) {
+	s.mutex.Lock()
+	s.logged = append(s.logged, line)
+	s.mutex.Unlock()
+}
+
+// FailEventsLog answers the next n requests to the events-log plugin with an error.
+func (s *Server) FailEventsLog(n int) {
+	s.mutex.Lock()
+	s.failures = n
+	s.mutex.Unlock()
+}
+
+// Push sends one event line to every active stream-events session, and records it in the events-log plugin.
+func (s *Server) Push(line string) {
+	s.mutex.Lock()
+	s.logged = append(s.logged, line)
+	b := make([]*stream, 0, len(s.streams))
+	for _, item := range s.streams {
+		b = append(b, item)
+	}
+	s.mutex.Unlock()
+
+	for _, item := range b {
+		select {
+		case item.buf <- line:
+		case <-item.done:
+		}
+	}
+}
+
+// Streams returns the number of active stream-events sessions.
+func (s *Server) Streams() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.streams)
+}
+
+// Disconnect drops every client connection while the server keeps listening.
+func (s *Server) Disconnect() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for c := range s.conns {
+		_ = c.Close()
+	}
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+
+		go func(c net.Conn) {
+			defer s.wg.Done()
+			s.handleConn(c)
+		}(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	cfg := &cryptoSsh.ServerConfig{
+		PublicKeyCallback: func(meta cryptoSsh.ConnMetadata, _ cryptoSsh.PublicKey) (*cryptoSsh.Permissions, error) {
+			if meta.User() != Username {
+				return nil, errors.New("invalid user")
+			}
+			return &cryptoSsh.Permissions{}, nil
+		},
+	}
+
+	s.mutex.Lock()
+	cfg.AddHostKey(s.hostKey)
+	s.mutex.Unlock()
+
+	sc, chans, reqs, err := cryptoSsh.NewServerConn(conn, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	s.mutex.Lock()
+	s.conns[sc] = true
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, sc)
+		s.mutex.Unlock()
+	}()
+
+	go cryptoSsh.DiscardRequests(reqs)
+
+	var wg sync.WaitGroup
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			_ = nc.Reject(cryptoSsh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		ch, r, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(ch cryptoSsh.Channel, r <-chan *cryptoSsh.Request) {
+			defer wg.Done()
+			s.handleSession(sc, ch, r)
+		}(ch, r)
+	}
+
+	wg.Wait()
+}
+
+func (s *Server) handleSession(sc *cryptoSsh.ServerConn, ch cryptoSsh.Channel, reqs <-chan *cryptoSsh.Request) {
+	defer func() {
+		_ = ch.Close()
+	}()
+
+	for req := range reqs {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+
+		_ = req.Reply(true, nil)
+
+		go cryptoSsh.DiscardRequests(reqs)
+
+		s.mutex.Lock()
+		delay := s.cfg.Delay
+		s.mutex.Unlock()
+
+		time.Sleep(delay)
+
+		cmd := strings.TrimPrefix(parseString(req.Payload), cmdPrefix)
+
+		switch cmd {
+		case cmdVersion:
+			_, _ = io.WriteString(ch, Version+"\n")
+			exit(ch, exitSuccess)
+		case cmdStreamEvents:
+			s.stream(sc, ch)
+		default:
+			_, _ = io.WriteString(ch.Stderr(), fmt.Sprintf("fatal: %s: not found\n", cmd))
+			exit(ch, exitFailure)
+		}
+
+		return
+	}
+}
+
+func (s *Server) stream(sc *cryptoSsh.ServerConn, ch cryptoSsh.Channel) {
+	st := &stream{
+		buf:  make(chan string),
+		done: make(chan struct{}),
+	}
+
+	s.mutex.Lock()
+	s.streams[ch] = st
+	events := s.cfg.Events
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.streams, ch)
+		s.mutex.Unlock()
+		close(st.done)
+	}()
+
+	closed := make(chan struct{})
+
+	go func() {
+		_ = sc.Wait()
+		close(closed)
+	}()
+
+	for _, item := range events {
+		if _, err := io.WriteString(ch, item+"\n"); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case line := <-st.buf:
+			if _, err := io.WriteString(ch, line+"\n"); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// handleEventsLog returns the events recorded between t1 and t2, both included, one per line.
+func (s *Server) handleEventsLog(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != eventsLogPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	t1, err1 := time.ParseInLocation(eventsLogLayout, r.URL.Query().Get("t1"), time.Local)
+	t2, err2 := time.ParseInLocation(eventsLogLayout, r.URL.Query().Get("t2"), time.Local)
+
+	if err1 != nil || err2 != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	failed := s.failures > 0
+	if failed {
+		s.failures--
+	}
+	logged := append([]string{}, s.logged...)
+	s.mutex.Unlock()
+
+	if failed {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	for _, item := range logged {
+		var e struct {
+			EventCreatedOn int64 `json:"eventCreatedOn"`
+		}
+		if err := json.Unmarshal([]byte(item), &e); err != nil {
+			continue
+		}
+		if e.EventCreatedOn < t1.Unix() || e.EventCreatedOn > t2.Unix() {
+			continue
+		}
+		_, _ = io.WriteString(w, item+"\n")
+	}
+}
+
+func exit(ch cryptoSsh.Channel, status uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, status)
+	_, _ = ch.SendRequest("exit-status", false, b)
+}
+
+func parseString(payload []byte) string {
+	if len(payload) < 4 {
+		return ""
+	}
+
+	n := binary.BigEndian.Uint32(payload)
+	if int(n) > len(payload)-4 {
+		return ""
+	}
+
+	return string(payload[4 : 4+n])
+}
+
+func newSigner() (cryptoSsh.Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return cryptoSsh.NewSignerFromKey(key)
+}
+
+// Keyfile writes a fresh client private key in OpenSSH format and returns its path.
+func Keyfile(dir string) (string, error) {
+	return KeyfileWithPassphrase(dir, "")
+}
+
+// KeyfileWithPassphrase writes a fresh client private key encrypted with the passphrase, if not empty.
+func KeyfileWithPassphrase(dir, passphrase string) (string, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate key")
+	}
+
+	var b *pem.Block
+
+	if passphrase != "" {
+		b, err = cryptoSsh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+	} else {
+		b, err = cryptoSsh.MarshalPrivateKey(key, "")
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal key")
+	}
+
+	name := filepath.Join(dir, "id_ed25519")
+
+	if err := os.WriteFile(name, pem.EncodeToMemory(b), 0o600); err != nil {
+		return "", errors.Wrap(err, "failed to write file")
+	}
+
+	return name, nil
+}
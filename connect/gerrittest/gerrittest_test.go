@@ -0,0 +1,105 @@
+package gerrittest
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cryptoSsh "golang.org/x/crypto/ssh"
+)
+
+func initClient(t *testing.T, s *Server) *cryptoSsh.Client {
+	name, err := Keyfile(t.TempDir())
+	assert.Equal(t, nil, err)
+
+	key, _ := os.ReadFile(name)
+	signer, err := cryptoSsh.ParsePrivateKey(key)
+	assert.Equal(t, nil, err)
+
+	c, err := cryptoSsh.Dial("tcp", s.listener.Addr().String(), &cryptoSsh.ClientConfig{
+		User:            Username,
+		Auth:            []cryptoSsh.AuthMethod{cryptoSsh.PublicKeys(signer)},
+		HostKeyCallback: cryptoSsh.FixedHostKey(s.HostKey()),
+	})
+	assert.Equal(t, nil, err)
+
+	return c
+}
+
+func TestServer(t *testing.T) {
+	s, err := New(Config{Delay: 10 * time.Millisecond})
+	assert.Equal(t, nil, err)
+
+	defer func() {
+		_ = s.Close()
+	}()
+
+	c := initClient(t, s)
+
+	defer func() {
+		_ = c.Close()
+	}()
+
+	session, _ := c.NewSession()
+	out, err := session.Output("gerrit version")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Version+"\n", string(out))
+	_ = session.Close()
+
+	session, _ = c.NewSession()
+	_, err = session.Output("gerrit invalid")
+	assert.NotEqual(t, nil, err)
+	_ = session.Close()
+
+	old := s.HostKey()
+	assert.Equal(t, nil, s.RotateHostKey())
+	assert.NotEqual(t, old.Marshal(), s.HostKey().Marshal())
+}
+
+func TestParseString(t *testing.T) {
+	assert.Equal(t, "", parseString(nil))
+	assert.Equal(t, "", parseString([]byte{0, 0, 0, 9, 'a'}))
+	assert.Equal(t, "a", parseString([]byte{0, 0, 0, 1, 'a'}))
+}
+
+func TestEventsLog(t *testing.T) {
+	s, err := New(Config{})
+	assert.Equal(t, nil, err)
+
+	defer func() {
+		_ = s.Close()
+	}()
+
+	s.Log(`{"type":"ref-updated","eventCreatedOn":1672567200}`)
+	s.Push(`{"type":"ref-updated","eventCreatedOn":1672567260}`)
+
+	get := func(since, until int64) (int, string) {
+		q := url.Values{}
+		q.Set("t1", time.Unix(since, 0).Format(eventsLogLayout))
+		q.Set("t2", time.Unix(until, 0).Format(eventsLogLayout))
+		rsp, err := http.Get(s.RestURL() + eventsLogPath + "?" + q.Encode())
+		assert.Equal(t, nil, err)
+		defer func() {
+			_ = rsp.Body.Close()
+		}()
+		b, _ := io.ReadAll(rsp.Body)
+		return rsp.StatusCode, string(b)
+	}
+
+	code, body := get(1672567200, 1672567259)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"type":"ref-updated","eventCreatedOn":1672567200}`+"\n", body)
+
+	s.FailEventsLog(1)
+
+	code, _ = get(1672567200, 1672567260)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	_, body = get(1672567200, 1672567260)
+	assert.Equal(t, 2, strings.Count(body, "\n"))
+}
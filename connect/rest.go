@@ -0,0 +1,174 @@
+package connect
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+)
+
+const (
+	restLayout  = "2006.01.02 15:04:05"
+	restLine    = 1 << 22
+	restPath    = "/a/plugins/events-log/events/"
+	restTimeout = 30 * time.Second
+)
+
+// Rest to fetch events from the events-log plugin
+// https://gerrit.googlesource.com/plugins/events-log/+/refs/heads/master/src/main/resources/Documentation/rest-api-events.md
+type Rest interface {
+	Init(context.Context) error
+	Deinit(context.Context) error
+	Events(context.Context, int64, int64) ([]string, error)
+	Reload(context.Context, *config.Config) error
+}
+
+type RestConfig struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+type rest struct {
+	cfg    *RestConfig
+	client *http.Client
+	mutex  sync.RWMutex
+}
+
+func RestNew(_ context.Context, cfg *RestConfig) Rest {
+	return &rest{
+		cfg:    cfg,
+		client: nil,
+	}
+}
+
+func DefaultRestConfig() *RestConfig {
+	return &RestConfig{}
+}
+
+func (r *rest) Init(_ context.Context) error {
+	r.cfg.Logger.Debug("rest: Init")
+
+	if r.cfg.Config.Spec.Connect.Rest.Url == "" {
+		return nil
+	}
+
+	if _, err := url.Parse(r.cfg.Config.Spec.Connect.Rest.Url); err != nil {
+		return errors.Wrap(err, "invalid url")
+	}
+
+	r.client = &http.Client{
+		Timeout: restTimeout,
+	}
+
+	return nil
+}
+
+func (r *rest) Deinit(_ context.Context) error {
+	r.cfg.Logger.Debug("rest: Deinit")
+
+	if r.client != nil {
+		r.client.CloseIdleConnections()
+		r.client = nil
+	}
+
+	return nil
+}
+
+// Reload applies the URL and credentials of the config to the next requests
+func (r *rest) Reload(_ context.Context, cfg *config.Config) error {
+	r.cfg.Logger.Debug("rest: Reload")
+
+	if cfg.Spec.Connect.Rest.Url != "" {
+		if _, err := url.Parse(cfg.Spec.Connect.Rest.Url); err != nil {
+			return errors.Wrap(err, "invalid url")
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.cfg.Config.Spec.Connect.Rest = cfg.Spec.Connect.Rest
+
+	if r.cfg.Config.Spec.Connect.Rest.Url == "" {
+		if r.client != nil {
+			r.client.CloseIdleConnections()
+		}
+		r.client = nil
+	} else if r.client == nil {
+		r.client = &http.Client{
+			Timeout: restTimeout,
+		}
+	}
+
+	return nil
+}
+
+func (r *rest) Events(ctx context.Context, since, until int64) ([]string, error) {
+	r.cfg.Logger.Debug("rest: Events")
+
+	r.mutex.RLock()
+	client := r.client
+	c := r.cfg.Config.Spec.Connect.Rest
+	r.mutex.RUnlock()
+
+	if client == nil {
+		return nil, errors.New("invalid client")
+	}
+
+	if since < 0 || until < since {
+		return nil, errors.New("invalid date")
+	}
+
+	q := url.Values{}
+	q.Set("t1", time.Unix(since, 0).Format(restLayout))
+	q.Set("t2", time.Unix(until, 0).Format(restLayout))
+
+	u := strings.TrimSuffix(c.Url, "/") + restPath + "?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, string(c.Password))
+	}
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send request")
+	}
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, errors.New("invalid status " + rsp.Status)
+	}
+
+	var b []string
+
+	scan := bufio.NewScanner(rsp.Body)
+	scan.Buffer(make([]byte, bufio.MaxScanTokenSize), restLine)
+
+	for scan.Scan() {
+		if line := strings.TrimSpace(scan.Text()); line != "" {
+			b = append(b, line)
+		}
+	}
+
+	if err := scan.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+
+	return b, nil
+}
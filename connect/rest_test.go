@@ -0,0 +1,115 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+)
+
+func initRest(url string) *rest {
+	r := &rest{
+		cfg: DefaultRestConfig(),
+	}
+
+	r.cfg.Config.Spec.Connect.Rest.Url = url
+	r.cfg.Config.Spec.Connect.Rest.Username = "user"
+	r.cfg.Config.Spec.Connect.Rest.Password = "pass"
+
+	r.cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "rest",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return r
+}
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != "user" || p != "pass" || r.URL.Path != restPath {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("t1") == "" || r.URL.Query().Get("t2") == "" {
+			w.WriteHeader(nethttp.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s\n\n%s\n", event, event)
+	}))
+
+	defer srv.Close()
+
+	r := initRest(srv.URL)
+
+	_, err := r.Events(ctx, 0, 1)
+	assert.NotEqual(t, nil, err)
+
+	err = r.Init(ctx)
+	assert.Equal(t, nil, err)
+
+	defer func() {
+		_ = r.Deinit(ctx)
+	}()
+
+	_, err = r.Events(ctx, 2, 1)
+	assert.NotEqual(t, nil, err)
+
+	b, err := r.Events(ctx, 1672567200, 1672570800)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{event, event}, b)
+
+	r.cfg.Config.Spec.Connect.Rest.Password = "invalid"
+
+	_, err = r.Events(ctx, 1672567200, 1672570800)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestRestReload(t *testing.T) {
+	ctx := context.Background()
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "rotated" {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s\n", event)
+	}))
+
+	defer srv.Close()
+
+	r := initRest("")
+	_ = r.Init(ctx)
+
+	c := config.New()
+	c.Spec.Connect.Rest = config.Rest{Url: srv.URL, Username: "user", Password: "rotated"}
+
+	err := r.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+
+	b, err := r.Events(ctx, 1672567200, 1672570800)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{event}, b)
+
+	c.Spec.Connect.Rest.Url = ":invalid"
+
+	err = r.Reload(ctx, c)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, srv.URL, r.cfg.Config.Spec.Connect.Rest.Url)
+
+	c.Spec.Connect.Rest = config.Rest{}
+
+	err = r.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+
+	_, err = r.Events(ctx, 1672567200, 1672570800)
+	assert.NotEqual(t, nil, err)
+}
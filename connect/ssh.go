@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/pkg/errors"
 	cryptoSsh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 
 	"github.com/gerrittrigger/events/config"
 )
@@ -26,7 +27,9 @@ type Ssh interface {
 	Init(context.Context) error
 	Deinit(context.Context) error
 	Reconnect(context.Context) error
+	Reload(context.Context, *config.Config) error
 	Run(context.Context, string) (string, error)
+	Sessions(context.Context) []Session
 	Start(context.Context, string, chan string) error
 }
 
@@ -35,11 +38,24 @@ type SshConfig struct {
 	Logger hclog.Logger
 }
 
+// Session - Command started on Gerrit and still running
+type Session struct {
+	Command  string `json:"command"`
+	Hostname string `json:"hostname"`
+	Started  int64  `json:"started"`
+}
+
+type sshSession struct {
+	*cryptoSsh.Session
+	info Session
+}
+
 type ssh struct {
 	cfg          *SshConfig
 	client       *cryptoSsh.Client
 	clientConfig *cryptoSsh.ClientConfig
-	sessions     []*cryptoSsh.Session
+	sessions     []*sshSession
+	mutex        sync.Mutex
 }
 
 func SshNew(_ context.Context, cfg *SshConfig) Ssh {
@@ -47,7 +63,7 @@ func SshNew(_ context.Context, cfg *SshConfig) Ssh {
 		cfg:          cfg,
 		client:       nil,
 		clientConfig: nil,
-		sessions:     []*cryptoSsh.Session{},
+		sessions:     []*sshSession{},
 	}
 }
 
@@ -58,24 +74,77 @@ func DefaultSshConfig() *SshConfig {
 func (s *ssh) Init(_ context.Context) error {
 	s.cfg.Logger.Debug("ssh: Init")
 
-	var err error
+	clientConfig, err := newClientConfig(&s.cfg.Config)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	key, err := os.ReadFile(s.cfg.Config.Spec.Connect.Ssh.Keyfile)
+	s.clientConfig = clientConfig
+
+	host := s.cfg.Config.Spec.Connect.Hostname
+	port := s.cfg.Config.Spec.Connect.Ssh.Port
+
+	s.client, err = cryptoSsh.Dial("tcp", fmt.Sprintf("%s:%d", host, port), s.clientConfig)
 	if err != nil {
-		return errors.Wrap(err, "failed to read file")
+		s.client = nil
+		return errors.Wrap(err, "failed to connect server")
 	}
 
-	signer, err := cryptoSsh.ParsePrivateKey(key)
+	return nil
+}
+
+// Reload reads the keyfile and known hosts of the config, and keeps the previous ones if invalid,
+// the connection being kept until reconnected
+func (s *ssh) Reload(_ context.Context, cfg *config.Config) error {
+	s.cfg.Logger.Debug("ssh: Reload")
+
+	clientConfig, err := newClientConfig(cfg)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse key")
+		return err
+	}
+
+	s.mutex.Lock()
+	s.cfg.Config.Spec.Connect = cfg.Spec.Connect
+	s.clientConfig = clientConfig
+	s.mutex.Unlock()
+
+	return nil
+}
+
+func newClientConfig(cfg *config.Config) (*cryptoSsh.ClientConfig, error) {
+	key, err := os.ReadFile(cfg.Spec.Connect.Ssh.Keyfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file")
+	}
+
+	var signer cryptoSsh.Signer
+
+	if password := cfg.Spec.Connect.Ssh.KeyfilePassword; password != "" {
+		signer, err = cryptoSsh.ParsePrivateKeyWithPassphrase(key, []byte(string(password)))
+	} else {
+		signer, err = cryptoSsh.ParsePrivateKey(key)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse key")
 	}
 
 	hostKeyCallback := func(name string, addr net.Addr, key cryptoSsh.PublicKey) error {
 		return nil
 	}
 
-	s.clientConfig = &cryptoSsh.ClientConfig{
-		User: s.cfg.Config.Spec.Connect.Ssh.Username,
+	if cfg.Spec.Connect.Ssh.KnownHosts != "" {
+		hostKeyCallback, err = knownhosts.New(cfg.Spec.Connect.Ssh.KnownHosts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read known hosts")
+		}
+	}
+
+	return &cryptoSsh.ClientConfig{
+		User: cfg.Spec.Connect.Ssh.Username,
 		Auth: []cryptoSsh.AuthMethod{
 			cryptoSsh.PublicKeys(signer),
 		},
@@ -89,30 +158,25 @@ func (s *ssh) Init(_ context.Context) error {
 		},
 		Timeout:         10 * time.Second,
 		HostKeyCallback: hostKeyCallback,
-	}
-
-	host := s.cfg.Config.Spec.Connect.Hostname
-	port := s.cfg.Config.Spec.Connect.Ssh.Port
-
-	s.client, err = cryptoSsh.Dial("tcp", fmt.Sprintf("%s:%d", host, port), s.clientConfig)
-	if err != nil {
-		s.client = nil
-		return errors.Wrap(err, "failed to connect server")
-	}
-
-	return nil
+	}, nil
 }
 
 func (s *ssh) Deinit(_ context.Context) error {
 	s.cfg.Logger.Debug("ssh: Deinit")
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.close()
+}
+
+func (s *ssh) close() error {
 	for i := range s.sessions {
-		if s.sessions[i] != nil {
-			_ = s.sessions[i].Close()
-			s.sessions[i] = nil
-		}
+		_ = s.sessions[i].Close()
 	}
 
+	s.sessions = []*sshSession{}
+
 	if s.client != nil {
 		_ = s.client.Close()
 		s.client = nil
@@ -126,7 +190,10 @@ func (s *ssh) Reconnect(ctx context.Context) error {
 
 	var err error
 
-	_ = s.Deinit(ctx)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_ = s.close()
 
 	host := s.cfg.Config.Spec.Connect.Hostname
 	port := s.cfg.Config.Spec.Connect.Ssh.Port
@@ -143,11 +210,15 @@ func (s *ssh) Reconnect(ctx context.Context) error {
 func (s *ssh) Run(_ context.Context, cmd string) (string, error) {
 	s.cfg.Logger.Debug("ssh: Run")
 
-	if s.client == nil {
+	s.mutex.Lock()
+	client := s.client
+	s.mutex.Unlock()
+
+	if client == nil {
 		return "", errors.New("invalid client")
 	}
 
-	session, err := s.client.NewSession()
+	session, err := client.NewSession()
 
 	defer func(session *cryptoSsh.Session) {
 		if session != nil {
@@ -182,6 +253,9 @@ func (s *ssh) Start(_ context.Context, cmd string, out chan string) error {
 		_ = scan.Err()
 	}
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.client == nil {
 		return errors.New("invalid client")
 	}
@@ -219,13 +293,23 @@ func (s *ssh) Start(_ context.Context, cmd string, out chan string) error {
 		return errors.Wrap(err, "failed to start session")
 	}
 
-	s.sessions = append(s.sessions, session)
+	item := &sshSession{
+		Session: session,
+		info: Session{
+			Command:  cmd,
+			Hostname: s.cfg.Config.Spec.Connect.Hostname,
+			Started:  time.Now().Unix(),
+		},
+	}
+
+	s.sessions = append(s.sessions, item)
 
 	wg.Add(1)
 
 	go func() {
 		defer wg.Done()
 		_ = session.Wait()
+		s.remove(item)
 	}()
 
 	go func() {
@@ -234,3 +318,31 @@ func (s *ssh) Start(_ context.Context, cmd string, out chan string) error {
 
 	return nil
 }
+
+// Sessions lists the commands started and still running, e.g., stream-events
+func (s *ssh) Sessions(_ context.Context) []Session {
+	s.cfg.Logger.Debug("ssh: Sessions")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b := make([]Session, len(s.sessions))
+
+	for i := range s.sessions {
+		b[i] = s.sessions[i].info
+	}
+
+	return b
+}
+
+func (s *ssh) remove(item *sshSession) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.sessions {
+		if s.sessions[i] == item {
+			s.sessions = append(s.sessions[:i], s.sessions[i+1:]...)
+			return
+		}
+	}
+}
@@ -1,9 +1,269 @@
 package connect
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/connect/gerrittest"
+)
+
+const (
+	event   = `{"type":"ref-updated","eventCreatedOn":1672567200}`
+	timeout = 5 * time.Second
 )
 
-func TestSsh(t *testing.T) {
-	// PASS
+func initSsh(t *testing.T, srv *gerrittest.Server) *ssh {
+	keyfile, err := gerrittest.Keyfile(t.TempDir())
+	assert.Equal(t, nil, err)
+
+	s := &ssh{
+		cfg:      DefaultSshConfig(),
+		sessions: []*sshSession{},
+	}
+
+	s.cfg.Config.Spec.Connect.Hostname = srv.Hostname()
+	s.cfg.Config.Spec.Connect.Ssh.Keyfile = keyfile
+	s.cfg.Config.Spec.Connect.Ssh.Port = srv.Port()
+	s.cfg.Config.Spec.Connect.Ssh.Username = gerrittest.Username
+
+	s.cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "ssh",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return s
+}
+
+func initServer(t *testing.T, cfg gerrittest.Config) *gerrittest.Server {
+	srv, err := gerrittest.New(cfg)
+	assert.Equal(t, nil, err)
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return srv
+}
+
+func waitStreams(srv *gerrittest.Server, n int) bool {
+	for i := 0; i < 100; i++ {
+		if srv.Streams() == n {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestInit(t *testing.T) {
+	ctx := context.Background()
+	srv := initServer(t, gerrittest.Config{})
+	s := initSsh(t, srv)
+
+	err := s.Init(ctx)
+	assert.Equal(t, nil, err)
+	_ = s.Deinit(ctx)
+
+	s.cfg.Config.Spec.Connect.Ssh.Username = "invalid"
+
+	err = s.Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	s.cfg.Config.Spec.Connect.Ssh.Username = gerrittest.Username
+	s.cfg.Config.Spec.Connect.Ssh.Keyfile = "invalid"
+
+	err = s.Init(ctx)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestKeyfilePassword(t *testing.T) {
+	ctx := context.Background()
+	srv := initServer(t, gerrittest.Config{})
+	s := initSsh(t, srv)
+
+	keyfile, err := gerrittest.KeyfileWithPassphrase(t.TempDir(), "pass")
+	assert.Equal(t, nil, err)
+
+	s.cfg.Config.Spec.Connect.Ssh.Keyfile = keyfile
+
+	err = s.Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	s.cfg.Config.Spec.Connect.Ssh.KeyfilePassword = "pass"
+
+	err = s.Init(ctx)
+	assert.Equal(t, nil, err)
+	_ = s.Deinit(ctx)
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	srv := initServer(t, gerrittest.Config{})
+	s := initSsh(t, srv)
+
+	err := s.Init(ctx)
+	assert.Equal(t, nil, err)
+
+	defer func() {
+		_ = s.Deinit(ctx)
+	}()
+
+	c := s.cfg.Config
+
+	c.Spec.Connect.Ssh.Keyfile = "invalid"
+
+	err = s.Reload(ctx, &c)
+	assert.NotEqual(t, nil, err)
+
+	c.Spec.Connect.Ssh.Keyfile, _ = gerrittest.KeyfileWithPassphrase(t.TempDir(), "pass")
+	c.Spec.Connect.Ssh.KeyfilePassword = "pass"
+	c.Spec.Connect.Ssh.Username = "invalid"
+
+	err = s.Reload(ctx, &c)
+	assert.Equal(t, nil, err)
+
+	// Applied once reconnected
+	_, err = s.Run(ctx, "version")
+	assert.Equal(t, nil, err)
+
+	err = s.Reconnect(ctx)
+	assert.NotEqual(t, nil, err)
+
+	c.Spec.Connect.Ssh.Username = gerrittest.Username
+
+	err = s.Reload(ctx, &c)
+	assert.Equal(t, nil, err)
+
+	err = s.Reconnect(ctx)
+	assert.Equal(t, nil, err)
+}
+
+func TestKnownHosts(t *testing.T) {
+	ctx := context.Background()
+	srv := initServer(t, gerrittest.Config{})
+	s := initSsh(t, srv)
+
+	name, err := srv.KnownHosts(t.TempDir())
+	assert.Equal(t, nil, err)
+
+	s.cfg.Config.Spec.Connect.Ssh.KnownHosts = name
+
+	err = s.Init(ctx)
+	assert.Equal(t, nil, err)
+
+	err = srv.RotateHostKey()
+	assert.Equal(t, nil, err)
+
+	err = s.Reconnect(ctx)
+	assert.NotEqual(t, nil, err)
+
+	_ = s.Deinit(ctx)
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	srv := initServer(t, gerrittest.Config{})
+	s := initSsh(t, srv)
+
+	_, err := s.Run(ctx, "version")
+	assert.NotEqual(t, nil, err)
+
+	_ = s.Init(ctx)
+
+	defer func() {
+		_ = s.Deinit(ctx)
+	}()
+
+	out, err := s.Run(ctx, "version")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.HasPrefix(out, "gerrit version"))
+
+	_, err = s.Run(ctx, "invalid")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestStart(t *testing.T) {
+	ctx := context.Background()
+	srv := initServer(t, gerrittest.Config{Events: []string{event}})
+	s := initSsh(t, srv)
+
+	out := make(chan string, 10)
+
+	err := s.Start(ctx, "stream-events", out)
+	assert.NotEqual(t, nil, err)
+
+	_ = s.Init(ctx)
+
+	defer func() {
+		_ = s.Deinit(ctx)
+	}()
+
+	err = s.Start(ctx, "stream-events", out)
+	assert.Equal(t, nil, err)
+
+	select {
+	case b := <-out:
+		assert.Equal(t, event, b)
+	case <-time.After(timeout):
+		assert.Fail(t, "scripted event timeout")
+	}
+
+	b := s.Sessions(ctx)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, "stream-events", b[0].Command)
+	assert.Equal(t, srv.Hostname(), b[0].Hostname)
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+	srv.Push(event)
+
+	select {
+	case b := <-out:
+		assert.Equal(t, event, b)
+	case <-time.After(timeout):
+		assert.Fail(t, "pushed event timeout")
+	}
+
+	srv.Disconnect()
+	assert.Equal(t, true, waitStreams(srv, 0))
+
+	for i := 0; i < 100 && len(s.Sessions(ctx)) != 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	assert.Equal(t, 0, len(s.Sessions(ctx)))
+}
+
+func TestReconnect(t *testing.T) {
+	ctx := context.Background()
+	srv := initServer(t, gerrittest.Config{})
+	s := initSsh(t, srv)
+
+	_ = s.Init(ctx)
+
+	defer func() {
+		_ = s.Deinit(ctx)
+	}()
+
+	out := make(chan string, 10)
+
+	_ = s.Start(ctx, "stream-events", out)
+	assert.Equal(t, true, waitStreams(srv, 1))
+
+	srv.Disconnect()
+	assert.Equal(t, true, waitStreams(srv, 0))
+
+	_, err := s.Run(ctx, "version")
+	assert.NotEqual(t, nil, err)
+
+	err = s.Reconnect(ctx)
+	assert.Equal(t, nil, err)
+
+	_, err = s.Run(ctx, "version")
+	assert.Equal(t, nil, err)
 }
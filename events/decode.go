@@ -0,0 +1,373 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	refsHeads = "refs/heads/"
+)
+
+var (
+	typed = map[string]func() Typed{
+		EVENTS_ASSIGNEE_CHANGED:      func() Typed { return &AssigneeChanged{} },
+		EVENTS_BATCH_REF_UPDATED:     func() Typed { return &BatchRefUpdated{} },
+		EVENTS_CHANGE_ABANDONED:      func() Typed { return &ChangeAbandoned{} },
+		EVENTS_CHANGE_DELETED:        func() Typed { return &ChangeDeleted{} },
+		EVENTS_CHANGE_MERGED:         func() Typed { return &ChangeMerged{} },
+		EVENTS_CHANGE_RESTORED:       func() Typed { return &ChangeRestored{} },
+		EVENTS_COMMENT_ADDED:         func() Typed { return &CommentAdded{} },
+		EVENTS_DRAFT_PUBLISHED:       func() Typed { return &DraftPublished{} },
+		EVENTS_DROPPED_OUTPUT:        func() Typed { return &DroppedOutput{} },
+		EVENTS_HASHTAGS_CHANGED:      func() Typed { return &HashtagsChanged{} },
+		EVENTS_MERGE_FAILED:          func() Typed { return &MergeFailed{} },
+		EVENTS_PATCHSET_CREATED:      func() Typed { return &PatchsetCreated{} },
+		EVENTS_PATCHSET_NOTIFIED:     func() Typed { return &PatchsetNotified{} },
+		EVENTS_PRIVATE_STATE_CHANGED: func() Typed { return &PrivateStateChanged{} },
+		EVENTS_PROJECT_CREATED:       func() Typed { return &ProjectCreated{} },
+		EVENTS_PROJECT_HEAD_UPDATED:  func() Typed { return &ProjectHeadUpdated{} },
+		EVENTS_REF_REPLICATED:        func() Typed { return &RefReplicated{} },
+		EVENTS_REF_REPLICATED_DONE:   func() Typed { return &RefReplicatedDone{} },
+		EVENTS_REF_REPLICATION_DONE:  func() Typed { return &RefReplicatedDone{} },
+		EVENTS_REF_UPDATED:           func() Typed { return &RefUpdated{} },
+		EVENTS_RERUN_CHECK:           func() Typed { return &RerunCheck{} },
+		EVENTS_REVIEWER_ADDED:        func() Typed { return &ReviewerAdded{} },
+		EVENTS_REVIEWER_DELETED:      func() Typed { return &ReviewerDeleted{} },
+		EVENTS_TOPIC_CHANGED:         func() Typed { return &TopicChanged{} },
+		EVENTS_VOTE_DELETED:          func() Typed { return &VoteDeleted{} },
+		EVENTS_WIP_STATE_CHANGED:     func() Typed { return &WipStateChanged{} },
+	}
+)
+
+// Typed - Event decoded into the concrete struct of its type.
+type Typed interface {
+	Type() string
+	Project() string
+	Branch() string
+	CreatedOn() int64
+}
+
+// Base - Fields shared by all events.
+type Base struct {
+	EventType      string `json:"type"`
+	EventCreatedOn int64  `json:"eventCreatedOn,omitempty"`
+}
+
+func (b *Base) Type() string {
+	return b.EventType
+}
+
+func (b *Base) CreatedOn() int64 {
+	return b.EventCreatedOn
+}
+
+// ChangeBase - Fields shared by events about a change.
+type ChangeBase struct {
+	Base
+	Change    Change    `json:"change,omitempty"`
+	ChangeKey ChangeKey `json:"changeKey,omitempty"`
+}
+
+func (c *ChangeBase) Project() string {
+	return c.Change.Project
+}
+
+func (c *ChangeBase) Branch() string {
+	return c.Change.Branch
+}
+
+// PatchSetBase - Fields shared by events about a patchset of a change.
+type PatchSetBase struct {
+	ChangeBase
+	PatchSet PatchSet `json:"patchSet,omitempty"`
+}
+
+// AssigneeChanged - Sent when the assignee of a change has been modified.
+type AssigneeChanged struct {
+	ChangeBase
+	Changer     Account `json:"changer,omitempty"`
+	OldAssignee Account `json:"oldAssignee,omitempty"`
+}
+
+// BatchRefUpdated - Sent when one or more references are modified.
+type BatchRefUpdated struct {
+	Base
+	Submitter  Account     `json:"submitter,omitempty"`
+	RefUpdates []RefUpdate `json:"refUpdates,omitempty"`
+}
+
+func (b *BatchRefUpdated) Project() string {
+	if len(b.RefUpdates) == 0 {
+		return ""
+	}
+
+	return b.RefUpdates[0].Project
+}
+
+func (b *BatchRefUpdated) Branch() string {
+	if len(b.RefUpdates) == 0 {
+		return ""
+	}
+
+	return strings.TrimPrefix(b.RefUpdates[0].RefName, refsHeads)
+}
+
+// ChangeAbandoned - Sent when a change has been abandoned.
+type ChangeAbandoned struct {
+	PatchSetBase
+	Abandoner Account `json:"abandoner,omitempty"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// ChangeDeleted - Sent when a change has been deleted.
+type ChangeDeleted struct {
+	ChangeBase
+	Deleter Account `json:"deleter,omitempty"`
+}
+
+// ChangeMerged - Sent when a change has been merged into the git repository.
+type ChangeMerged struct {
+	PatchSetBase
+	Submitter Account `json:"submitter,omitempty"`
+	NewRev    string  `json:"newRev,omitempty"`
+}
+
+// ChangeRestored - Sent when an abandoned change has been restored.
+type ChangeRestored struct {
+	PatchSetBase
+	Restorer Account `json:"restorer,omitempty"`
+	Reason   string  `json:"reason,omitempty"`
+}
+
+// CommentAdded - Sent when a review comment has been posted on a change.
+type CommentAdded struct {
+	PatchSetBase
+	Author    Account    `json:"author,omitempty"`
+	Approvals []Approval `json:"approvals,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+}
+
+// DraftPublished - Sent when a draft change has been published.
+type DraftPublished struct {
+	PatchSetBase
+	Uploader Account `json:"uploader,omitempty"`
+}
+
+// DroppedOutput - Sent to notify a client that events have been dropped.
+type DroppedOutput struct {
+	Base
+}
+
+func (d *DroppedOutput) Project() string {
+	return ""
+}
+
+func (d *DroppedOutput) Branch() string {
+	return ""
+}
+
+// HashtagsChanged - Sent when the hashtags have been added to or removed from a change.
+type HashtagsChanged struct {
+	ChangeBase
+	Editor   Account  `json:"editor,omitempty"`
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	HashTags []string `json:"hashtags,omitempty"`
+}
+
+// MergeFailed - Sent when a change has failed to be merged into the git repository.
+type MergeFailed struct {
+	PatchSetBase
+	Submitter Account `json:"submitter,omitempty"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// PatchsetCreated - Sent when a new change has been uploaded, or a new patchset has been uploaded to an existing change.
+type PatchsetCreated struct {
+	PatchSetBase
+	Uploader Account `json:"uploader,omitempty"`
+}
+
+// PatchsetNotified - Sent when a patchset has been notified.
+type PatchsetNotified struct {
+	PatchSetBase
+}
+
+// PrivateStateChanged - Sent when the private state of a change has been changed.
+type PrivateStateChanged struct {
+	PatchSetBase
+	Changer Account `json:"changer,omitempty"`
+}
+
+// ProjectCreated - Sent when a new project has been created.
+type ProjectCreated struct {
+	Base
+	ProjectName string `json:"projectName,omitempty"`
+	ProjectHead string `json:"projectHead,omitempty"`
+}
+
+func (p *ProjectCreated) Project() string {
+	return p.ProjectName
+}
+
+func (p *ProjectCreated) Branch() string {
+	return strings.TrimPrefix(p.ProjectHead, refsHeads)
+}
+
+// ProjectHeadUpdated - Sent when the HEAD of a project has been updated.
+type ProjectHeadUpdated struct {
+	Base
+	ProjectName string `json:"projectName,omitempty"`
+	OldHead     string `json:"oldHead,omitempty"`
+	NewHead     string `json:"newHead,omitempty"`
+}
+
+func (p *ProjectHeadUpdated) Project() string {
+	return p.ProjectName
+}
+
+func (p *ProjectHeadUpdated) Branch() string {
+	return strings.TrimPrefix(p.NewHead, refsHeads)
+}
+
+// RefReplicated - Sent by the replication plugin when a ref has been replicated to a node.
+// https://gerrit.googlesource.com/plugins/replication/+/refs/heads/master/src/main/resources/Documentation/about.md
+type RefReplicated struct {
+	Base
+	ProjectName string `json:"project,omitempty"`
+	Ref         string `json:"ref,omitempty"`
+	TargetNode  string `json:"targetNode,omitempty"`
+	Status      string `json:"status,omitempty"`
+	RefStatus   string `json:"refStatus,omitempty"`
+}
+
+func (r *RefReplicated) Project() string {
+	return r.ProjectName
+}
+
+func (r *RefReplicated) Branch() string {
+	return strings.TrimPrefix(r.Ref, refsHeads)
+}
+
+// RefReplicatedDone - Sent by the replication plugin when a ref has been replicated to all nodes.
+// It is decoded for both ref-replication-done and the legacy ref-replicated-done type.
+type RefReplicatedDone struct {
+	Base
+	ProjectName string `json:"project,omitempty"`
+	Ref         string `json:"ref,omitempty"`
+	NodesCount  int    `json:"nodesCount,omitempty"`
+}
+
+func (r *RefReplicatedDone) Project() string {
+	return r.ProjectName
+}
+
+func (r *RefReplicatedDone) Branch() string {
+	return strings.TrimPrefix(r.Ref, refsHeads)
+}
+
+// RefUpdated - Sent when a reference is updated in a git repository.
+type RefUpdated struct {
+	Base
+	Submitter Account   `json:"submitter,omitempty"`
+	RefUpdate RefUpdate `json:"refUpdate,omitempty"`
+}
+
+func (r *RefUpdated) Project() string {
+	return r.RefUpdate.Project
+}
+
+func (r *RefUpdated) Branch() string {
+	return strings.TrimPrefix(r.RefUpdate.RefName, refsHeads)
+}
+
+// RerunCheck - Sent by the checks plugin when a check has been requested to rerun.
+type RerunCheck struct {
+	PatchSetBase
+}
+
+// ReviewerAdded - Sent when a reviewer is added to a change.
+type ReviewerAdded struct {
+	PatchSetBase
+	Adder    Account `json:"adder,omitempty"`
+	Reviewer Account `json:"reviewer,omitempty"`
+}
+
+// ReviewerDeleted - Sent when a reviewer (with a vote) is removed from a change.
+type ReviewerDeleted struct {
+	PatchSetBase
+	Reviewer  Account    `json:"reviewer,omitempty"`
+	Remover   Account    `json:"remover,omitempty"`
+	Approvals []Approval `json:"approvals,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+}
+
+// TopicChanged - Sent when the topic of a change has been changed.
+type TopicChanged struct {
+	ChangeBase
+	Changer  Account `json:"changer,omitempty"`
+	OldTopic string  `json:"oldTopic,omitempty"`
+}
+
+// VoteDeleted - Sent when a vote was removed from a change.
+type VoteDeleted struct {
+	PatchSetBase
+	Reviewer  Account    `json:"reviewer,omitempty"`
+	Remover   Account    `json:"remover,omitempty"`
+	Approvals []Approval `json:"approvals,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+}
+
+// WipStateChanged - Sent when the WIP state of a change has been changed.
+type WipStateChanged struct {
+	PatchSetBase
+	Changer Account `json:"changer,omitempty"`
+}
+
+// Unknown - Event of a type not known to this package, preserved as raw JSON.
+type Unknown struct {
+	Base
+	Raw json.RawMessage `json:"-"`
+}
+
+// MarshalJSON returns the raw JSON decoded, or the base fields if not decoded.
+func (u Unknown) MarshalJSON() ([]byte, error) {
+	if len(u.Raw) == 0 {
+		return json.Marshal(u.Base)
+	}
+
+	return u.Raw, nil
+}
+
+func (u *Unknown) Project() string {
+	return ""
+}
+
+func (u *Unknown) Branch() string {
+	return ""
+}
+
+// Decode returns the concrete struct for the type of the event in data.
+func Decode(data []byte) (Typed, error) {
+	var b Base
+
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal")
+	}
+
+	n, ok := typed[b.EventType]
+	if !ok {
+		return &Unknown{Base: b, Raw: append(json.RawMessage{}, data...)}, nil
+	}
+
+	t := n()
+
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal "+b.EventType)
+	}
+
+	return t, nil
+}
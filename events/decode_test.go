@@ -0,0 +1,80 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	_, err := Decode([]byte("invalid"))
+	assert.NotEqual(t, nil, err)
+
+	_, err = Decode([]byte(`{"type":"patchset-created","change":"invalid"}`))
+	assert.NotEqual(t, nil, err)
+
+	e, err := Decode([]byte(`{"type":"patchset-created","change":{"project":"p","branch":"b"},` +
+		`"patchSet":{"number":1},"uploader":{"name":"u"},"eventCreatedOn":1672567200}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, EVENTS_PATCHSET_CREATED, e.Type())
+	assert.Equal(t, "p", e.Project())
+	assert.Equal(t, "b", e.Branch())
+	assert.Equal(t, int64(1672567200), e.CreatedOn())
+
+	p, ok := e.(*PatchsetCreated)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, p.PatchSet.Number)
+	assert.Equal(t, "u", p.Uploader.Name)
+
+	e, err = Decode([]byte(`{"type":"ref-updated","refUpdate":{"project":"p","refName":"refs/heads/b"}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "p", e.Project())
+	assert.Equal(t, "b", e.Branch())
+
+	_, ok = e.(*RefUpdated)
+	assert.Equal(t, true, ok)
+
+	e, err = Decode([]byte(`{"type":"change-merged","change":{"project":"p","branch":"b"},"newRev":"r"}`))
+	assert.Equal(t, nil, err)
+
+	m, ok := e.(*ChangeMerged)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "r", m.NewRev)
+
+	e, err = Decode([]byte(`{"type":"project-created","projectName":"p","projectHead":"refs/heads/b"}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "p", e.Project())
+	assert.Equal(t, "b", e.Branch())
+
+	raw := `{"type":"invalid-type","eventCreatedOn":1672567200,"foo":"bar"}`
+
+	e, err = Decode([]byte(raw))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "invalid-type", e.Type())
+	assert.Equal(t, "", e.Project())
+	assert.Equal(t, "", e.Branch())
+	assert.Equal(t, int64(1672567200), e.CreatedOn())
+
+	u, ok := e.(*Unknown)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, raw, string(u.Raw))
+
+	// Encoded back as decoded
+	buf, err := json.Marshal(e)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, raw, string(buf))
+
+	buf, err = json.Marshal(Unknown{Base: Base{EventType: "invalid-type"}})
+	assert.Equal(t, nil, err)
+	assert.Contains(t, string(buf), `"type":"invalid-type"`)
+}
+
+func TestTyped(t *testing.T) {
+	for k, n := range typed {
+		e, err := Decode([]byte(`{"type":"` + k + `"}`))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, k, e.Type())
+		assert.IsType(t, n(), e)
+	}
+}
@@ -1,22 +1,29 @@
 package events
 
 const (
+	EVENTS_ASSIGNEE_CHANGED      = "assignee-changed"
+	EVENTS_BATCH_REF_UPDATED     = "batch-ref-updated"
 	EVENTS_CHANGE_ABANDONED      = "change-abandoned"
+	EVENTS_CHANGE_DELETED        = "change-deleted"
 	EVENTS_CHANGE_MERGED         = "change-merged"
 	EVENTS_CHANGE_RESTORED       = "change-restored"
 	EVENTS_COMMENT_ADDED         = "comment-added"
 	EVENTS_DRAFT_PUBLISHED       = "draft-published"
+	EVENTS_DROPPED_OUTPUT        = "dropped-output"
 	EVENTS_HASHTAGS_CHANGED      = "hashtags-changed"
 	EVENTS_MERGE_FAILED          = "merge-failed"
 	EVENTS_PATCHSET_CREATED      = "patchset-created"
 	EVENTS_PATCHSET_NOTIFIED     = "patchset-notified"
 	EVENTS_PRIVATE_STATE_CHANGED = "private-state-changed"
 	EVENTS_PROJECT_CREATED       = "project-created"
+	EVENTS_PROJECT_HEAD_UPDATED  = "project-head-updated"
 	EVENTS_REF_REPLICATED        = "ref-replicated"
 	EVENTS_REF_REPLICATED_DONE   = "ref-replicated-done"
+	EVENTS_REF_REPLICATION_DONE  = "ref-replication-done"
 	EVENTS_REF_UPDATED           = "ref-updated"
 	EVENTS_RERUN_CHECK           = "rerun-check"
 	EVENTS_REVIEWER_ADDED        = "reviewer-added"
+	EVENTS_REVIEWER_DELETED      = "reviewer-deleted"
 	EVENTS_TOPIC_CHANGED         = "topic-changed"
 	EVENTS_VOTE_DELETED          = "vote-deleted"
 	EVENTS_WIP_STATE_CHANGED     = "wip-state-changed"
@@ -32,28 +39,41 @@ type Event struct {
 	Approvals []Approval `json:"approvals,omitempty"`
 
 	Abandoner Account `json:"abandoner,omitempty"`
+	Adder     Account `json:"adder,omitempty"`
 	Changer   Account `json:"changer,omitempty"`
+	Deleter   Account `json:"deleter,omitempty"`
 	Submitter Account `json:"submitter,omitempty"`
+	Remover   Account `json:"remover,omitempty"`
 	Restorer  Account `json:"restorer,omitempty"`
 	Author    Account `json:"author,omitempty"`
 	Uploader  Account `json:"uploader,omitempty"`
 	Editor    Account `json:"editor,omitempty"`
 	Reviewer  Account `json:"reviewer,omitempty"`
 
-	NewRev      string    `json:"newRev,omitempty"`
-	OldAssignee string    `json:"oldAssignee,omitempty"`
-	OldTopic    string    `json:"oldTopic,omitempty"`
-	Reason      string    `json:"reason,omitempty"`
-	Comment     string    `json:"comment,omitempty"`
-	Added       []string  `json:"added,omitempty"`
-	Removed     []string  `json:"removed,omitempty"`
-	HashTags    []string  `json:"hashtags,omitempty"`
-	ProjectName string    `json:"projectName,omitempty"`
-	ProjectHead string    `json:"projectHead,omitempty"`
-	Project     string    `json:"project,omitempty"`
-	RefName     string    `json:"refName,omitempty"`
-	RefUpdate   RefUpdate `json:"refUpdate,omitempty"`
-	ChangeKey   ChangeKey `json:"changeKey,omitempty"`
+	NewRev      string      `json:"newRev,omitempty"`
+	OldAssignee Account     `json:"oldAssignee,omitempty"`
+	OldTopic    string      `json:"oldTopic,omitempty"`
+	Reason      string      `json:"reason,omitempty"`
+	Comment     string      `json:"comment,omitempty"`
+	Added       []string    `json:"added,omitempty"`
+	Removed     []string    `json:"removed,omitempty"`
+	HashTags    []string    `json:"hashtags,omitempty"`
+	ProjectName string      `json:"projectName,omitempty"`
+	ProjectHead string      `json:"projectHead,omitempty"`
+	OldHead     string      `json:"oldHead,omitempty"`
+	NewHead     string      `json:"newHead,omitempty"`
+	Project     string      `json:"project,omitempty"`
+	RefName     string      `json:"refName,omitempty"`
+	RefUpdate   RefUpdate   `json:"refUpdate,omitempty"`
+	RefUpdates  []RefUpdate `json:"refUpdates,omitempty"`
+	ChangeKey   ChangeKey   `json:"changeKey,omitempty"`
+
+	// Sent by the replication plugin
+	Ref        string `json:"ref,omitempty"`
+	TargetNode string `json:"targetNode,omitempty"`
+	Status     string `json:"status,omitempty"`
+	RefStatus  string `json:"refStatus,omitempty"`
+	NodesCount int    `json:"nodesCount,omitempty"`
 
 	EventCreatedOn int64 `json:"eventCreatedOn,omitempty"`
 }
@@ -61,20 +81,28 @@ type Event struct {
 // Change - The Gerrit change being reviewed, or that was already reviewed
 // https://gerrit-review.googlesource.com/Documentation/json.html#change
 type Change struct {
-	Project       string  `json:"project,omitempty"`
-	Branch        string  `json:"branch,omitempty"`
-	Topic         string  `json:"topic,omitempty"`
-	ID            string  `json:"id,omitempty"`
-	Number        int     `json:"number,omitempty"`
-	Subject       string  `json:"subject,omitempty"`
-	Owner         Account `json:"owner,omitempty"`
-	URL           string  `json:"url,omitempty"`
-	CommitMessage string  `json:"commitMessage,omitempty"`
-	CreatedOn     int64   `json:"createdOn,omitempty"`
-	LastUpdate    int64   `json:"lastUpdate,omitempty"`
-	Open          bool    `json:"open,omitempty"`
-	Private       bool    `json:"private,omitempty"`
-	WIP           bool    `json:"wip,omitempty"`
+	Project       string   `json:"project,omitempty"`
+	Branch        string   `json:"branch,omitempty"`
+	Topic         string   `json:"topic,omitempty"`
+	ID            string   `json:"id,omitempty"`
+	Number        int      `json:"number,omitempty"`
+	Subject       string   `json:"subject,omitempty"`
+	Owner         Account  `json:"owner,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	CommitMessage string   `json:"commitMessage,omitempty"`
+	HashTags      []string `json:"hashtags,omitempty"`
+	CreatedOn     int64    `json:"createdOn,omitempty"`
+	LastUpdate    int64    `json:"lastUpdated,omitempty"`
+	Open          bool     `json:"open,omitempty"`
+	Private       bool     `json:"private,omitempty"`
+	WIP           bool     `json:"wip,omitempty"`
+	Assignee      Account  `json:"assignee,omitempty"`
+
+	// MERGE_IF_NECESSARY, FAST_FORWARD_ONLY, REBASE_IF_NECESSARY, REBASE_ALWAYS, MERGE_ALWAYS, CHERRY_PICK
+	SubmitType string `json:"submitType,omitempty"`
+
+	CherryPickOfChange   int `json:"cherryPickOfChange,omitempty"`
+	CherryPickOfPatchSet int `json:"cherryPickOfPatchSet,omitempty"`
 
 	// NEW - Change is still being reviewed.
 	// DRAFT - Change is a draft change that only consists of draft patchsets.
@@ -142,6 +170,7 @@ type Approval struct {
 	OldValue    string  `json:"oldValue,omitempty"`
 	GrantedOn   int64   `json:"grantedOn,omitempty"`
 	Author      Account `json:"author,omitempty"`
+	By          Account `json:"by,omitempty"`
 }
 
 // RefUpdate - Information about a ref that was updated.
@@ -1,9 +1,134 @@
 package events
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	createdOn = 1672567200
+	project   = "platform/build"
+	branch    = "main"
 )
 
+var (
+	golden = []struct {
+		name    string
+		project string
+		branch  string
+	}{
+		{EVENTS_ASSIGNEE_CHANGED, project, branch},
+		{EVENTS_BATCH_REF_UPDATED, project, branch},
+		{EVENTS_CHANGE_ABANDONED, project, branch},
+		{EVENTS_CHANGE_DELETED, project, branch},
+		{EVENTS_CHANGE_MERGED, project, branch},
+		{EVENTS_CHANGE_RESTORED, project, branch},
+		{EVENTS_COMMENT_ADDED, project, branch},
+		{EVENTS_DRAFT_PUBLISHED, project, branch},
+		{EVENTS_DROPPED_OUTPUT, "", ""},
+		{EVENTS_HASHTAGS_CHANGED, project, branch},
+		{EVENTS_MERGE_FAILED, project, branch},
+		{EVENTS_PATCHSET_CREATED, project, branch},
+		{EVENTS_PATCHSET_NOTIFIED, project, branch},
+		{EVENTS_PRIVATE_STATE_CHANGED, project, branch},
+		{EVENTS_PROJECT_CREATED, project, branch},
+		{EVENTS_PROJECT_HEAD_UPDATED, project, branch},
+		{EVENTS_REF_REPLICATED, project, branch},
+		{EVENTS_REF_REPLICATED_DONE, project, branch},
+		{EVENTS_REF_REPLICATION_DONE, project, branch},
+		{EVENTS_REF_UPDATED, project, branch},
+		{EVENTS_RERUN_CHECK, project, branch},
+		{EVENTS_REVIEWER_ADDED, project, branch},
+		{EVENTS_REVIEWER_DELETED, project, branch},
+		{EVENTS_TOPIC_CHANGED, project, branch},
+		{EVENTS_VOTE_DELETED, project, branch},
+		{EVENTS_WIP_STATE_CHANGED, project, branch},
+	}
+)
+
+func readGolden(t *testing.T, name string) []byte {
+	b, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	assert.Equal(t, nil, err)
+
+	return b
+}
+
 func TestEvents(t *testing.T) {
-	// PASS
+	files, _ := filepath.Glob(filepath.Join("testdata", "*.json"))
+	assert.Equal(t, len(golden), len(files))
+	assert.Equal(t, len(golden), len(typed))
+
+	for _, item := range golden {
+		b := readGolden(t, item.name)
+
+		// Every field is covered by the flat struct too
+		e := Event{}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&e)
+		assert.Equal(t, nil, err, item.name)
+		assert.Equal(t, item.name, e.Type)
+		assert.Equal(t, int64(createdOn), e.EventCreatedOn)
+
+		d, err := Decode(b)
+		assert.Equal(t, nil, err, item.name)
+		assert.Equal(t, item.name, d.Type())
+		assert.Equal(t, item.project, d.Project(), item.name)
+		assert.Equal(t, item.branch, d.Branch(), item.name)
+		assert.Equal(t, int64(createdOn), d.CreatedOn())
+
+		dec = json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(typed[item.name]())
+		assert.Equal(t, nil, err, item.name)
+	}
+}
+
+func TestChange(t *testing.T) {
+	e := Event{}
+	err := json.Unmarshal(readGolden(t, EVENTS_PATCHSET_CREATED), &e)
+	assert.Equal(t, nil, err)
+
+	dec := json.NewDecoder(bytes.NewReader(readGolden(t, EVENTS_PATCHSET_CREATED)))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&e)
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, []string{"ci"}, e.Change.HashTags)
+	assert.Equal(t, "MERGE_IF_NECESSARY", e.Change.SubmitType)
+	assert.Equal(t, 12000, e.Change.CherryPickOfChange)
+	assert.Equal(t, 2, e.Change.CherryPickOfPatchSet)
+	assert.Equal(t, int64(1672567100), e.Change.LastUpdate)
+	assert.Equal(t, "assignee", e.Change.Assignee.Username)
+	assert.Equal(t, 2, len(e.PatchSet.Files))
+
+	err = json.Unmarshal(readGolden(t, EVENTS_COMMENT_ADDED), &e)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "reviewer", e.Approvals[0].By.Username)
+
+	err = json.Unmarshal(readGolden(t, EVENTS_BATCH_REF_UPDATED), &e)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(e.RefUpdates))
+
+	err = json.Unmarshal(readGolden(t, EVENTS_ASSIGNEE_CHANGED), &e)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "old", e.OldAssignee.Username)
+
+	r := Event{}
+	err = json.Unmarshal(readGolden(t, EVENTS_REF_REPLICATED), &r)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "refs/heads/main", r.Ref)
+	assert.Equal(t, "mirror.example.com", r.TargetNode)
+	assert.Equal(t, "succeeded", r.Status)
+	assert.Equal(t, "OK", r.RefStatus)
+
+	r = Event{}
+	err = json.Unmarshal(readGolden(t, EVENTS_REF_REPLICATION_DONE), &r)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, r.NodesCount)
 }
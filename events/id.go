@@ -0,0 +1,71 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+const (
+	idSeparator = "\x00"
+)
+
+// ID derives the identity of the event from the fields telling events apart, i.e., its type, change, patchset,
+// project, refs, accounts, comment, approvals and timestamp, so that the same event received by several replicas
+// gets the same ID, whereas volatile fields, e.g., the status of the change, are left out
+func ID(e *Event) string {
+	b := []string{
+		e.Type,
+		e.Change.Project,
+		strconv.Itoa(e.Change.Number),
+		e.Change.ID,
+		strconv.Itoa(e.PatchSet.Number),
+		e.PatchSet.Revision,
+		e.Project,
+		e.ProjectName,
+		e.ProjectHead,
+		e.OldHead,
+		e.NewHead,
+		e.RefName,
+		refID(&e.RefUpdate),
+		e.Ref,
+		e.TargetNode,
+		e.Status,
+		e.RefStatus,
+		strconv.Itoa(e.NodesCount),
+		e.NewRev,
+		e.OldTopic,
+		e.Reason,
+		e.Comment,
+		strings.Join(e.Added, ","),
+		strings.Join(e.Removed, ","),
+		strings.Join(e.HashTags, ","),
+	}
+
+	for i := range e.RefUpdates {
+		b = append(b, refID(&e.RefUpdates[i]))
+	}
+
+	for i := range e.Approvals {
+		a := &e.Approvals[i]
+		b = append(b, a.Type, a.Value, a.OldValue, a.By.Username, a.By.Email)
+	}
+
+	for _, item := range []*Account{
+		&e.Abandoner, &e.Adder, &e.Author, &e.Changer, &e.Deleter, &e.Editor,
+		&e.OldAssignee, &e.Remover, &e.Restorer, &e.Reviewer, &e.Submitter, &e.Uploader,
+	} {
+		b = append(b, item.Username, item.Email, item.Name)
+	}
+
+	b = append(b, strconv.FormatInt(e.EventCreatedOn, 10))
+
+	h := sha256.Sum256([]byte(strings.Join(b, idSeparator)))
+
+	return hex.EncodeToString(h[:])
+}
+
+func refID(r *RefUpdate) string {
+	return strings.Join([]string{r.Project, r.RefName, r.OldRev, r.NewRev}, idSeparator)
+}
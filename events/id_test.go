@@ -0,0 +1,73 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestID(t *testing.T) {
+	ids := map[string]string{}
+
+	for _, item := range golden {
+		e := Event{}
+		assert.Equal(t, nil, json.Unmarshal(readGolden(t, item.name), &e))
+
+		id := ID(&e)
+		assert.Equal(t, 64, len(id))
+		assert.Equal(t, "", ids[id], item.name)
+		ids[id] = item.name
+
+		// Decoded again, e.g., by another replica
+		c := Event{}
+		_ = json.Unmarshal(readGolden(t, item.name), &c)
+		assert.Equal(t, id, ID(&c))
+	}
+
+	e := Event{Type: EVENTS_COMMENT_ADDED, Author: Account{Username: "ci"}, EventCreatedOn: createdOn}
+	id := ID(&e)
+
+	e.Author.Username = "bot"
+	assert.NotEqual(t, id, ID(&e))
+
+	e.Author.Username = "ci"
+	e.EventCreatedOn++
+	assert.NotEqual(t, id, ID(&e))
+
+	e = Event{Type: EVENTS_REF_UPDATED, RefUpdate: RefUpdate{Project: project, RefName: "refs/heads/main", NewRev: "a"}}
+	id = ID(&e)
+
+	e.RefUpdate.NewRev = "b"
+	assert.NotEqual(t, id, ID(&e))
+}
+
+func TestIDCollision(t *testing.T) {
+	differ := func(name string, fn func(*Event)) {
+		a, b := Event{}, Event{}
+		_ = json.Unmarshal(readGolden(t, name), &a)
+		_ = json.Unmarshal(readGolden(t, name), &b)
+		fn(&b)
+		assert.NotEqual(t, ID(&a), ID(&b), name)
+	}
+
+	differ(EVENTS_REF_REPLICATED, func(e *Event) { e.Ref = "refs/heads/release-1.0" })
+	differ(EVENTS_REF_REPLICATED, func(e *Event) { e.TargetNode = "backup.example.com" })
+	differ(EVENTS_REF_REPLICATED, func(e *Event) { e.Status = "failed" })
+	differ(EVENTS_REF_REPLICATION_DONE, func(e *Event) { e.Ref = "refs/heads/release-1.0" })
+	differ(EVENTS_PROJECT_CREATED, func(e *Event) { e.ProjectName = "platform/tools" })
+	differ(EVENTS_PROJECT_HEAD_UPDATED, func(e *Event) { e.NewHead = "refs/heads/release-1.0" })
+	differ(EVENTS_COMMENT_ADDED, func(e *Event) { e.Comment = "Patch Set 2: Verified+1" })
+	differ(EVENTS_COMMENT_ADDED, func(e *Event) { e.Approvals[0].Value = "1" })
+	differ(EVENTS_HASHTAGS_CHANGED, func(e *Event) { e.Added = []string{"release"} })
+	differ(EVENTS_HASHTAGS_CHANGED, func(e *Event) { e.Removed = nil })
+	differ(EVENTS_TOPIC_CHANGED, func(e *Event) { e.OldTopic = "other" })
+
+	// Volatile fields of the change are left out
+	a, b := Event{}, Event{}
+	_ = json.Unmarshal(readGolden(t, EVENTS_COMMENT_ADDED), &a)
+	_ = json.Unmarshal(readGolden(t, EVENTS_COMMENT_ADDED), &b)
+	b.Change.Status = "MERGED"
+	b.Change.LastUpdate++
+	assert.Equal(t, ID(&a), ID(&b))
+}
@@ -0,0 +1,236 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	defaultName = "events"
+	filePerm    = 0o600
+	renewCount  = 3
+)
+
+type Leader interface {
+	Init(context.Context) error
+	Deinit(context.Context) error
+	Holder() string
+	Run(context.Context, storage.Storage, chan bool) error
+}
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+type leader struct {
+	cfg     *Config
+	file    *os.File
+	held    bool
+	holder  string
+	mutex   sync.Mutex
+	name    string
+	stopped bool
+	storage storage.Storage
+}
+
+func New(_ context.Context, cfg *Config) Leader {
+	return &leader{
+		cfg: cfg,
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+func (l *leader) Init(_ context.Context) error {
+	l.cfg.Logger.Debug("leader: Init")
+
+	l.name = l.cfg.Config.Spec.Leader.Name
+	if l.name == "" {
+		l.name = defaultName
+	}
+
+	// Replicas are told apart by host name, e.g., of the pod, and by process
+	l.holder = l.cfg.Config.Spec.Leader.Holder
+	if l.holder == "" {
+		h, err := os.Hostname()
+		if err != nil {
+			return errors.Wrap(err, "failed to get hostname")
+		}
+		l.holder = fmt.Sprintf("%s-%d", h, os.Getpid())
+	}
+
+	l.mutex.Lock()
+	l.stopped = false
+	l.mutex.Unlock()
+
+	return nil
+}
+
+// Deinit releases the lease if held, for another replica to take over without waiting for it to expire
+func (l *leader) Deinit(ctx context.Context) error {
+	l.cfg.Logger.Debug("leader: Deinit")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.stopped = true
+
+	return l.release(ctx)
+}
+
+func (l *leader) Holder() string {
+	l.cfg.Logger.Debug("leader: Holder")
+
+	return l.holder
+}
+
+// Run sends true to elected once the lease is taken, and false once lost, renewing it three times per lease,
+// or taking the lock file instead if set, e.g., for SQLite on a shared disk
+func (l *leader) Run(ctx context.Context, st storage.Storage, elected chan bool) error {
+	l.cfg.Logger.Debug("leader: Run")
+
+	ttl := time.Duration(l.cfg.Config.Spec.Leader.LeaseSeconds) * time.Second
+
+	// Elected alone when turned off
+	if ttl == 0 {
+		elected <- true
+		<-ctx.Done()
+		return nil
+	}
+
+	l.mutex.Lock()
+	l.storage = st
+	l.mutex.Unlock()
+
+	ticker := time.NewTicker(ttl / renewCount)
+	defer ticker.Stop()
+
+	held := false
+
+	for {
+		ok, stopped := l.acquire(ctx, ttl)
+		if stopped {
+			return nil
+		}
+
+		if ok != held {
+			held = ok
+			select {
+			case elected <- ok:
+			case <-ctx.Done():
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			l.mutex.Lock()
+			_ = l.release(context.WithoutCancel(ctx))
+			l.mutex.Unlock()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *leader) acquire(ctx context.Context, ttl time.Duration) (ok, stopped bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.stopped {
+		return false, true
+	}
+
+	var err error
+
+	if l.cfg.Config.Spec.Leader.LockFile != "" {
+		ok, err = l.acquireFile()
+	} else {
+		ok, err = l.storage.AcquireLease(ctx, l.name, l.holder, ttl)
+	}
+
+	if err != nil {
+		// Stepped down, as the lease may be taken once expired
+		l.cfg.Logger.Error("leader: failed to acquire lease", "error", err)
+		ok = false
+	}
+
+	if ok && !l.held {
+		l.cfg.Logger.Info("leader: elected", "name", l.name, "holder", l.holder)
+	} else if !ok && l.held {
+		l.cfg.Logger.Warn("leader: lost", "name", l.name, "holder", l.holder)
+	}
+
+	l.held = ok
+
+	return ok, false
+}
+
+// acquireFile takes an exclusive lock on the lock file, held until released or the process exits
+func (l *leader) acquireFile() (bool, error) {
+	if l.file != nil {
+		return true, nil
+	}
+
+	fi, err := os.OpenFile(l.cfg.Config.Spec.Leader.LockFile, os.O_CREATE|os.O_RDWR, filePerm)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to open lock file")
+	}
+
+	ok, err := lockFile(fi)
+	if err != nil || !ok {
+		_ = fi.Close()
+		return false, errors.Wrap(err, "failed to lock file")
+	}
+
+	// Written to tell the replica holding it
+	_ = fi.Truncate(0)
+	_, _ = fi.WriteAt([]byte(l.holder+"\n"), 0)
+
+	l.file = fi
+
+	return true, nil
+}
+
+func (l *leader) release(ctx context.Context) error {
+	if !l.held {
+		return nil
+	}
+
+	if l.file != nil {
+		l.held = false
+		err := unlockFile(l.file)
+		_ = l.file.Close()
+		l.file = nil
+		if err != nil {
+			return errors.Wrap(err, "failed to unlock file")
+		}
+		l.cfg.Logger.Info("leader: released", "name", l.cfg.Config.Spec.Leader.LockFile, "holder", l.holder)
+		return nil
+	}
+
+	if l.storage == nil {
+		return nil
+	}
+
+	l.held = false
+
+	if err := l.storage.ReleaseLease(ctx, l.name, l.holder); err != nil {
+		return errors.Wrap(err, "failed to release lease")
+	}
+
+	l.cfg.Logger.Info("leader: released", "name", l.name, "holder", l.holder)
+
+	return nil
+}
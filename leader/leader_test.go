@@ -0,0 +1,201 @@
+package leader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	timeout = 5 * time.Second
+)
+
+func initLeader(holder string) *leader {
+	l := &leader{
+		cfg: DefaultConfig(),
+	}
+
+	l.cfg.Config.Spec.Leader.Holder = holder
+	l.cfg.Config.Spec.Leader.LeaseSeconds = 1
+
+	l.cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "leader",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	_ = l.Init(context.Background())
+
+	return l
+}
+
+func initStorage(t *testing.T, name string) storage.Storage {
+	ctx := context.Background()
+
+	c := storage.DefaultConfig()
+	c.Config = config.Config{}
+	c.Config.Spec.Storage.Sqlite.Filename = name
+
+	c.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "storage",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	s := storage.New(ctx, c)
+	assert.Equal(t, nil, s.Init(ctx))
+
+	t.Cleanup(func() {
+		_ = s.Deinit(ctx)
+	})
+
+	return s
+}
+
+func waitElected(elected chan bool, want bool) bool {
+	select {
+	case ok := <-elected:
+		return ok == want
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestInit(t *testing.T) {
+	l := initLeader("")
+
+	assert.Equal(t, defaultName, l.name)
+	assert.NotEqual(t, "", l.Holder())
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Replicas sharing the database
+	name := filepath.Join(t.TempDir(), "test.db")
+
+	a := initLeader("a")
+	ea := make(chan bool)
+
+	go func() {
+		_ = a.Run(ctx, initStorage(t, name), ea)
+	}()
+
+	assert.Equal(t, true, waitElected(ea, true))
+
+	b := initLeader("b")
+	eb := make(chan bool)
+
+	go func() {
+		_ = b.Run(ctx, initStorage(t, name), eb)
+	}()
+
+	select {
+	case <-eb:
+		assert.Fail(t, "elected while the lease is held")
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	// Taken over once released
+	_ = a.Deinit(ctx)
+	assert.Equal(t, true, waitElected(eb, true))
+}
+
+func TestRunExpired(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	name := filepath.Join(t.TempDir(), "test.db")
+	st := initStorage(t, name)
+
+	// Held by a replica which stopped without releasing it
+	ok, err := st.AcquireLease(ctx, defaultName, "a", time.Second)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	b := initLeader("b")
+	eb := make(chan bool)
+
+	go func() {
+		_ = b.Run(ctx, initStorage(t, name), eb)
+	}()
+
+	assert.Equal(t, true, waitElected(eb, true))
+
+	l, _ := st.ReadLease(ctx, defaultName)
+	assert.Equal(t, "b", l.Holder)
+
+	// Stepped down once taken by another replica, e.g., after a pause longer than the lease
+	_ = st.ReleaseLease(ctx, defaultName, "b")
+
+	ok, _ = st.AcquireLease(ctx, defaultName, "a", time.Minute)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, true, waitElected(eb, false))
+}
+
+func TestRunOff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	l := initLeader("a")
+	l.cfg.Config.Spec.Leader.LeaseSeconds = 0
+
+	elected := make(chan bool, 1)
+	done := make(chan bool)
+
+	go func() {
+		_ = l.Run(ctx, nil, elected)
+		done <- true
+	}()
+
+	assert.Equal(t, true, waitElected(elected, true))
+
+	cancel()
+	<-done
+}
+
+func TestRunLockFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Replicas sharing the disk, with no lease row
+	name := filepath.Join(t.TempDir(), "leader.lock")
+
+	a := initLeader("a")
+	a.cfg.Config.Spec.Leader.LockFile = name
+	ea := make(chan bool)
+
+	go func() {
+		_ = a.Run(ctx, nil, ea)
+	}()
+
+	assert.Equal(t, true, waitElected(ea, true))
+
+	buf, err := os.ReadFile(name)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a\n", string(buf))
+
+	b := initLeader("b")
+	b.cfg.Config.Spec.Leader.LockFile = name
+	eb := make(chan bool)
+
+	go func() {
+		_ = b.Run(ctx, nil, eb)
+	}()
+
+	select {
+	case <-eb:
+		assert.Fail(t, "elected while the lock file is held")
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	// Taken over once unlocked
+	_ = a.Deinit(ctx)
+	assert.Equal(t, true, waitElected(eb, true))
+}
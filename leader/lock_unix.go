@@ -0,0 +1,23 @@
+//go:build !windows
+
+package leader
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile takes an exclusive lock on the file without waiting, and returns whether taken
+func lockFile(fi *os.File) (bool, error) {
+	err := unix.Flock(int(fi.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if err == unix.EWOULDBLOCK {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlockFile(fi *os.File) error {
+	return unix.Flock(int(fi.Fd()), unix.LOCK_UN)
+}
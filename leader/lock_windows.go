@@ -0,0 +1,24 @@
+//go:build windows
+
+package leader
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on the file without waiting, and returns whether taken
+func lockFile(fi *os.File) (bool, error) {
+	err := windows.LockFileEx(windows.Handle(fi.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0, &windows.Overlapped{})
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlockFile(fi *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(fi.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
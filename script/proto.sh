@@ -0,0 +1,6 @@
+#!/bin/bash
+
+go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.35.2
+go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1
+
+protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative api/events.proto
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	nethttp "net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/connect"
+	"github.com/gerrittrigger/events/storage"
+)
+
+type sessionsResult struct {
+	Dropped     int64             `json:"dropped"`
+	Holder      string            `json:"holder,omitempty"`
+	Leader      bool              `json:"leader"`
+	Paused      bool              `json:"paused"`
+	Sessions    []connect.Session `json:"sessions"`
+	Subscribers []subscriberInfo  `json:"subscribers"`
+}
+
+func newSubscriberInfo(api string, id *auth.Identity, remote, rule string) subscriberInfo {
+	info := subscriberInfo{
+		Api:     api,
+		Remote:  remote,
+		Rule:    rule,
+		Started: time.Now().Unix(),
+	}
+
+	if id != nil {
+		info.Identity = id.Name
+	}
+
+	return info
+}
+
+func (s *server) adminHandler(ctx *gin.Context) {
+	if s.cfg.Auth == nil || !s.cfg.Auth.Enabled(ctx) {
+		ctx.AbortWithStatusJSON(nethttp.StatusForbidden, httpError{Code: nethttp.StatusForbidden, Message: "missing auth"})
+		return
+	}
+
+	ctx.Next()
+}
+
+// configHandler renders the effective config, secrets and the userinfo of URLs being masked
+func (s *server) configHandler(ctx *gin.Context) {
+	s.mutex.Lock()
+	c := s.cfg.Config.Redacted()
+	s.mutex.Unlock()
+
+	ctx.YAML(nethttp.StatusOK, c)
+}
+
+func (s *server) sessionsHandler(ctx *gin.Context) {
+	r := sessionsResult{
+		Dropped:     atomic.LoadInt64(&s.dropped),
+		Leader:      atomic.LoadInt32(&s.elected) != 0,
+		Paused:      atomic.LoadInt64(&s.paused) != 0,
+		Sessions:    []connect.Session{},
+		Subscribers: []subscriberInfo{},
+	}
+
+	if s.cfg.Leader != nil {
+		r.Holder = s.cfg.Leader.Holder()
+	}
+
+	if s.cfg.Ssh != nil {
+		r.Sessions = s.cfg.Ssh.Sessions(ctx)
+	}
+
+	s.mutex.Lock()
+	for sub := range s.subscribers {
+		r.Subscribers = append(r.Subscribers, sub.info)
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(r.Subscribers, func(i, j int) bool {
+		return r.Subscribers[i].Started < r.Subscribers[j].Started
+	})
+
+	ctx.JSON(nethttp.StatusOK, r)
+}
+
+// pauseHandler discards the events received from now on, the stream of Gerrit being kept to resume quickly,
+// and is refused without the events-log plugin to replay them
+func (s *server) pauseHandler(ctx *gin.Context) {
+	if !s.replayable() {
+		ctx.JSON(nethttp.StatusConflict, httpError{Code: nethttp.StatusConflict, Message: "missing rest to replay the events once resumed"})
+		return
+	}
+
+	if atomic.CompareAndSwapInt64(&s.paused, 0, time.Now().Unix()) {
+		s.cfg.Logger.Warn("server: ingestion paused")
+	}
+
+	ctx.JSON(nethttp.StatusOK, gin.H{"status": "paused"})
+}
+
+// resumeHandler stores the events received from now on, and replays those discarded since paused
+func (s *server) resumeHandler(ctx *gin.Context) {
+	if since := atomic.SwapInt64(&s.paused, 0); since != 0 {
+		until := time.Now().Unix()
+		s.cfg.Logger.Warn("server: ingestion resumed", "since", since, "until", until)
+		s.replayGap(context.WithoutCancel(ctx), since, until)
+	}
+
+	ctx.JSON(nethttp.StatusOK, gin.H{"status": "resumed"})
+}
+
+// purgeHandler deletes the events as autoclean does, without waiting for its schedule
+func (s *server) purgeHandler(ctx *gin.Context) {
+	n, err := s.cfg.Storage.Purge(ctx)
+	if err != nil {
+		ctx.JSON(nethttp.StatusInternalServerError, httpError{Code: nethttp.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+
+	s.cfg.Logger.Warn("server: events purged", "count", n)
+
+	ctx.JSON(nethttp.StatusOK, gin.H{"status": "purged", "deleted": n})
+}
+
+// reconnectHandler asks the stream of Gerrit to reconnect, as the watchdog does once timed out
+func (s *server) reconnectHandler(ctx *gin.Context) {
+	s.cfg.Logger.Info("server: reconnecting on request")
+	s.reconnect()
+
+	ctx.JSON(nethttp.StatusAccepted, gin.H{"status": "reconnecting"})
+}
+
+// replayHandler replays the events of the window from the events-log plugin, those already stored being skipped
+func (s *server) replayHandler(ctx *gin.Context) {
+	if !s.replayable() {
+		ctx.JSON(nethttp.StatusNotImplemented, httpError{Code: nethttp.StatusNotImplemented, Message: "missing rest"})
+		return
+	}
+
+	since, until, err := s.parseQuery(ctx.Request.URL.Query().Get("q"))
+	if err != nil {
+		ctx.JSON(nethttp.StatusBadRequest, httpError{Code: nethttp.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	if until < since {
+		ctx.JSON(nethttp.StatusBadRequest, httpError{Code: nethttp.StatusBadRequest, Message: "invalid window"})
+		return
+	}
+
+	gap := &storage.Gap{Since: since, Until: until}
+
+	if err := s.cfg.Storage.CreateGap(ctx, gap); err != nil {
+		ctx.JSON(nethttp.StatusInternalServerError, httpError{Code: nethttp.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+
+	if err := s.replayEvent(ctx, gap); err != nil {
+		ctx.JSON(nethttp.StatusBadGateway, httpError{Code: nethttp.StatusBadGateway, Message: err.Error()})
+		return
+	}
+
+	ctx.JSON(nethttp.StatusOK, gin.H{"status": "replayed", "since": since, "until": until})
+}
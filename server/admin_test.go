@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/connect"
+)
+
+const (
+	adminToken = "admin-s3cret"
+	readToken  = "read-s3cret"
+)
+
+func initAdmin(s *server) {
+	ctx := context.Background()
+
+	s.cfg.Config.Spec.Server.Auth.Tokens = []config.Token{
+		{Name: "ci", Token: readToken, Scopes: []string{auth.ScopeRead}},
+		{Name: "ops", Token: adminToken, Scopes: []string{auth.ScopeAdmin}},
+	}
+
+	c := auth.DefaultConfig()
+	c.Config = s.cfg.Config
+	c.Logger = s.cfg.Logger
+
+	s.cfg.Auth = auth.New(ctx, c)
+	_ = s.cfg.Auth.Init(ctx)
+}
+
+func serveAdmin(s *server, method, path, token string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req, _ := nethttp.NewRequest(method, path, nethttp.NoBody)
+	req.Header.Set("Authorization", "Bearer "+token)
+	s.engine.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestAdmin(t *testing.T) {
+	s := initServer(t)
+
+	assert.Equal(t, nethttp.StatusForbidden, serveAdmin(s, "GET", "/admin/sessions", adminToken).Code)
+
+	initAdmin(s)
+
+	assert.Equal(t, nethttp.StatusUnauthorized, serveAdmin(s, "GET", "/admin/sessions", "invalid").Code)
+	assert.Equal(t, nethttp.StatusForbidden, serveAdmin(s, "GET", "/admin/sessions", readToken).Code)
+
+	s.cfg.Config.Spec.Redis.Url = "redis://:s3cret@localhost:6379/0"
+
+	rec := serveAdmin(s, "GET", "/admin/config", adminToken)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Equal(t, true, strings.Contains(rec.Body.String(), "name: ops"))
+	assert.Equal(t, false, strings.Contains(rec.Body.String(), adminToken))
+	assert.Equal(t, false, strings.Contains(rec.Body.String(), "s3cret"))
+	assert.Equal(t, true, strings.Contains(rec.Body.String(), "@localhost:6379/0"))
+
+	// Refused as the events discarded could not be replayed
+	rec = serveAdmin(s, "POST", "/admin/pause", adminToken)
+	assert.Equal(t, nethttp.StatusConflict, rec.Code)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&s.paused))
+
+	rec = serveAdmin(s, "POST", "/admin/reconnect", adminToken)
+	assert.Equal(t, nethttp.StatusAccepted, rec.Code)
+	assert.Equal(t, 1, len(s.reconn))
+
+	rec = serveAdmin(s, "POST", "/admin/purge", adminToken)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Equal(t, `{"deleted":1,"status":"purged"}`, rec.Body.String())
+
+	rec = serveAdmin(s, "POST", "/admin/replay", adminToken)
+	assert.Equal(t, nethttp.StatusNotImplemented, rec.Code)
+}
+
+func TestAdminSessions(t *testing.T) {
+	s := initServer(t)
+
+	initAdmin(s)
+
+	sub := s.subscribe(newSubscriberInfo("http", &auth.Identity{Name: "ci"}, "192.0.2.1", "merged"), nil)
+	defer s.unsubscribe(sub)
+
+	rec := serveAdmin(s, "GET", "/admin/sessions", adminToken)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+
+	var r sessionsResult
+
+	_ = json.Unmarshal(rec.Body.Bytes(), &r)
+	assert.Equal(t, false, r.Paused)
+	assert.Equal(t, []connect.Session{}, r.Sessions)
+	assert.Equal(t, 1, len(r.Subscribers))
+	assert.Equal(t, "http", r.Subscribers[0].Api)
+	assert.Equal(t, "ci", r.Subscribers[0].Identity)
+	assert.Equal(t, "192.0.2.1", r.Subscribers[0].Remote)
+	assert.Equal(t, "merged", r.Subscribers[0].Rule)
+}
+
+func TestAdminIngestion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, srv := initGerrit(t)
+	initAdmin(s)
+
+	// The events-log plugin returns the events of the window, being queried
+	replayed := `{"type":"ref-updated","eventCreatedOn":1672567230}`
+	queried := make(chan string, 10)
+
+	rsrv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		queried <- r.URL.RawQuery
+		_, _ = w.Write([]byte(replayed + "\n"))
+	}))
+
+	defer rsrv.Close()
+
+	c := connect.DefaultRestConfig()
+	c.Config.Spec.Connect.Rest.Url = rsrv.URL
+	s.cfg.Config.Spec.Connect.Rest.Url = rsrv.URL
+	c.Logger = s.cfg.Logger
+	s.cfg.Rest = connect.RestNew(ctx, c)
+
+	err := s.Init(ctx)
+	assert.Equal(t, nil, err)
+
+	go func() {
+		_ = s.Run(ctx)
+	}()
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+
+	var r sessionsResult
+
+	rec := serveAdmin(s, "GET", "/admin/sessions", adminToken)
+	_ = json.Unmarshal(rec.Body.Bytes(), &r)
+	assert.Equal(t, 1, len(r.Sessions))
+	assert.Equal(t, "stream-events", r.Sessions[0].Command)
+
+	assert.Equal(t, nethttp.StatusOK, serveAdmin(s, "POST", "/admin/pause", adminToken).Code)
+
+	rec = serveAdmin(s, "GET", "/admin/sessions", adminToken)
+	_ = json.Unmarshal(rec.Body.Bytes(), &r)
+	assert.Equal(t, true, r.Paused)
+
+	// Discarded while paused, and replayed once resumed
+	srv.Push(event)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, true, waitEvents(s, 0))
+
+	assert.Equal(t, nethttp.StatusOK, serveAdmin(s, "POST", "/admin/resume", adminToken).Code)
+	assert.Equal(t, true, waitEvents(s, 1))
+
+	b, err := s.cfg.Storage.ReadGap(ctx, 0, time.Now().Unix()+1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+
+	srv.Push(event)
+	assert.Equal(t, true, waitEvents(s, 2))
+
+	<-queried
+
+	rec = serveAdmin(s, "POST", "/admin/replay?q=since:2023-01-01+10:00:00+until:2023-01-01+09:00:00", adminToken)
+	assert.Equal(t, nethttp.StatusBadRequest, rec.Code)
+
+	// Replayed events already stored are skipped
+	rec = serveAdmin(s, "POST", "/admin/replay?q=since:2023-01-01+10:00:00+until:2023-01-01+11:00:00", adminToken)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Equal(t, "t1=2023.01.01+10%3A00%3A00&t2=2023.01.01+11%3A00%3A00", <-queried)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, true, waitEvents(s, 2))
+}
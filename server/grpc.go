@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/gerrittrigger/events/api"
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	grpcProto      = "h2"
+	reflectionName = "/grpc.reflection."
+)
+
+type grpcIdentity struct{}
+
+type grpcStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (g *grpcStream) Context() context.Context {
+	return g.ctx
+}
+
+// eventsServer implements the gRPC API on top of the storage and subscribers of the server
+type eventsServer struct {
+	api.UnimplementedEventsServer
+	s *server
+}
+
+func (s *server) initGrpc(_ context.Context) *grpc.Server {
+	s.cfg.Logger.Debug("server: initGrpc")
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	}
+
+	if s.certificate != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.certificate.config(grpcProto))))
+	}
+
+	g := grpc.NewServer(opts...)
+
+	api.RegisterEventsServer(g, &eventsServer{s: s})
+	reflection.Register(g)
+
+	return g
+}
+
+func (s *server) listenGrpc(ctx context.Context) error {
+	s.cfg.Logger.Debug("server: listenGrpc")
+
+	l, err := net.Listen("tcp", ":"+strconv.Itoa(s.cfg.GrpcPort))
+	if err != nil {
+		return errors.Wrap(err, "failed to listen")
+	}
+
+	s.grpc = s.initGrpc(ctx)
+
+	go func() {
+		_ = s.grpc.Serve(l)
+	}()
+
+	return nil
+}
+
+func (s *server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	c, err := s.authenticateGrpc(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(c, req)
+}
+
+func (s *server) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	c, err := s.authenticateGrpc(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &grpcStream{ServerStream: ss, ctx: c})
+}
+
+// authenticateGrpc maps the metadata and the peer certificate to a request to reuse the auth of the HTTP API,
+// reflection being public as the health endpoint
+func (s *server) authenticateGrpc(ctx context.Context, method string) (context.Context, error) {
+	if s.cfg.Auth == nil || !s.cfg.Auth.Enabled(ctx) || strings.HasPrefix(method, reflectionName) {
+		return ctx, nil
+	}
+
+	req := &nethttp.Request{Header: nethttp.Header{}}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, item := range md.Get("authorization") {
+			req.Header.Add("Authorization", item)
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.TLS = &info.State
+		}
+	}
+
+	id, err := s.cfg.Auth.Authenticate(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if !id.HasScope(auth.ScopeRead) {
+		return nil, status.Error(codes.PermissionDenied, "missing scope "+auth.ScopeRead)
+	}
+
+	return context.WithValue(ctx, grpcIdentity{}, id), nil
+}
+
+func (e *eventsServer) Query(ctx context.Context, req *api.QueryRequest) (*api.QueryResponse, error) {
+	e.s.cfg.Logger.Debug("server: Query")
+
+	if req.GetSince() < 0 || req.GetUntil() <= req.GetSince() {
+		return nil, status.Error(codes.InvalidArgument, "invalid date")
+	}
+
+	if err := e.s.validFilter(ctx, req.GetFilter()); err != nil {
+		return nil, err
+	}
+
+	size := int(req.GetPageSize())
+	if size <= 0 || size > storage.BatchSize {
+		size = storage.BatchSize
+	}
+
+	var after uint64
+
+	if req.GetPageToken() != "" {
+		var err error
+		if after, err = strconv.ParseUint(req.GetPageToken(), 10, 64); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	rsp := &api.QueryResponse{}
+
+	// Pages are read until filled up, as the filter may skip events
+	for len(rsp.Records) < size {
+		n := size - len(rsp.Records)
+		b, err := e.s.cfg.Storage.ReadPage(ctx, req.GetSince(), req.GetUntil(), uint(after), n)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		for i := range b {
+			after = uint64(b[i].ID)
+			if r, ok := e.s.grpcRecord(ctx, req.GetFilter(), b[i].ID, b[i].EventBase64); ok {
+				rsp.Records = append(rsp.Records, r)
+			}
+		}
+		if len(b) < n {
+			return rsp, nil
+		}
+	}
+
+	rsp.NextPageToken = strconv.FormatUint(after, 10)
+
+	return rsp, nil
+}
+
+func (e *eventsServer) Subscribe(req *api.SubscribeRequest, stream api.Events_SubscribeServer) error {
+	e.s.cfg.Logger.Debug("server: Subscribe")
+
+	ctx := stream.Context()
+
+	if err := e.s.validFilter(ctx, req.GetFilter()); err != nil {
+		return err
+	}
+
+	var remote string
+
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+
+	id, _ := ctx.Value(grpcIdentity{}).(*auth.Identity)
+
+	// Subscribed before replaying, so that no event is missed in between
+	sub := e.s.subscribe(newSubscriberInfo("grpc", id, remote, req.GetFilter().GetTrigger()), func(_ *events.Event) bool {
+		return true
+	})
+
+	defer e.s.unsubscribe(sub)
+
+	last := uint(req.GetCursor())
+
+	if last != 0 {
+		for {
+			b, err := e.s.cfg.Storage.ReadPage(ctx, 0, math.MaxInt64, last, storage.BatchSize)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			for i := range b {
+				last = b[i].ID
+				if err := e.s.sendRecord(stream, req.GetFilter(), b[i].ID, b[i].EventBase64); err != nil {
+					return err
+				}
+			}
+			if len(b) < storage.BatchSize {
+				break
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case r := <-sub.events:
+			if r.id <= last {
+				continue
+			}
+			last = r.id
+			if err := e.s.sendRecord(stream, req.GetFilter(), r.id, r.result.EventBase64); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *server) sendRecord(stream api.Events_SubscribeServer, filter *api.Filter, id uint, data string) error {
+	r, ok := s.grpcRecord(stream.Context(), filter, id, data)
+	if !ok {
+		return nil
+	}
+
+	return stream.Send(r)
+}
+
+func (s *server) validFilter(ctx context.Context, filter *api.Filter) error {
+	if filter.GetTrigger() != "" && !s.hasRule(ctx, filter.GetTrigger()) {
+		return status.Error(codes.InvalidArgument, "invalid rule")
+	}
+
+	return nil
+}
+
+// grpcRecord applies the visibility of the caller, and then the filter, to the stored event
+func (s *server) grpcRecord(ctx context.Context, filter *api.Filter, id uint, data string) (*api.Record, bool) {
+	var i *auth.Identity
+
+	if v, ok := ctx.Value(grpcIdentity{}).(*auth.Identity); ok {
+		i = v
+	}
+
+	r, ok := s.filterEvent(ctx, i, httpResult{EventBase64: data})
+	if !ok {
+		return nil, false
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(r.EventBase64)
+	if err != nil {
+		return nil, false
+	}
+
+	e := events.Event{}
+
+	if err := json.Unmarshal(buf, &e); err != nil {
+		return nil, false
+	}
+
+	if !s.matchFilter(ctx, filter, buf, &e) {
+		return nil, false
+	}
+
+	return &api.Record{Id: uint64(id), Event: api.NewEvent(&e), Raw: buf}, true
+}
+
+func (s *server) matchFilter(ctx context.Context, filter *api.Filter, data []byte, event *events.Event) bool {
+	if len(filter.GetTypes()) != 0 && !contains(filter.GetTypes(), event.Type) {
+		return false
+	}
+
+	if len(filter.GetProjects()) != 0 {
+		t, err := events.Decode(data)
+		if err != nil || !contains(filter.GetProjects(), t.Project()) {
+			return false
+		}
+	}
+
+	if filter.GetTrigger() != "" {
+		if ok, _ := s.cfg.Trigger.Match(ctx, filter.GetTrigger(), event); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func contains(items []string, name string) bool {
+	for _, item := range items {
+		if item == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gerrittrigger/events/api"
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/storage"
+)
+
+const (
+	bufSize = 1 << 20
+)
+
+func initGrpc(t *testing.T, s *server) *grpc.ClientConn {
+	l := bufconn.Listen(bufSize)
+
+	g := s.initGrpc(context.Background())
+
+	go func() {
+		_ = g.Serve(l)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Equal(t, nil, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		g.Stop()
+	})
+
+	return conn
+}
+
+func initRecords(s *server) {
+	merged := `{"type":"ref-updated","refUpdate":{"project":"platform/build"},"eventCreatedOn":1672567201}`
+	created := `{"type":"patchset-created","change":{"project":"platform/tools","number":1},"eventCreatedOn":1672567202}`
+
+	_ = s.cfg.Storage.Create(context.Background(), []storage.Model{
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(merged)), EventCreatedOn: 1672567201},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(created)), EventCreatedOn: 1672567202},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(event)), EventCreatedOn: 1672567203},
+	})
+}
+
+func TestGrpcQuery(t *testing.T) {
+	ctx := context.Background()
+	s := initServer(t)
+	initRecords(s)
+	initTrigger(s)
+
+	c := api.NewEventsClient(initGrpc(t, s))
+
+	_, err := c.Query(ctx, &api.QueryRequest{Since: 1672567201, Until: 1672567200})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, PageToken: "invalid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, Filter: &api.Filter{Trigger: "invalid"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The stored event which failed to decode is skipped
+	rsp, err := c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, PageSize: 2})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(rsp.GetRecords()))
+	assert.Equal(t, events.EVENTS_REF_UPDATED, rsp.GetRecords()[0].GetEvent().GetType())
+	assert.Equal(t, "platform/build", rsp.GetRecords()[0].GetEvent().GetRefUpdate().GetProject())
+	assert.Equal(t, int64(1), rsp.GetRecords()[1].GetEvent().GetChange().GetNumber())
+	assert.NotEqual(t, "", rsp.GetNextPageToken())
+
+	rsp, err = c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, PageSize: 2, PageToken: rsp.GetNextPageToken()})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(rsp.GetRecords()))
+	assert.Equal(t, event, string(rsp.GetRecords()[0].GetRaw()))
+	assert.Equal(t, "", rsp.GetNextPageToken())
+
+	rsp, _ = c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, Filter: &api.Filter{Projects: []string{"platform/tools"}}})
+	assert.Equal(t, 1, len(rsp.GetRecords()))
+	assert.Equal(t, events.EVENTS_PATCHSET_CREATED, rsp.GetRecords()[0].GetEvent().GetType())
+
+	rsp, _ = c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, Filter: &api.Filter{Types: []string{events.EVENTS_REF_UPDATED}}})
+	assert.Equal(t, 2, len(rsp.GetRecords()))
+
+	rsp, _ = c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, PageSize: 1, Filter: &api.Filter{Trigger: "merged"}})
+	assert.Equal(t, 1, len(rsp.GetRecords()))
+	assert.Equal(t, "platform/build", rsp.GetRecords()[0].GetEvent().GetRefUpdate().GetProject())
+
+	rsp, _ = c.Query(ctx, &api.QueryRequest{Since: 1672567200, Until: 1672567300, PageSize: 1, Filter: &api.Filter{Trigger: "merged"},
+		PageToken: rsp.GetNextPageToken()})
+	assert.Equal(t, 0, len(rsp.GetRecords()))
+	assert.Equal(t, "", rsp.GetNextPageToken())
+}
+
+func TestGrpcSubscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s := initServer(t)
+	initRecords(s)
+
+	c := api.NewEventsClient(initGrpc(t, s))
+
+	b, _ := s.cfg.Storage.ReadPage(ctx, 0, 1672567300, 0, storage.BatchSize)
+
+	stream, err := c.Subscribe(ctx, &api.SubscribeRequest{Cursor: uint64(b[1].ID), Filter: &api.Filter{Types: []string{events.EVENTS_REF_UPDATED}}})
+	assert.Equal(t, nil, err)
+
+	r, err := stream.Recv()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(b[len(b)-1].ID), r.GetId())
+	assert.Equal(t, event, string(r.GetRaw()))
+
+	// Published events already replayed are skipped
+	s.publishEvent(&events.Event{}, &b[len(b)-1])
+
+	live := `{"type":"ref-updated","refUpdate":{"project":"platform/live"},"eventCreatedOn":1672567204}`
+	m := []storage.Model{{EventBase64: base64.StdEncoding.EncodeToString([]byte(live)), EventCreatedOn: 1672567204}}
+	_ = s.cfg.Storage.Create(ctx, m)
+	s.publishEvent(&events.Event{}, &m[0])
+
+	r, err = stream.Recv()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(m[0].ID), r.GetId())
+	assert.Equal(t, "platform/live", r.GetEvent().GetRefUpdate().GetProject())
+}
+
+func TestGrpcAuth(t *testing.T) {
+	ctx := context.Background()
+	s := initServer(t)
+	initRecords(s)
+
+	ac := auth.DefaultConfig()
+	ac.Config.Spec.Server.Auth.Tokens = []config.Token{
+		{Name: "ci", Token: "read", Scopes: []string{auth.ScopeRead}},
+		{Name: "none", Token: "none"},
+	}
+	ac.Logger = s.cfg.Logger
+
+	s.cfg.Auth = auth.New(ctx, ac)
+	_ = s.cfg.Auth.Init(ctx)
+
+	conn := initGrpc(t, s)
+	c := api.NewEventsClient(conn)
+	req := &api.QueryRequest{Since: 1672567200, Until: 1672567300}
+
+	_, err := c.Query(ctx, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = c.Query(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer none"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	rsp, err := c.Query(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer read"), req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(rsp.GetRecords()))
+
+	stream, _ := c.Subscribe(ctx, &api.SubscribeRequest{})
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Reflection is public
+	rc, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	assert.Equal(t, nil, err)
+	_ = rc.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	})
+	rr, err := rc.Recv()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "events.v1.Events", rr.GetListServicesResponse().GetService()[0].GetName())
+}
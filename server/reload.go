@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	nethttp "net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+)
+
+type reloader interface {
+	Reload(context.Context, *config.Config) error
+}
+
+// Reload applies the config to the running components, and restores the previous one if any of them rejects it
+func (s *server) Reload(ctx context.Context, cfg *config.Config) error {
+	s.cfg.Logger.Debug("server: Reload")
+
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	s.mutex.Lock()
+	old := s.cfg.Config
+	s.mutex.Unlock()
+
+	names, items := s.reloaders()
+
+	for i := range items {
+		if err := items[i].Reload(ctx, cfg); err != nil {
+			for j := 0; j < i; j++ {
+				_ = items[j].Reload(ctx, &old)
+			}
+			return errors.Wrap(err, "failed to reload "+names[i])
+		}
+	}
+
+	s.mutex.Lock()
+	s.cfg.Config = *cfg
+	s.mutex.Unlock()
+
+	s.setLevel(cfg)
+
+	if fields := restartFields(&old, cfg); len(fields) != 0 {
+		s.cfg.Logger.Warn("server: restart required to apply", "fields", strings.Join(fields, ","))
+	}
+
+	// Changes of the target or credentials of Gerrit are applied by reconnecting, the stream being started again
+	if old.Spec.Connect.Hostname != cfg.Spec.Connect.Hostname || old.Spec.Connect.Ssh != cfg.Spec.Connect.Ssh {
+		s.cfg.Logger.Info("server: reconnecting to apply", "hostname", cfg.Spec.Connect.Hostname)
+		s.reconnect()
+	}
+
+	s.cfg.Logger.Info("server: config reloaded")
+
+	return nil
+}
+
+// reloaders lists the components, those reading files first so that they fail before the others are reloaded
+func (s *server) reloaders() ([]string, []reloader) {
+	var names []string
+	var items []reloader
+
+	add := func(name string, item reloader, ok bool) {
+		if ok {
+			names = append(names, name)
+			items = append(items, item)
+		}
+	}
+
+	add("ssh", s.cfg.Ssh, s.cfg.Ssh != nil)
+	add("auth", s.cfg.Auth, s.cfg.Auth != nil)
+	add("rest", s.cfg.Rest, s.cfg.Rest != nil)
+	add("trigger", s.cfg.Trigger, s.cfg.Trigger != nil)
+	add("visibility", s.cfg.Visibility, s.cfg.Visibility != nil)
+	add("storage", s.cfg.Storage, s.cfg.Storage != nil)
+	add("watchdog", s.cfg.Watchdog, s.cfg.Watchdog != nil)
+
+	return names, items
+}
+
+// reconnect asks fetchEvent to reconnect to Gerrit, unless already asked
+func (s *server) reconnect() {
+	select {
+	case s.reconn <- true:
+	default:
+	}
+}
+
+// setLevel sets the log level of the config, or the one on start if unset
+func (s *server) setLevel(cfg *config.Config) {
+	level := s.level
+
+	if cfg.Spec.Log.Level != "" {
+		level = hclog.LevelFromString(cfg.Spec.Log.Level)
+	}
+
+	if level != hclog.NoLevel {
+		s.cfg.Logger.SetLevel(level)
+	}
+}
+
+// restartFields lists the changed settings which are only read on start
+func restartFields(old, cfg *config.Config) []string {
+	var b []string
+
+	if !reflect.DeepEqual(old.Spec.Exec, cfg.Spec.Exec) {
+		b = append(b, "spec.exec")
+	}
+
+	if old.Spec.File != cfg.Spec.File {
+		b = append(b, "spec.file")
+	}
+
+	if old.Spec.Leader != cfg.Spec.Leader {
+		b = append(b, "spec.leader")
+	}
+
+	if old.Spec.Nats != cfg.Spec.Nats {
+		b = append(b, "spec.nats")
+	}
+
+	if old.Spec.Redis != cfg.Spec.Redis {
+		b = append(b, "spec.redis")
+	}
+
+	if !reflect.DeepEqual(old.Spec.Server.Cors, cfg.Spec.Server.Cors) {
+		b = append(b, "spec.server.cors")
+	}
+
+	// Clients are reloaded by auth
+	o, n := old.Spec.Server.Tls, cfg.Spec.Server.Tls
+	o.Clients, n.Clients = nil, nil
+
+	if !reflect.DeepEqual(o, n) {
+		b = append(b, "spec.server.tls")
+	}
+
+	if old.Spec.Storage.Sqlite != cfg.Spec.Storage.Sqlite {
+		b = append(b, "spec.storage.sqlite")
+	}
+
+	return b
+}
+
+func (s *server) reloadHandler(ctx *gin.Context) {
+	if s.cfg.Load == nil {
+		ctx.JSON(nethttp.StatusNotImplemented, httpError{Code: nethttp.StatusNotImplemented, Message: "missing config file"})
+		return
+	}
+
+	cfg, err := s.cfg.Load(ctx)
+	if err != nil {
+		ctx.JSON(nethttp.StatusBadRequest, httpError{Code: nethttp.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	// Components keep the context, e.g., to autoclean, after the request
+	if err := s.Reload(context.WithoutCancel(ctx), cfg); err != nil {
+		ctx.JSON(nethttp.StatusInternalServerError, httpError{Code: nethttp.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+
+	ctx.JSON(nethttp.StatusOK, gin.H{"status": "reloaded"})
+}
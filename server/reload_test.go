@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/connect/gerrittest"
+	"github.com/gerrittrigger/events/visibility"
+)
+
+func TestReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, srv := initGerrit(t)
+	initTrigger(s)
+
+	vc := visibility.DefaultConfig()
+	vc.Logger = s.cfg.Logger
+	s.cfg.Visibility = visibility.New(ctx, vc)
+
+	err := s.Init(ctx)
+	assert.Equal(t, nil, err)
+
+	go func() {
+		_ = s.Run(ctx)
+	}()
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+
+	c := s.cfg.Config
+	c.Spec.Log.Level = "ERROR"
+	c.Spec.Trigger.Rules = []config.Rule{{Name: "build"}}
+
+	err = s.Reload(ctx, &c)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, s.hasRule(ctx, "build"))
+	assert.Equal(t, false, s.hasRule(ctx, "merged"))
+	assert.Equal(t, hclog.Error, s.cfg.Logger.GetLevel())
+
+	// Rejected by visibility once trigger is reloaded, which is restored
+	invalid := c
+	invalid.Spec.Server.Visibility.Private = "invalid"
+	invalid.Spec.Trigger.Rules = []config.Rule{{Name: "other"}}
+
+	err = s.Reload(ctx, &invalid)
+	assert.Equal(t, "failed to reload visibility: invalid action invalid", err.Error())
+	assert.Equal(t, true, s.hasRule(ctx, "build"))
+	assert.Equal(t, false, s.hasRule(ctx, "other"))
+	assert.Equal(t, c, s.cfg.Config)
+
+	invalid = c
+	invalid.Spec.Connect.Ssh.Keyfile = "invalid"
+
+	err = s.Reload(ctx, &invalid)
+	assert.NotEqual(t, nil, err)
+
+	// Reconnected with the credentials reloaded
+	c.Spec.Connect.Ssh.Username = "invalid"
+
+	err = s.Reload(ctx, &c)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, waitStreams(srv, 0))
+
+	c.Spec.Connect.Ssh.Username = gerrittest.Username
+	c.Spec.Log.Level = ""
+
+	err = s.Reload(ctx, &c)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, waitStreams(srv, 1))
+	assert.Equal(t, hclog.Info, s.cfg.Logger.GetLevel())
+
+	srv.Push(event)
+	assert.Equal(t, true, waitEvents(s, 1))
+}
+
+func TestReloadHandler(t *testing.T) {
+	s := initServer(t)
+
+	assert.Equal(t, nethttp.StatusForbidden, serveAdmin(s, "POST", "/admin/reload", adminToken).Code)
+
+	initAdmin(s)
+
+	assert.Equal(t, nethttp.StatusForbidden, serveAdmin(s, "POST", "/admin/reload", readToken).Code)
+	assert.Equal(t, nethttp.StatusNotImplemented, serveAdmin(s, "POST", "/admin/reload", adminToken).Code)
+
+	c := s.cfg.Config
+	c.Spec.Storage.Sqlite.Filename = name
+
+	var load error
+
+	s.cfg.Load = func(context.Context) (*config.Config, error) {
+		return &c, load
+	}
+
+	load = errors.New("line 1: kind: invalid")
+
+	rec := serveAdmin(s, "POST", "/admin/reload", adminToken)
+	assert.Equal(t, nethttp.StatusBadRequest, rec.Code)
+
+	var e httpError
+
+	_ = json.Unmarshal(rec.Body.Bytes(), &e)
+	assert.Equal(t, "line 1: kind: invalid", e.Message)
+
+	load = nil
+	c.Spec.Storage.Autoclean = "@daily"
+
+	assert.Equal(t, nethttp.StatusOK, serveAdmin(s, "POST", "/admin/reload", adminToken).Code)
+	assert.Equal(t, "@daily", s.cfg.Config.Spec.Storage.Autoclean)
+}
@@ -4,22 +4,32 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"io"
+	"math"
 	nethttp "net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-contrib/cors"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/go-hclog"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 
+	"github.com/gerrittrigger/events/auth"
 	"github.com/gerrittrigger/events/config"
 	"github.com/gerrittrigger/events/connect"
 	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/leader"
 	"github.com/gerrittrigger/events/queue"
+	"github.com/gerrittrigger/events/sink"
 	"github.com/gerrittrigger/events/storage"
+	"github.com/gerrittrigger/events/trigger"
+	"github.com/gerrittrigger/events/visibility"
 	"github.com/gerrittrigger/events/watchdog"
 )
 
@@ -34,22 +44,49 @@ const (
 	maxDuration = 10 * time.Second
 	maxHeader   = 1 << 20
 	waitCount   = 2
+
+	followPeriod = time.Second
+	replayPeriod = time.Minute
+	storeRetries = 5
+	replayWindow = time.Hour
+	recentSize   = 1000
+
+	streamBuffer = 100
+	streamEvent  = "message"
+
+	identityKey = "identity"
+	lastEventID = "Last-Event-ID"
+)
+
+var (
+	// storeBackoff before retrying the storage failing, doubled on each retry
+	storeBackoff = time.Second
 )
 
 type Server interface {
 	Init(context.Context) error
 	Deinit(context.Context) error
 	Run(context.Context) error
+	Reload(context.Context, *config.Config) error
+	Handler() nethttp.Handler
 }
 
 type Config struct {
-	Config   config.Config
-	Logger   hclog.Logger
-	Port     int
-	Queue    queue.Queue
-	Ssh      connect.Ssh
-	Storage  storage.Storage
-	Watchdog watchdog.Watchdog
+	Auth       auth.Auth
+	Config     config.Config
+	GrpcPort   int
+	Leader     leader.Leader
+	Load       func(context.Context) (*config.Config, error)
+	Logger     hclog.Logger
+	Port       int
+	Queue      queue.Queue
+	Rest       connect.Rest
+	Sink       sink.Dispatcher
+	Ssh        connect.Ssh
+	Storage    storage.Storage
+	Trigger    trigger.Trigger
+	Visibility visibility.Visibility
+	Watchdog   watchdog.Watchdog
 }
 
 type httpError struct {
@@ -62,15 +99,53 @@ type httpResult struct {
 	EventCreatedOn int64  `json:"eventCreatedOn"`
 }
 
+type subscriber struct {
+	filter func(*events.Event) bool
+	events chan streamResult
+	info   subscriberInfo
+}
+
+// subscriberInfo - Client streaming events, listed by the admin API
+type subscriberInfo struct {
+	Api      string `json:"api"`
+	Identity string `json:"identity"`
+	Remote   string `json:"remote"`
+	Rule     string `json:"rule,omitempty"`
+	Started  int64  `json:"started"`
+}
+
+type streamResult struct {
+	id     uint
+	result httpResult
+}
+
 type server struct {
-	cfg    *Config
-	engine *gin.Engine
+	cfg         *Config
+	certificate *certificate
+	engine      *gin.Engine
+	grpc        *grpc.Server
+	dropped     int64
+	elected     int32
+	last        int64
+	level       hclog.Level
+	mutex       sync.Mutex
+	paused      int64
+	published   uint
+	recent      map[string]bool
+	recentIDs   []string
+	recentPos   int
+	reconn      chan bool
+	replaying   map[uint]bool
+	reload      sync.Mutex
+	subscribers map[*subscriber]bool
 }
 
 func New(_ context.Context, cfg *Config) Server {
 	return &server{
-		cfg:    cfg,
-		engine: nil,
+		cfg:         cfg,
+		engine:      nil,
+		reconn:      make(chan bool, 1),
+		subscribers: map[*subscriber]bool{},
 	}
 }
 
@@ -81,6 +156,15 @@ func DefaultConfig() *Config {
 func (s *server) Init(ctx context.Context) error {
 	s.cfg.Logger.Debug("server: Init")
 
+	s.level = s.cfg.Logger.GetLevel()
+	s.setLevel(&s.cfg.Config)
+
+	if s.cfg.Auth != nil {
+		if err := s.cfg.Auth.Init(ctx); err != nil {
+			return errors.Wrap(err, "failed to init auth")
+		}
+	}
+
 	if err := s.cfg.Queue.Init(ctx); err != nil {
 		return errors.Wrap(err, "failed to init queue")
 	}
@@ -89,14 +173,53 @@ func (s *server) Init(ctx context.Context) error {
 		return errors.Wrap(err, "failed to init ssh")
 	}
 
+	if s.cfg.Rest != nil {
+		if err := s.cfg.Rest.Init(ctx); err != nil {
+			return errors.Wrap(err, "failed to init rest")
+		}
+	}
+
 	if err := s.cfg.Storage.Init(ctx); err != nil {
 		return errors.Wrap(err, "failed to init storage")
 	}
 
+	if s.cfg.Sink != nil {
+		if err := s.cfg.Sink.Init(ctx); err != nil {
+			return errors.Wrap(err, "failed to init sink")
+		}
+	}
+
+	if s.cfg.Trigger != nil {
+		if err := s.cfg.Trigger.Init(ctx); err != nil {
+			return errors.Wrap(err, "failed to init trigger")
+		}
+	}
+
+	if s.cfg.Visibility != nil {
+		if err := s.cfg.Visibility.Init(ctx); err != nil {
+			return errors.Wrap(err, "failed to init visibility")
+		}
+	}
+
 	if err := s.cfg.Watchdog.Init(ctx); err != nil {
 		return errors.Wrap(err, "failed to init watchdog")
 	}
 
+	if s.cfg.Leader != nil {
+		if err := s.cfg.Leader.Init(ctx); err != nil {
+			return errors.Wrap(err, "failed to init leader")
+		}
+	}
+
+	if s.cfg.Config.Spec.Server.Tls.CertFile != "" {
+		c, err := newCertificate(s.cfg.Config.Spec.Server.Tls, s.cfg.Logger)
+		if err != nil {
+			return errors.Wrap(err, "failed to load certificate")
+		}
+		s.certificate = c
+		go c.watch(ctx)
+	}
+
 	if err := s.initHttp(ctx); err != nil {
 		return errors.Wrap(err, "failed to init http")
 	}
@@ -105,59 +228,107 @@ func (s *server) Init(ctx context.Context) error {
 		return errors.Wrap(err, "failed to listen http")
 	}
 
+	if s.cfg.GrpcPort != 0 {
+		if err := s.listenGrpc(ctx); err != nil {
+			return errors.Wrap(err, "failed to listen grpc")
+		}
+	}
+
 	return nil
 }
 
 func (s *server) Deinit(ctx context.Context) error {
 	s.cfg.Logger.Debug("server: Deinit")
 
+	if s.grpc != nil {
+		s.grpc.Stop()
+	}
+
+	// Released before the storage is closed
+	if s.cfg.Leader != nil {
+		_ = s.cfg.Leader.Deinit(ctx)
+	}
+
 	_ = s.cfg.Watchdog.Deinit(ctx)
+
+	if s.cfg.Visibility != nil {
+		_ = s.cfg.Visibility.Deinit(ctx)
+	}
+
+	if s.cfg.Trigger != nil {
+		_ = s.cfg.Trigger.Deinit(ctx)
+	}
+
+	if s.cfg.Sink != nil {
+		_ = s.cfg.Sink.Deinit(ctx)
+	}
+
 	_ = s.cfg.Storage.Deinit(ctx)
+
+	if s.cfg.Rest != nil {
+		_ = s.cfg.Rest.Deinit(ctx)
+	}
+
 	_ = s.cfg.Ssh.Deinit(ctx)
 	_ = s.cfg.Queue.Deinit(ctx)
 
+	if s.cfg.Auth != nil {
+		_ = s.cfg.Auth.Deinit(ctx)
+	}
+
 	return nil
 }
 
 func (s *server) Run(ctx context.Context) error {
 	s.cfg.Logger.Debug("server: Run")
 
-	var err error
+	var putErr, storeErr error
 	var wg sync.WaitGroup
 
 	buf := make(chan string)
 
 	go func(c context.Context, b chan string) {
-		s.fetchEvent(c, b)
+		s.leadEvent(c, b)
 	}(ctx, buf)
 
 	wg.Add(waitCount)
 
 	go func(c context.Context, b chan string) {
 		defer wg.Done()
-		for item := range b {
-			err = s.cfg.Queue.Put(c, item)
-			if err != nil {
+		for {
+			var item string
+			select {
+			case <-c.Done():
+				return
+			case item = <-b:
+			}
+			// Events received while paused are discarded, and replayed once resumed via the events-log plugin
+			if atomic.LoadInt64(&s.paused) != 0 {
+				continue
+			}
+			if putErr = s.cfg.Queue.Put(c, item); putErr != nil {
 				return
 			}
 		}
 	}(ctx, buf)
 
-	if err != nil {
-		return errors.Wrap(err, "failed to put queue")
-	}
-
 	go func(ctx context.Context) {
 		defer wg.Done()
-		err = s.storeEvent(ctx)
-		if err != nil {
-			return
-		}
+		storeErr = s.storeEvent(ctx)
 	}(ctx)
 
 	wg.Wait()
 
-	return err
+	if putErr != nil {
+		return errors.Wrap(putErr, "failed to put queue")
+	}
+
+	return storeErr
+}
+
+// Handler serves the HTTP API once initialized, e.g., to be embedded or tested with httptest
+func (s *server) Handler() nethttp.Handler {
+	return s.engine
 }
 
 func (s *server) initHttp(_ context.Context) error {
@@ -170,7 +341,7 @@ func (s *server) initHttp(_ context.Context) error {
 			ctx.JSON(nethttp.StatusNotFound, httpError{Code: nethttp.StatusNotFound, Message: err.Error()})
 			return
 		}
-		ctx.JSON(nethttp.StatusOK, b)
+		ctx.JSON(nethttp.StatusOK, s.filterEvents(ctx, s.identity(ctx), b))
 	}
 
 	s.engine = gin.New()
@@ -178,27 +349,312 @@ func (s *server) initHttp(_ context.Context) error {
 		return errors.New("failed to create gin")
 	}
 
-	s.engine.Use(cors.New(cors.Config{
-		AllowCredentials: true,
-		AllowHeaders:     []string{"*"},
-		AllowMethods:     []string{"GET"},
-		AllowOrigins:     []string{"*"},
-		AllowOriginFunc: func(origin string) bool {
-			return true
-		},
-		ExposeHeaders: []string{"Content-Type"},
-		MaxAge:        maxAge,
-	}))
+	s.engine.Use(cors.New(s.corsConfig()))
 
 	s.engine.Use(gin.Logger())
 	s.engine.Use(gin.Recovery())
 
-	e := s.engine.Group("/events")
+	s.engine.GET("/health", func(ctx *gin.Context) {
+		ctx.JSON(nethttp.StatusOK, gin.H{"status": "ok"})
+	})
+
+	r := s.engine.Group("/", s.authHandler(auth.ScopeRead))
+
+	e := r.Group("/events")
 	e.GET("/", handler)
+	e.GET("/stream", s.streamHandler)
+
+	t := r.Group("/triggers")
+	t.GET("/", s.triggersHandler)
+	t.GET("/:name/events/", s.triggerHandler)
+	t.GET("/:name/stream", s.streamHandler)
+
+	a := s.engine.Group("/admin", s.adminHandler, s.authHandler(auth.ScopeAdmin))
+	a.GET("/config", s.configHandler)
+	a.GET("/sessions", s.sessionsHandler)
+	a.POST("/pause", s.pauseHandler)
+	a.POST("/purge", s.purgeHandler)
+	a.POST("/reconnect", s.reconnectHandler)
+	a.POST("/reload", s.reloadHandler)
+	a.POST("/replay", s.replayHandler)
+	a.POST("/resume", s.resumeHandler)
 
 	return nil
 }
 
+func (s *server) corsConfig() cors.Config {
+	c := cors.Config{
+		AllowHeaders:  []string{"Authorization", "Content-Type", "Last-Event-ID"},
+		AllowMethods:  []string{"GET", "POST"},
+		ExposeHeaders: []string{"Content-Type"},
+		MaxAge:        maxAge,
+	}
+
+	// Credentials are only sent to the origins allowed explicitly
+	if origins := s.cfg.Config.Spec.Server.Cors.AllowOrigins; len(origins) != 0 {
+		c.AllowOrigins = origins
+		c.AllowCredentials = true
+	} else {
+		c.AllowAllOrigins = true
+	}
+
+	return c
+}
+
+func (s *server) authHandler(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.cfg.Auth == nil || !s.cfg.Auth.Enabled(ctx) {
+			ctx.Next()
+			return
+		}
+
+		id, err := s.cfg.Auth.Authenticate(ctx, ctx.Request)
+		if err != nil {
+			ctx.Header("WWW-Authenticate", `Basic realm="events"`)
+			ctx.AbortWithStatusJSON(nethttp.StatusUnauthorized, httpError{Code: nethttp.StatusUnauthorized, Message: err.Error()})
+			return
+		}
+
+		if !id.HasScope(scope) {
+			ctx.AbortWithStatusJSON(nethttp.StatusForbidden, httpError{Code: nethttp.StatusForbidden, Message: "missing scope " + scope})
+			return
+		}
+
+		ctx.Set(identityKey, id)
+		ctx.Next()
+	}
+}
+
+func (s *server) triggersHandler(ctx *gin.Context) {
+	if s.cfg.Trigger == nil {
+		ctx.JSON(nethttp.StatusOK, []string{})
+		return
+	}
+
+	ctx.JSON(nethttp.StatusOK, s.cfg.Trigger.Rules(ctx))
+}
+
+func (s *server) triggerHandler(ctx *gin.Context) {
+	name := ctx.Param("name")
+	if !s.hasRule(ctx, name) {
+		ctx.JSON(nethttp.StatusNotFound, httpError{Code: nethttp.StatusNotFound, Message: "invalid rule"})
+		return
+	}
+
+	q := ctx.Request.URL.Query().Get("q")
+
+	b, err := s.queryTrigger(ctx, name, q)
+	if err != nil {
+		ctx.JSON(nethttp.StatusNotFound, httpError{Code: nethttp.StatusNotFound, Message: err.Error()})
+		return
+	}
+
+	ctx.JSON(nethttp.StatusOK, s.filterEvents(ctx, s.identity(ctx), b))
+}
+
+// streamHandler streams the events matched by the rule, or all of them without, e.g., /events/stream
+func (s *server) streamHandler(ctx *gin.Context) {
+	name := ctx.Param("name")
+	if name != "" && !s.hasRule(ctx, name) {
+		ctx.JSON(nethttp.StatusNotFound, httpError{Code: nethttp.StatusNotFound, Message: "invalid rule"})
+		return
+	}
+
+	id := s.identity(ctx)
+
+	sub := s.subscribe(newSubscriberInfo("http", id, ctx.ClientIP(), name), func(e *events.Event) bool {
+		return s.matchRule(ctx, name, e)
+	})
+
+	defer s.unsubscribe(sub)
+
+	// Streams outlive the write timeout of the server
+	_ = nethttp.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Status(nethttp.StatusOK)
+
+	// Reconnecting clients resume after the last event received, subscribed before replaying so that none is missed
+	last, _ := strconv.ParseUint(ctx.GetHeader(lastEventID), 10, 64)
+	if last != 0 {
+		last = uint64(s.replayStream(ctx, name, id, uint(last)))
+	}
+
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(_ io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case r := <-sub.events:
+			if uint64(r.id) <= last {
+				return true
+			}
+			if b, ok := s.filterEvent(ctx, id, r.result); ok {
+				ctx.Render(-1, sse.Event{Id: strconv.FormatUint(uint64(r.id), 10), Event: streamEvent, Data: b})
+			}
+			return true
+		}
+	})
+}
+
+// replayStream renders the stored events matched by the rule after the ID, and returns the ID of the last one read
+// matchRule returns whether the event is matched by the rule, any event being matched without
+func (s *server) matchRule(ctx context.Context, name string, e *events.Event) bool {
+	if name == "" {
+		return true
+	}
+
+	ok, _ := s.cfg.Trigger.Match(ctx, name, e)
+
+	return ok
+}
+
+func (s *server) replayStream(ctx *gin.Context, name string, id *auth.Identity, last uint) uint {
+	for {
+		b, err := s.cfg.Storage.ReadPage(ctx, 0, math.MaxInt64, last, storage.BatchSize)
+		if err != nil {
+			s.cfg.Logger.Error("server: failed to replay stream", "error", err)
+			return last
+		}
+		for i := range b {
+			last = b[i].ID
+			buf, err := base64.StdEncoding.DecodeString(b[i].EventBase64)
+			if err != nil {
+				continue
+			}
+			e := events.Event{}
+			if err := json.Unmarshal(buf, &e); err != nil {
+				continue
+			}
+			if !s.matchRule(ctx, name, &e) {
+				continue
+			}
+			r := httpResult{EventBase64: b[i].EventBase64, EventCreatedOn: b[i].EventCreatedOn}
+			if r, ok := s.filterEvent(ctx, id, r); ok {
+				ctx.Render(-1, sse.Event{Id: strconv.FormatUint(uint64(b[i].ID), 10), Event: streamEvent, Data: r})
+			}
+		}
+		if len(b) < storage.BatchSize {
+			return last
+		}
+	}
+}
+
+func (s *server) identity(ctx *gin.Context) *auth.Identity {
+	if v, ok := ctx.Get(identityKey); ok {
+		if id, ok := v.(*auth.Identity); ok {
+			return id
+		}
+	}
+
+	return nil
+}
+
+func (s *server) filterEvents(ctx context.Context, id *auth.Identity, results []httpResult) []httpResult {
+	if s.cfg.Visibility == nil {
+		return results
+	}
+
+	m := make([]httpResult, 0, len(results))
+
+	for i := range results {
+		if b, ok := s.filterEvent(ctx, id, results[i]); ok {
+			m = append(m, b)
+		}
+	}
+
+	return m
+}
+
+// filterEvent hides the events which failed to decode, as their visibility is unknown
+func (s *server) filterEvent(ctx context.Context, id *auth.Identity, result httpResult) (httpResult, bool) {
+	if s.cfg.Visibility == nil {
+		return result, true
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(result.EventBase64)
+	if err != nil {
+		s.cfg.Logger.Error("server: failed to decode event", "error", err)
+		return result, false
+	}
+
+	b, ok, err := s.cfg.Visibility.Filter(ctx, id, buf)
+	if err != nil {
+		s.cfg.Logger.Error("server: failed to filter event", "error", err)
+		return result, false
+	}
+
+	if !ok {
+		return result, false
+	}
+
+	return httpResult{EventBase64: base64.StdEncoding.EncodeToString(b), EventCreatedOn: result.EventCreatedOn}, true
+}
+
+func (s *server) hasRule(ctx context.Context, name string) bool {
+	if s.cfg.Trigger == nil {
+		return false
+	}
+
+	for _, item := range s.cfg.Trigger.Rules(ctx) {
+		if item == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *server) subscribe(info subscriberInfo, filter func(*events.Event) bool) *subscriber {
+	sub := &subscriber{
+		filter: filter,
+		events: make(chan streamResult, streamBuffer),
+		info:   info,
+	}
+
+	s.mutex.Lock()
+	s.subscribers[sub] = true
+	s.mutex.Unlock()
+
+	return sub
+}
+
+func (s *server) unsubscribe(sub *subscriber) {
+	s.mutex.Lock()
+	delete(s.subscribers, sub)
+	s.mutex.Unlock()
+}
+
+func (s *server) publishEvent(event *events.Event, data *storage.Model) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r := streamResult{
+		id: data.ID,
+		result: httpResult{
+			EventBase64:    data.EventBase64,
+			EventCreatedOn: data.EventCreatedOn,
+		},
+	}
+
+	if data.ID > s.published {
+		s.published = data.ID
+	}
+
+	for sub := range s.subscribers {
+		if !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.events <- r:
+		default:
+			s.cfg.Logger.Warn("server: subscriber too slow, event dropped", "id", data.ID)
+		}
+	}
+}
+
 func (s *server) listenHttp(_ context.Context) error {
 	s.cfg.Logger.Debug("server: listenHttp")
 
@@ -212,8 +668,16 @@ func (s *server) listenHttp(_ context.Context) error {
 		MaxHeaderBytes: maxHeader,
 	}
 
+	if s.certificate != nil {
+		srv.TLSConfig = s.certificate.config()
+	}
+
 	go func() {
-		err = srv.ListenAndServe()
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
 		if err != nil {
 			return
 		}
@@ -222,21 +686,180 @@ func (s *server) listenHttp(_ context.Context) error {
 	return err
 }
 
+// leadEvent fetches the events while elected, followers serving the API and streams from the database shared
+func (s *server) leadEvent(ctx context.Context, param chan string) {
+	s.cfg.Logger.Debug("server: leadEvent")
+
+	if s.cfg.Leader == nil {
+		atomic.StoreInt32(&s.elected, 1)
+		s.fetchEvent(ctx, param)
+		return
+	}
+
+	elected := make(chan bool)
+
+	go func(ctx context.Context, elected chan bool) {
+		_ = s.cfg.Leader.Run(ctx, s.cfg.Storage, elected)
+	}(ctx, elected)
+
+	var cancel context.CancelFunc
+
+	follow := s.startFollow(ctx)
+
+	defer func() {
+		if cancel != nil {
+			cancel()
+		}
+		if follow != nil {
+			follow()
+		}
+	}()
+
+	deinit := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ok := <-elected:
+			if ok && cancel == nil {
+				// Connected again once stepped down before
+				if deinit {
+					_ = s.cfg.Ssh.Reconnect(ctx)
+				}
+				// Caught up with the events stored by the previous leader
+				follow()
+				follow = nil
+				s.tailEvent(ctx, s.lastPublished())
+				atomic.StoreInt32(&s.elected, 1)
+				cancel = s.startEvent(ctx, param)
+			} else if !ok && cancel != nil {
+				atomic.StoreInt32(&s.elected, 0)
+				cancel()
+				cancel = nil
+				_ = s.cfg.Ssh.Deinit(ctx)
+				deinit = true
+				follow = s.startFollow(ctx)
+			}
+		}
+	}
+}
+
+// startEvent fetches the events until canceled
+func (s *server) startEvent(ctx context.Context, param chan string) context.CancelFunc {
+	c, cancel := context.WithCancel(ctx)
+
+	go s.fetchEvent(c, param)
+
+	return cancel
+}
+
+// startFollow tails the database until stopped, the function returned waiting for it to stop
+func (s *server) startFollow(ctx context.Context) func() {
+	c, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+
+	go func() {
+		s.followEvent(c)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// followEvent publishes the events stored by the leader to the subscribers of a follower,
+// starting after the last one published or else stored
+func (s *server) followEvent(ctx context.Context) {
+	s.cfg.Logger.Debug("server: followEvent")
+
+	ticker := time.NewTicker(followPeriod)
+	defer ticker.Stop()
+
+	after := s.lastPublished()
+	started := after != 0
+
+	for {
+		if !started {
+			if id, err := s.cfg.Storage.ReadLastID(ctx); err != nil {
+				s.cfg.Logger.Error("server: failed to read", "error", err)
+			} else {
+				after, started = id, true
+				s.mutex.Lock()
+				s.published = max(s.published, id)
+				s.mutex.Unlock()
+			}
+		}
+		if started {
+			after = s.tailEvent(ctx, after)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tailEvent publishes the events stored after the ID given, returning the last one read
+func (s *server) tailEvent(ctx context.Context, after uint) uint {
+	for ctx.Err() == nil {
+		b, err := s.cfg.Storage.ReadPage(ctx, 0, math.MaxInt64, after, storage.BatchSize)
+		if err != nil {
+			s.cfg.Logger.Error("server: failed to read", "error", err)
+			break
+		}
+
+		for i := range b {
+			after = b[i].ID
+			buf, err := base64.StdEncoding.DecodeString(b[i].EventBase64)
+			if err != nil {
+				continue
+			}
+			var event events.Event
+			if err := json.Unmarshal(buf, &event); err != nil {
+				continue
+			}
+			s.publishEvent(&event, &b[i])
+		}
+
+		if len(b) < storage.BatchSize {
+			break
+		}
+	}
+
+	return after
+}
+
+func (s *server) lastPublished() uint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.published
+}
+
 func (s *server) fetchEvent(ctx context.Context, param chan string) {
 	s.cfg.Logger.Debug("server: fetchEvent")
 
-	reconn := make(chan bool, 1)
 	start := make(chan bool, 1)
 
 	_ = s.cfg.Ssh.Start(ctx, "stream-events", param)
 
 	go func(ctx context.Context, reconn, start chan bool) {
 		_ = s.cfg.Watchdog.Run(ctx, s.cfg.Ssh, reconn, start)
-	}(ctx, reconn, start)
+	}(ctx, s.reconn, start)
+
+	if s.replayable() {
+		go s.retryGap(ctx)
+	}
 
 	for {
 		select {
-		case <-reconn:
+		case <-ctx.Done():
+			return
+		case <-s.reconn:
 			if err := s.cfg.Ssh.Reconnect(ctx); err == nil {
 				start <- true
 			}
@@ -275,7 +898,39 @@ func (s *server) queryEvent(ctx context.Context, query string) ([]httpResult, er
 	return m, nil
 }
 
+func (s *server) queryTrigger(ctx context.Context, name, query string) ([]httpResult, error) {
+	s.cfg.Logger.Debug("server: queryTrigger")
+
+	b, err := s.queryEvent(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make([]httpResult, 0, len(b))
+
+	for i := range b {
+		buf, err := base64.StdEncoding.DecodeString(b[i].EventBase64)
+		if err != nil {
+			continue
+		}
+		e := events.Event{}
+		if err := json.Unmarshal(buf, &e); err != nil {
+			continue
+		}
+		if ok, _ := s.cfg.Trigger.Match(ctx, name, &e); ok {
+			m = append(m, b[i])
+		}
+	}
+
+	return m, nil
+}
+
 func (s *server) parseQuery(query string) (rs, ru int64, err error) {
+	return ParseQuery(query)
+}
+
+// ParseQuery parses the query of the API (since:YYYY-MM-DD HH:MM:SS until:YYYY-MM-DD HH:MM:SS) in local time
+func ParseQuery(query string) (rs, ru int64, err error) {
 	helper := func(q string) (int64, error) {
 		loc, _ := time.LoadLocation(queryLocation)
 		t, e := time.ParseInLocation(queryLayout, q, loc)
@@ -325,16 +980,228 @@ func (s *server) storeEvent(ctx context.Context) error {
 		return errors.Wrap(err, "failed to get queue")
 	}
 
-	for item := range r {
+	// Stopped only once canceled or the queue closed, failures being logged
+	for {
+		var item string
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case item, ok = <-r:
+			if !ok {
+				return nil
+			}
+		}
+
 		e := events.Event{}
-		if err = json.Unmarshal([]byte(item), &e); err != nil {
-			break
+		if err := json.Unmarshal([]byte(item), &e); err != nil {
+			s.cfg.Logger.Error("server: invalid event", "line", item, "error", err)
+			continue
 		}
-		b := []storage.Model{{EventBase64: base64.StdEncoding.EncodeToString([]byte(item)), EventCreatedOn: e.EventCreatedOn}}
-		if err = s.cfg.Storage.Create(ctx, b); err != nil {
-			break
+		if e.Type == events.EVENTS_DROPPED_OUTPUT {
+			s.dropEvent(ctx, &e)
+			continue
 		}
+		s.retryEvent(ctx, &sink.Event{Data: []byte(item), Event: &e, ID: events.ID(&e)})
 	}
+}
 
-	return err
+// retryEvent dispatches the event, retrying with backoff while the storage fails, e.g., as the database is locked,
+// and then records a gap at its time to be replayed
+func (s *server) retryEvent(ctx context.Context, event *sink.Event) {
+	backoff := storeBackoff
+
+	for i := 0; ; i++ {
+		err := s.dispatch(ctx, event)
+		if err == nil {
+			return
+		}
+		if i == storeRetries {
+			s.cfg.Logger.Error("server: failed to store", "id", event.ID, "error", err)
+			s.replayGap(ctx, event.Event.EventCreatedOn, event.Event.EventCreatedOn)
+			return
+		}
+		s.cfg.Logger.Warn("server: failed to store, retried", "id", event.ID, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// seen records the ID of the event, and returns whether recorded before within the last ones
+func (s *server) seen(id string) bool {
+	if s.recent == nil {
+		s.recent = make(map[string]bool, recentSize)
+		s.recentIDs = make([]string, recentSize)
+	}
+
+	if s.recent[id] {
+		return true
+	}
+
+	delete(s.recent, s.recentIDs[s.recentPos])
+
+	s.recent[id] = true
+	s.recentIDs[s.recentPos] = id
+	s.recentPos = (s.recentPos + 1) % recentSize
+
+	return false
+}
+
+func (s *server) dropEvent(ctx context.Context, event *events.Event) {
+	s.cfg.Logger.Debug("server: dropEvent")
+
+	n := atomic.AddInt64(&s.dropped, 1)
+
+	until := event.EventCreatedOn
+	if until == 0 {
+		until = time.Now().Unix()
+	}
+
+	// Events are dropped since the last stored one, or within the default window if nothing was stored yet
+	since := atomic.LoadInt64(&s.last)
+	if since == 0 || since > until {
+		since = until - int64(replayWindow/time.Second)
+	}
+
+	s.cfg.Logger.Warn("server: dropped output", "count", n, "since", since, "until", until)
+
+	s.replayGap(ctx, since, until)
+}
+
+// replayGap records the window in which events were missed, and replays it in the background,
+// or records it as unrecoverable without the events-log plugin
+func (s *server) replayGap(ctx context.Context, since, until int64) {
+	gap := &storage.Gap{Since: since, Until: until, Unrecoverable: !s.replayable()}
+
+	if err := s.cfg.Storage.CreateGap(ctx, gap); err != nil {
+		s.cfg.Logger.Error("server: failed to create gap", "error", err)
+		return
+	}
+
+	if gap.Unrecoverable {
+		s.cfg.Logger.Warn("server: events lost, not replayed without spec.connect.rest.url", "since", since, "until", until)
+		return
+	}
+
+	go func(ctx context.Context, gap *storage.Gap) {
+		if err := s.replayEvent(ctx, gap); err != nil {
+			s.cfg.Logger.Error("server: failed to replay gap", "since", gap.Since, "until", gap.Until, "error", err)
+		}
+	}(ctx, gap)
+}
+
+// replayable returns whether the events missed can be replayed, i.e., with the events-log plugin
+func (s *server) replayable() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.cfg.Rest != nil && s.cfg.Config.Spec.Connect.Rest.Url != ""
+}
+
+// retryGap replays the gaps not replayed yet, e.g., as the events-log plugin failed, on start and then periodically
+func (s *server) retryGap(ctx context.Context) {
+	s.cfg.Logger.Debug("server: retryGap")
+
+	ticker := time.NewTicker(replayPeriod)
+	defer ticker.Stop()
+
+	for {
+		s.replayGaps(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *server) replayGaps(ctx context.Context) {
+	b, err := s.cfg.Storage.ReadPendingGap(ctx)
+	if err != nil {
+		s.cfg.Logger.Error("server: failed to read gap", "error", err)
+		return
+	}
+
+	for i := range b {
+		if err := s.replayEvent(ctx, &b[i]); err != nil {
+			s.cfg.Logger.Error("server: failed to replay gap", "since", b[i].Since, "until", b[i].Until, "error", err)
+		}
+	}
+}
+
+// replayEvent queues the events of the gap not stored yet, deduplicated by their ID, the gap being replayed once at a time
+func (s *server) replayEvent(ctx context.Context, gap *storage.Gap) error {
+	s.cfg.Logger.Debug("server: replayEvent")
+
+	if !s.replayable() {
+		return errors.New("invalid rest")
+	}
+
+	s.mutex.Lock()
+	if s.replaying == nil {
+		s.replaying = map[uint]bool{}
+	}
+	busy := s.replaying[gap.ID]
+	s.replaying[gap.ID] = true
+	s.mutex.Unlock()
+
+	if busy {
+		return nil
+	}
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.replaying, gap.ID)
+		s.mutex.Unlock()
+	}()
+
+	b, err := s.cfg.Rest.Events(ctx, gap.Since, gap.Until)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch")
+	}
+
+	m, err := s.cfg.Storage.Read(ctx, gap.Since, gap.Until+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to read")
+	}
+
+	stored := make(map[string]bool, len(m))
+
+	for i := range m {
+		if m[i].EventID != "" {
+			stored[m[i].EventID] = true
+		}
+	}
+
+	for _, item := range b {
+		var e events.Event
+		if err := json.Unmarshal([]byte(item), &e); err != nil {
+			s.cfg.Logger.Warn("server: invalid event replayed", "error", err)
+			continue
+		}
+		if e.Type == events.EVENTS_DROPPED_OUTPUT {
+			continue
+		}
+		id := events.ID(&e)
+		if stored[id] {
+			continue
+		}
+		stored[id] = true
+		if err := s.cfg.Queue.Put(ctx, item); err != nil {
+			return errors.Wrap(err, "failed to put queue")
+		}
+	}
+
+	gap.Replayed = true
+
+	if err := s.cfg.Storage.UpdateGap(ctx, gap); err != nil {
+		return errors.Wrap(err, "failed to update gap")
+	}
+
+	return nil
 }
@@ -1,21 +1,43 @@
 package server
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
 	nethttp "net/http"
 	"net/http/httptest"
-	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 
+	"github.com/gerrittrigger/events/auth"
 	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/connect"
+	"github.com/gerrittrigger/events/connect/gerrittest"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/leader"
+	"github.com/gerrittrigger/events/queue"
 	"github.com/gerrittrigger/events/storage"
+	"github.com/gerrittrigger/events/trigger"
+	"github.com/gerrittrigger/events/visibility"
+	"github.com/gerrittrigger/events/watchdog"
 )
 
 const (
-	name = "test.db"
+	event = `{"type":"ref-updated","eventCreatedOn":1672567200}`
+	later = `{"type":"ref-updated","eventCreatedOn":1672567201}`
+	name  = "test.db"
 )
 
 var (
@@ -27,12 +49,14 @@ var (
 	}
 )
 
-func initServer() server {
+func initServer(t *testing.T) *server {
 	ctx := context.Background()
 
-	s := server{
-		cfg:    DefaultConfig(),
-		engine: nil,
+	s := &server{
+		cfg:         DefaultConfig(),
+		engine:      nil,
+		reconn:      make(chan bool, 1),
+		subscribers: map[*subscriber]bool{},
 	}
 
 	s.cfg.Config = config.Config{}
@@ -44,7 +68,7 @@ func initServer() server {
 
 	s.cfg.Port = 8080
 
-	s.cfg.Storage = initStorage()
+	s.cfg.Storage = initStorage(filepath.Join(t.TempDir(), name))
 	_ = s.cfg.Storage.Init(ctx)
 	_ = s.cfg.Storage.Create(ctx, data)
 
@@ -53,12 +77,12 @@ func initServer() server {
 	return s
 }
 
-func initStorage() storage.Storage {
+func initStorage(filename string) storage.Storage {
 	c := storage.DefaultConfig()
 	ctx := context.Background()
 
 	c.Config = config.Config{}
-	c.Config.Spec.Storage.Sqlite.Filename = name
+	c.Config.Spec.Storage.Sqlite.Filename = filename
 
 	c.Logger = hclog.New(&hclog.LoggerOptions{
 		Name:  "storage",
@@ -68,8 +92,820 @@ func initStorage() storage.Storage {
 	return storage.New(ctx, c)
 }
 
+func initGerrit(t *testing.T) (*server, *gerrittest.Server) {
+	srv, err := gerrittest.New(gerrittest.Config{})
+	assert.Equal(t, nil, err)
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return initReplica(t, srv, filepath.Join(t.TempDir(), name)), srv
+}
+
+// initReplica connects to Gerrit and stores the events in the database, which may be shared with other replicas
+func initReplica(t *testing.T, srv *gerrittest.Server, filename string) *server {
+	ctx := context.Background()
+
+	keyfile, err := gerrittest.Keyfile(t.TempDir())
+	assert.Equal(t, nil, err)
+
+	c := config.Config{}
+	c.Spec.Connect.Hostname = srv.Hostname()
+	c.Spec.Connect.Ssh.Keyfile = keyfile
+	c.Spec.Connect.Ssh.Port = srv.Port()
+	c.Spec.Connect.Ssh.Username = gerrittest.Username
+	c.Spec.Storage.Sqlite.Filename = filename
+	c.Spec.Watchdog.PeriodSeconds = 1
+	c.Spec.Watchdog.TimeoutSeconds = 1
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "server",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	qc := queue.DefaultConfig()
+	qc.Config = c
+	qc.Logger = logger
+
+	sc := connect.DefaultSshConfig()
+	sc.Config = c
+	sc.Logger = logger
+
+	stc := storage.DefaultConfig()
+	stc.Config = c
+	stc.Logger = logger
+
+	wc := watchdog.DefaultConfig()
+	wc.Config = c
+	wc.Logger = logger
+
+	s := &server{
+		cfg: &Config{
+			Config:   c,
+			Logger:   logger,
+			Port:     0,
+			Queue:    queue.New(ctx, qc),
+			Ssh:      connect.SshNew(ctx, sc),
+			Storage:  storage.New(ctx, stc),
+			Watchdog: watchdog.New(ctx, wc),
+		},
+		reconn:      make(chan bool, 1),
+		subscribers: map[*subscriber]bool{},
+	}
+
+	t.Cleanup(func() {
+		_ = s.Deinit(ctx)
+	})
+
+	return s
+}
+
+func waitEvents(s *server, n int) bool {
+	for i := 0; i < 100; i++ {
+		b, _ := s.cfg.Storage.Read(context.Background(), 0, time.Now().Unix())
+		if len(b) == n {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func waitStreams(srv *gerrittest.Server, n int) bool {
+	for i := 0; i < 100; i++ {
+		if srv.Streams() == n {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	s, srv := initGerrit(t)
+
+	err := s.Init(ctx)
+	assert.Equal(t, nil, err)
+
+	go func() {
+		_ = s.Run(ctx)
+	}()
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+	srv.Push(event)
+	assert.Equal(t, true, waitEvents(s, 1))
+
+	srv.Disconnect()
+	assert.Equal(t, true, waitStreams(srv, 0))
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+	srv.Push(later)
+	assert.Equal(t, true, waitEvents(s, 2))
+}
+
+func initLeader(s *server, holder string) {
+	c := leader.DefaultConfig()
+	c.Config.Spec.Leader.Holder = holder
+	c.Config.Spec.Leader.LeaseSeconds = 1
+	c.Logger = s.cfg.Logger
+
+	s.cfg.Leader = leader.New(context.Background(), c)
+}
+
+func TestLeader(t *testing.T) {
+	srv, err := gerrittest.New(gerrittest.Config{})
+	assert.Equal(t, nil, err)
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	// Replicas sharing the database
+	filename := filepath.Join(t.TempDir(), name)
+	replicas := []*server{initReplica(t, srv, filename), initReplica(t, srv, filename)}
+	cancels := make([]context.CancelFunc, len(replicas))
+
+	for i, s := range replicas {
+		initLeader(s, "replica-"+strconv.Itoa(i))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels[i] = cancel
+		assert.Equal(t, nil, s.Init(ctx))
+		go func() {
+			_ = s.Run(ctx)
+		}()
+	}
+
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+
+	// Streamed by the leader only
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, 1, srv.Streams())
+
+	elected := func(s *server) bool {
+		return atomic.LoadInt32(&s.elected) == 1
+	}
+
+	i := 0
+	if elected(replicas[1]) {
+		i = 1
+	}
+
+	l, f := replicas[i], replicas[1-i]
+	assert.Equal(t, true, elected(l))
+	assert.Equal(t, false, elected(f))
+
+	// Streamed to the clients of the follower from the database
+	sub := f.subscribe(subscriberInfo{}, func(*events.Event) bool { return true })
+
+	next := func() uint {
+		select {
+		case r := <-sub.events:
+			return r.id
+		case <-time.After(5 * time.Second):
+			return 0
+		}
+	}
+
+	srv.Push(event)
+	assert.Equal(t, true, waitEvents(f, 1))
+
+	b, _ := f.cfg.Storage.ReadPage(context.Background(), 0, math.MaxInt64, 0, storage.BatchSize)
+	assert.Equal(t, b[0].ID, next())
+
+	// Taken over by the follower once the leader stopped
+	cancels[i]()
+	_ = l.Deinit(context.Background())
+
+	for j := 0; j < 100 && !elected(f); j++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	assert.Equal(t, true, elected(f))
+	assert.Equal(t, true, waitStreams(srv, 1))
+
+	srv.Push(later)
+	assert.Equal(t, true, waitEvents(f, 2))
+
+	// Published once when elected
+	b, _ = f.cfg.Storage.ReadPage(context.Background(), 0, math.MaxInt64, 0, storage.BatchSize)
+	assert.Equal(t, b[1].ID, next())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, len(sub.events))
+}
+
+func TestActiveActive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, err := gerrittest.New(gerrittest.Config{})
+	assert.Equal(t, nil, err)
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	// Replicas sharing the database, each streaming the events of Gerrit
+	filename := filepath.Join(t.TempDir(), name)
+	replicas := []*server{initReplica(t, srv, filename), initReplica(t, srv, filename)}
+	subs := make([]*subscriber, len(replicas))
+
+	for i, s := range replicas {
+		assert.Equal(t, nil, s.Init(ctx))
+		subs[i] = s.subscribe(subscriberInfo{}, func(*events.Event) bool { return true })
+		go func() {
+			_ = s.Run(ctx)
+		}()
+	}
+
+	assert.Equal(t, true, waitStreams(srv, 2))
+
+	srv.Push(event)
+	srv.Push(later)
+
+	assert.Equal(t, true, waitEvents(replicas[0], 2))
+
+	b, _ := replicas[0].cfg.Storage.ReadPage(ctx, 0, 1672567300, 0, storage.BatchSize)
+	assert.Equal(t, 2, len(b))
+
+	// Published once by each replica, whichever stored it, IDs being possibly skipped by concurrent inserts
+	for _, sub := range subs {
+		var ids []uint
+		for len(ids) < 2 {
+			select {
+			case r := <-sub.events:
+				ids = append(ids, r.id)
+			case <-time.After(5 * time.Second):
+				assert.Fail(t, "event timeout")
+				return
+			}
+		}
+		assert.Equal(t, []uint{b[0].ID, b[1].ID}, ids)
+	}
+
+	// Stored once when replayed
+	_ = replicas[1].cfg.Queue.Put(ctx, event)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, true, waitEvents(replicas[0], 2))
+	assert.Equal(t, 0, len(subs[1].events))
+}
+
+func TestDropEvent(t *testing.T) {
+	ctx := context.Background()
+	s, _ := initGerrit(t)
+
+	replayed := `{"type":"ref-updated","eventCreatedOn":1672567230}`
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		_, _ = w.Write([]byte(event + "\n" + replayed + "\n"))
+	}))
+
+	defer srv.Close()
+
+	c := connect.DefaultRestConfig()
+	c.Config.Spec.Connect.Rest.Url = srv.URL
+	s.cfg.Config.Spec.Connect.Rest.Url = srv.URL
+	c.Logger = s.cfg.Logger
+	s.cfg.Rest = connect.RestNew(ctx, c)
+
+	_ = s.cfg.Queue.Init(ctx)
+	_ = s.cfg.Rest.Init(ctx)
+	_ = s.cfg.Storage.Init(ctx)
+
+	go func() {
+		_ = s.storeEvent(ctx)
+	}()
+
+	_ = s.cfg.Queue.Put(ctx, event)
+	assert.Equal(t, true, waitEvents(s, 1))
+
+	_ = s.cfg.Queue.Put(ctx, `{"type":"dropped-output","eventCreatedOn":1672567260}`)
+	assert.Equal(t, true, waitEvents(s, 2))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&s.dropped))
+
+	b, err := s.cfg.Storage.ReadGap(ctx, 0, time.Now().Unix())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, int64(1672567200), b[0].Since)
+	assert.Equal(t, int64(1672567260), b[0].Until)
+
+	s.cfg.Rest = nil
+
+	err = s.replayEvent(ctx, &b[0])
+	assert.NotEqual(t, nil, err)
+}
+
+// failStorage fails to insert the first events, e.g., as the database is locked
+type failStorage struct {
+	storage.Storage
+	fails int32
+}
+
+func (f *failStorage) Insert(ctx context.Context, data *storage.Model) (bool, error) {
+	if atomic.AddInt32(&f.fails, -1) >= 0 {
+		return false, errors.New("database is locked")
+	}
+
+	return f.Storage.Insert(ctx, data)
+}
+
+func TestStoreEventFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storeBackoff = time.Millisecond
+
+	defer func() {
+		storeBackoff = time.Second
+	}()
+
+	s, _ := initGerrit(t)
+
+	_ = s.cfg.Queue.Init(ctx)
+	_ = s.cfg.Storage.Init(ctx)
+
+	f := &failStorage{Storage: s.cfg.Storage, fails: 2}
+	s.cfg.Storage = f
+
+	done := make(chan error)
+
+	go func() {
+		done <- s.storeEvent(ctx)
+	}()
+
+	// Stored once the storage is back, invalid events being skipped
+	_ = s.cfg.Queue.Put(ctx, "{")
+	_ = s.cfg.Queue.Put(ctx, event)
+	assert.Equal(t, true, waitEvents(s, 1))
+
+	// Recorded as a gap once the retries are exhausted
+	atomic.StoreInt32(&f.fails, storeRetries+1)
+	_ = s.cfg.Queue.Put(ctx, later)
+
+	var gaps []storage.Gap
+
+	for i := 0; i < 100 && len(gaps) == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+		gaps, _ = s.cfg.Storage.ReadGap(ctx, 0, time.Now().Unix())
+	}
+
+	assert.Equal(t, 1, len(gaps))
+	assert.Equal(t, int64(1672567201), gaps[0].Since)
+
+	_ = s.cfg.Queue.Put(ctx, `{"type":"ref-updated","eventCreatedOn":1672567202}`)
+	assert.Equal(t, true, waitEvents(s, 2))
+
+	cancel()
+	assert.Equal(t, nil, <-done)
+}
+
+func TestDropEventUnrecoverable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, _ := initGerrit(t)
+
+	_ = s.cfg.Queue.Init(ctx)
+	_ = s.cfg.Storage.Init(ctx)
+
+	go func() {
+		_ = s.storeEvent(ctx)
+	}()
+
+	// Recorded once without the events-log plugin, and never retried
+	_ = s.cfg.Queue.Put(ctx, `{"type":"dropped-output","eventCreatedOn":1672567260}`)
+
+	var gaps []storage.Gap
+
+	for i := 0; i < 100 && len(gaps) == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+		gaps, _ = s.cfg.Storage.ReadGap(ctx, 0, time.Now().Unix())
+	}
+
+	assert.Equal(t, 1, len(gaps))
+	assert.Equal(t, true, gaps[0].Unrecoverable)
+	assert.Equal(t, false, s.replayable())
+
+	b, err := s.cfg.Storage.ReadPendingGap(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(b))
+}
+
+func TestReplayGap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, srv := initGerrit(t)
+	initAdmin(s)
+
+	c := connect.DefaultRestConfig()
+	c.Config.Spec.Connect.Rest.Url = srv.RestURL()
+	s.cfg.Config.Spec.Connect.Rest.Url = srv.RestURL()
+	c.Logger = s.cfg.Logger
+	s.cfg.Rest = connect.RestNew(ctx, c)
+
+	assert.Equal(t, nil, s.Init(ctx))
+
+	go func() {
+		_ = s.Run(ctx)
+	}()
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+
+	srv.Push(event)
+	assert.Equal(t, true, waitEvents(s, 1))
+
+	// Dropped from the stream, and not replayed as the events-log plugin fails
+	replayed := `{"type":"ref-updated","eventCreatedOn":1672567230}`
+	newer := `{"type":"ref-updated","eventCreatedOn":1672567300}`
+
+	srv.Log(replayed)
+	srv.Log(`{"eventCreatedOn":1672567200,"type":"ref-updated"}`)
+	srv.FailEventsLog(1)
+	srv.Push(`{"type":"dropped-output","eventCreatedOn":1672567260}`)
+
+	var gaps []storage.Gap
+
+	for i := 0; i < 100 && len(gaps) == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+		gaps, _ = s.cfg.Storage.ReadGap(ctx, 0, time.Now().Unix())
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Equal(t, 1, len(gaps))
+	assert.Equal(t, false, gaps[0].Replayed)
+
+	srv.Push(newer)
+	assert.Equal(t, true, waitEvents(s, 2))
+
+	// Retried, the events already stored being skipped whatever their encoding
+	s.replayGaps(ctx)
+	assert.Equal(t, true, waitEvents(s, 3))
+
+	gaps, _ = s.cfg.Storage.ReadGap(ctx, 0, time.Now().Unix())
+	assert.Equal(t, true, gaps[0].Replayed)
+
+	time.Sleep(100 * time.Millisecond)
+
+	b, _ := s.cfg.Storage.Read(ctx, 0, time.Now().Unix())
+	assert.Equal(t, 3, len(b))
+
+	// Not moved backwards by the replayed event
+	assert.Equal(t, int64(1672567300), atomic.LoadInt64(&s.last))
+
+	var r sessionsResult
+
+	rec := serveAdmin(s, "GET", "/admin/sessions", adminToken)
+	_ = json.Unmarshal(rec.Body.Bytes(), &r)
+	assert.Equal(t, int64(1), r.Dropped)
+}
+
+func initTrigger(s *server) {
+	c := trigger.DefaultConfig()
+
+	c.Config.Spec.Trigger.Rules = []config.Rule{
+		{
+			Name:     "merged",
+			Events:   []string{events.EVENTS_REF_UPDATED},
+			Projects: []config.Project{{Pattern: config.Pattern{Type: trigger.PatternAnt, Pattern: "platform/**"}}},
+		},
+	}
+
+	c.Logger = s.cfg.Logger
+
+	s.cfg.Trigger = trigger.New(context.Background(), c)
+	_ = s.cfg.Trigger.Init(context.Background())
+}
+
+func TestTrigger(t *testing.T) {
+	ctx := context.Background()
+	s := initServer(t)
+
+	matched := `{"type":"ref-updated","refUpdate":{"project":"platform/build"},"eventCreatedOn":1672567201}`
+
+	_ = s.cfg.Storage.Create(ctx, []storage.Model{
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(matched)), EventCreatedOn: 1672567201},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(event)), EventCreatedOn: 1672567202},
+	})
+
+	rec := httptest.NewRecorder()
+	req, _ := nethttp.NewRequest("GET", "/triggers/", nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Equal(t, "[]", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("GET", "/triggers/merged/events/?q=", nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusNotFound, rec.Code)
+
+	initTrigger(s)
+
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("GET", "/triggers/", nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, `["merged"]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("GET", "/triggers/merged/events/?q=", nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("GET", `/triggers/merged/events/?q=since:2023-01-01+10:00:00+until:2023-01-01+11:00:00`, nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+
+	var b []httpResult
+	_ = json.Unmarshal(rec.Body.Bytes(), &b)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, int64(1672567201), b[0].EventCreatedOn)
+}
+
+func TestStream(t *testing.T) {
+	s := initServer(t)
+	initTrigger(s)
+
+	srv := httptest.NewServer(s.engine)
+	defer srv.Close()
+
+	rsp, err := nethttp.Get(srv.URL + "/triggers/invalid/stream")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nethttp.StatusNotFound, rsp.StatusCode)
+	_ = rsp.Body.Close()
+
+	rsp, err = nethttp.Get(srv.URL + "/triggers/merged/stream")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nethttp.StatusOK, rsp.StatusCode)
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	for i := 0; i < 100; i++ {
+		s.mutex.Lock()
+		n := len(s.subscribers)
+		s.mutex.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e := events.Event{Type: events.EVENTS_CHANGE_MERGED}
+	s.publishEvent(&e, &storage.Model{Model: gorm.Model{ID: 1}, EventBase64: "ignored"})
+
+	e = events.Event{Type: events.EVENTS_REF_UPDATED, RefUpdate: events.RefUpdate{Project: "platform/build"}}
+	s.publishEvent(&e, &storage.Model{Model: gorm.Model{ID: 2}, EventBase64: "matched", EventCreatedOn: 1672567201})
+
+	scan := bufio.NewScanner(rsp.Body)
+
+	var lines []string
+
+	for scan.Scan() {
+		if scan.Text() == "" {
+			break
+		}
+		lines = append(lines, scan.Text())
+	}
+
+	assert.Equal(t, []string{"id:2", "event:message", `data:{"eventBase64":"matched","eventCreatedOn":1672567201}`}, lines)
+}
+
+func TestStreamAll(t *testing.T) {
+	s := initServer(t)
+	initTrigger(s)
+
+	srv := httptest.NewServer(s.engine)
+	defer srv.Close()
+
+	rsp, err := nethttp.Get(srv.URL + "/events/stream")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nethttp.StatusOK, rsp.StatusCode)
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	for i := 0; i < 100; i++ {
+		s.mutex.Lock()
+		n := len(s.subscribers)
+		s.mutex.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Not matched by any rule
+	e := events.Event{Type: events.EVENTS_CHANGE_MERGED}
+	s.publishEvent(&e, &storage.Model{Model: gorm.Model{ID: 1}, EventBase64: "unmatched", EventCreatedOn: 1672567201})
+
+	scan := bufio.NewScanner(rsp.Body)
+
+	var lines []string
+
+	for scan.Scan() {
+		if scan.Text() == "" {
+			break
+		}
+		lines = append(lines, scan.Text())
+	}
+
+	assert.Equal(t, []string{"id:1", "event:message", `data:{"eventBase64":"unmatched","eventCreatedOn":1672567201}`}, lines)
+}
+
+func TestStreamResume(t *testing.T) {
+	ctx := context.Background()
+	s := initServer(t)
+	initTrigger(s)
+
+	matched := `{"type":"ref-updated","refUpdate":{"project":"platform/build"},"eventCreatedOn":%d}`
+
+	m := []storage.Model{
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(matched, 1672567201))), EventCreatedOn: 1672567201},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(event)), EventCreatedOn: 1672567202},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(matched, 1672567203))), EventCreatedOn: 1672567203},
+	}
+
+	_ = s.cfg.Storage.Create(ctx, m)
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	req, _ := nethttp.NewRequest("GET", srv.URL+"/triggers/merged/stream", nethttp.NoBody)
+	req.Header.Set(lastEventID, strconv.FormatUint(uint64(m[0].ID), 10))
+
+	rsp, err := nethttp.DefaultClient.Do(req)
+	assert.Equal(t, nil, err)
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	// Published events already replayed are skipped
+	e := events.Event{Type: events.EVENTS_REF_UPDATED, RefUpdate: events.RefUpdate{Project: "platform/build"}}
+	s.publishEvent(&e, &m[2])
+	s.publishEvent(&e, &storage.Model{Model: gorm.Model{ID: m[2].ID + 1}, EventBase64: "live", EventCreatedOn: 1672567204})
+
+	scan := bufio.NewScanner(rsp.Body)
+
+	var lines []string
+
+	for scan.Scan() && len(lines) < 7 {
+		lines = append(lines, scan.Text())
+	}
+
+	assert.Equal(t, []string{
+		"id:" + strconv.FormatUint(uint64(m[2].ID), 10),
+		"event:message",
+		`data:{"eventBase64":"` + m[2].EventBase64 + `","eventCreatedOn":1672567203}`,
+		"",
+		"id:" + strconv.FormatUint(uint64(m[2].ID+1), 10),
+		"event:message",
+		`data:{"eventBase64":"live","eventCreatedOn":1672567204}`,
+	}, lines)
+}
+
+func TestAuth(t *testing.T) {
+	ctx := context.Background()
+	s := initServer(t)
+
+	c := auth.DefaultConfig()
+	c.Config.Spec.Server.Auth.Tokens = []config.Token{
+		{Name: "ci", Token: "read", Scopes: []string{auth.ScopeRead}},
+		{Name: "none", Token: "none"},
+	}
+	c.Logger = s.cfg.Logger
+
+	s.cfg.Auth = auth.New(ctx, c)
+	_ = s.cfg.Auth.Init(ctx)
+
+	s.cfg.Config.Spec.Server.Cors.AllowOrigins = []string{"https://example.com"}
+	_ = s.initHttp(ctx)
+
+	rec := httptest.NewRecorder()
+	req, _ := nethttp.NewRequest("GET", "/health", nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("GET", "/events/?q=", nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req.Header.Set("Authorization", "Bearer none")
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req.Header.Set("Authorization", "Bearer read")
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("GET", "/triggers/", nethttp.NoBody)
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("GET", "/health", nethttp.NoBody)
+	req.Header.Set("Origin", "https://invalid.com")
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req.Header.Set("Origin", "https://example.com")
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Preflight of the admin API
+	rec = httptest.NewRecorder()
+	req, _ = nethttp.NewRequest("OPTIONS", "/admin/pause", nethttp.NoBody)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	s.engine.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusNoContent, rec.Code)
+	assert.Equal(t, true, strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), "POST"))
+}
+
+func TestVisibility(t *testing.T) {
+	ctx := context.Background()
+	s := initServer(t)
+
+	private := `{"type":"patchset-created","change":{"project":"platform/build","subject":"Secret","private":true,` +
+		`"owner":{"username":"owner"}},"eventCreatedOn":1672567201}`
+	wip := `{"type":"patchset-created","change":{"project":"platform/build","subject":"Draft","wip":true},"eventCreatedOn":1672567202}`
+
+	_ = s.cfg.Storage.Create(ctx, []storage.Model{
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(private)), EventCreatedOn: 1672567201},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(wip)), EventCreatedOn: 1672567202},
+		{EventBase64: base64.StdEncoding.EncodeToString([]byte(event)), EventCreatedOn: 1672567203},
+	})
+
+	ac := auth.DefaultConfig()
+	ac.Config.Spec.Server.Auth.Tokens = []config.Token{
+		{Name: "ci", Token: "ci", Scopes: []string{auth.ScopeRead}},
+		{Name: "owner", Token: "owner", Scopes: []string{auth.ScopeRead}},
+		{Name: "dev", Token: "dev", Scopes: []string{auth.ScopeRead}},
+	}
+	ac.Logger = s.cfg.Logger
+
+	s.cfg.Auth = auth.New(ctx, ac)
+	_ = s.cfg.Auth.Init(ctx)
+
+	vc := visibility.DefaultConfig()
+	vc.Config.Spec.Server.Visibility = config.Visibility{
+		Wip:    visibility.ActionHide,
+		Groups: []config.Group{{Name: "devs", Members: []string{"dev"}, Private: visibility.ActionRedact, Wip: visibility.ActionShow}},
+	}
+	vc.Logger = s.cfg.Logger
+
+	s.cfg.Visibility = visibility.New(ctx, vc)
+	_ = s.cfg.Visibility.Init(ctx)
+
+	query := func(token string) []string {
+		rec := httptest.NewRecorder()
+		req, _ := nethttp.NewRequest("GET", `/events/?q=since:2023-01-01+10:00:00+until:2023-01-01+11:00:00`, nethttp.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.engine.ServeHTTP(rec, req)
+		assert.Equal(t, nethttp.StatusOK, rec.Code)
+		var b []httpResult
+		_ = json.Unmarshal(rec.Body.Bytes(), &b)
+		m := make([]string, len(b))
+		for i := range b {
+			buf, _ := base64.StdEncoding.DecodeString(b[i].EventBase64)
+			m[i] = string(buf)
+		}
+		return m
+	}
+
+	assert.Equal(t, []string{event}, query("ci"))
+	assert.Equal(t, []string{private, event}, query("owner"))
+
+	b := query("dev")
+	assert.Equal(t, 3, len(b))
+	assert.Equal(t, `{"change":{"private":true,"project":"platform/build"},"eventCreatedOn":1672567201,"type":"patchset-created"}`, b[0])
+	assert.Equal(t, wip, b[1])
+}
+
 func TestQueryEvent(t *testing.T) {
-	s := initServer()
+	s := initServer(t)
 
 	rec := httptest.NewRecorder()
 	req, _ := nethttp.NewRequest("GET", "/events/?q=", nethttp.NoBody)
@@ -81,12 +917,10 @@ func TestQueryEvent(t *testing.T) {
 	s.engine.ServeHTTP(rec, req)
 	assert.Equal(t, nethttp.StatusOK, rec.Code)
 	assert.NotEqual(t, nil, rec.Body.String())
-
-	_ = os.Remove(name)
 }
 
 func TestParseQuery(t *testing.T) {
-	s := initServer()
+	s := initServer(t)
 
 	_, _, err := s.parseQuery("")
 	assert.NotEqual(t, nil, err)
@@ -102,6 +936,4 @@ func TestParseQuery(t *testing.T) {
 
 	_, _, err = s.parseQuery("since:2023-01-01 10:00:00 until:2023-01-01 11:00:00")
 	assert.Equal(t, nil, err)
-
-	_ = os.Remove(name)
 }
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/sink"
+	"github.com/gerrittrigger/events/storage"
+)
+
+// storageSink - Primary sink storing the events, and publishing them to the clients of the replica
+type storageSink struct {
+	s *server
+}
+
+func (t *storageSink) Init(_ context.Context) error {
+	return nil
+}
+
+func (t *storageSink) Deinit(_ context.Context) error {
+	return nil
+}
+
+// Deliver stores the event, and returns sink.ErrDuplicate if stored before, e.g., by another replica
+func (t *storageSink) Deliver(ctx context.Context, event *sink.Event) error {
+	s := t.s
+
+	b := storage.Model{
+		EventBase64:    base64.StdEncoding.EncodeToString(event.Data),
+		EventCreatedOn: event.Event.EventCreatedOn,
+		EventID:        event.ID,
+	}
+
+	ok, err := s.cfg.Storage.Insert(ctx, &b)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert")
+	}
+
+	// Not moved backwards by replayed events
+	for {
+		last := atomic.LoadInt64(&s.last)
+		if event.Event.EventCreatedOn <= last || atomic.CompareAndSwapInt64(&s.last, last, event.Event.EventCreatedOn) {
+			break
+		}
+	}
+
+	// Events stored by other replicas first are published too, but once
+	if !s.seen(b.EventID) {
+		s.publishEvent(event.Event, &b)
+	}
+
+	if !ok {
+		return sink.ErrDuplicate
+	}
+
+	return nil
+}
+
+// dispatch stores the event, and fans it out to the sinks if stored by this replica, i.e., once across the replicas
+func (s *server) dispatch(ctx context.Context, event *sink.Event) error {
+	primary := &storageSink{s: s}
+
+	if s.cfg.Sink != nil {
+		return s.cfg.Sink.Dispatch(ctx, primary, event)
+	}
+
+	if err := primary.Deliver(ctx, event); err != nil && !errors.Is(err, sink.ErrDuplicate) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	natsServer "github.com/nats-io/nats-server/v2/server"
+	natsGo "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/connect/gerrittest"
+	"github.com/gerrittrigger/events/sink"
+	"github.com/gerrittrigger/events/sink/nats"
+	"github.com/gerrittrigger/events/sink/redis"
+)
+
+func initSink(s *server, targets ...sink.Target) {
+	c := sink.DefaultConfig()
+	c.Config = s.cfg.Config
+	c.Logger = s.cfg.Logger
+	c.Sinks = targets
+
+	s.cfg.Sink = sink.New(context.Background(), c)
+}
+
+func initNats(s *server, url string) sink.Target {
+	c := nats.DefaultConfig()
+	c.Config = s.cfg.Config
+	c.Config.Spec.Nats.Url = url
+	c.Logger = s.cfg.Logger
+
+	return sink.Target{Name: "nats", Sink: nats.New(context.Background(), c)}
+}
+
+func initRedis(s *server, url string) sink.Target {
+	c := redis.DefaultConfig()
+	c.Config = s.cfg.Config
+	c.Config.Spec.Redis.Url = url
+	c.Logger = s.cfg.Logger
+
+	return sink.Target{Name: "redis", Sink: redis.New(context.Background(), c)}
+}
+
+func TestNats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ns, err := natsServer.NewServer(&natsServer.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	assert.Equal(t, nil, err)
+
+	go ns.Start()
+	defer ns.Shutdown()
+
+	assert.Equal(t, true, ns.ReadyForConnections(5*time.Second))
+
+	conn, err := natsGo.Connect(ns.ClientURL())
+	assert.Equal(t, nil, err)
+
+	defer conn.Close()
+
+	sub, err := conn.SubscribeSync("gerrit.>")
+	assert.Equal(t, nil, err)
+
+	srv, err := gerrittest.New(gerrittest.Config{})
+	assert.Equal(t, nil, err)
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	filename := filepath.Join(t.TempDir(), name)
+
+	for _, s := range []*server{initReplica(t, srv, filename), initReplica(t, srv, filename)} {
+		initSink(s, initNats(s, ns.ClientURL()))
+		assert.Equal(t, nil, s.Init(ctx))
+		go func() {
+			_ = s.Run(ctx)
+		}()
+	}
+
+	assert.Equal(t, true, waitStreams(srv, 2))
+
+	srv.Push(event)
+	srv.Push(later)
+
+	// Published once by the replica storing the event
+	var b []string
+	for {
+		msg, err := sub.NextMsg(time.Second)
+		if err != nil {
+			break
+		}
+		b = append(b, string(msg.Data))
+	}
+
+	assert.Equal(t, []string{event, later}, b)
+}
+
+func TestRedis(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mr := miniredis.RunT(t)
+
+	s, srv := initGerrit(t)
+
+	// A Redis being down blocks neither the other one nor the storage
+	ns := miniredis.RunT(t)
+	target := initRedis(s, "redis://"+ns.Addr())
+	target.Delivery.BackoffSeconds = 1
+	target.Delivery.Retries = 10
+
+	initSink(s, initRedis(s, "redis://"+mr.Addr()), target)
+
+	assert.Equal(t, nil, s.Init(ctx))
+
+	go func() {
+		_ = s.Run(ctx)
+	}()
+
+	ns.Close()
+
+	assert.Equal(t, true, waitStreams(srv, 1))
+	srv.Push(event)
+	srv.Push(later)
+	assert.Equal(t, true, waitEvents(s, 2))
+
+	assert.Equal(t, true, waitStream(mr, "gerrit:events", 2))
+
+	b, _ := mr.Stream("gerrit:events")
+	assert.Equal(t, event, b[0].Values[9])
+	assert.Equal(t, later, b[1].Values[9])
+}
+
+func waitStream(mr *miniredis.Miniredis, key string, n int) bool {
+	for i := 0; i < 100; i++ {
+		if b, _ := mr.Stream(key); len(b) == n {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
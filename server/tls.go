@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+)
+
+const (
+	reloadPeriod = 10 * time.Second
+)
+
+var (
+	tlsVersions = map[string]uint16{
+		"":    tls.VersionTLS12,
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+)
+
+// certificate to serve TLS with the key pair and client CA reloaded on file change
+type certificate struct {
+	cfg     config.Tls
+	logger  hclog.Logger
+	mutex   sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+func newCertificate(cfg config.Tls, logger hclog.Logger) (*certificate, error) {
+	if _, ok := tlsVersions[cfg.MinVersion]; !ok {
+		return nil, errors.New("invalid min version " + cfg.MinVersion)
+	}
+
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("missing client ca file")
+	}
+
+	c := &certificate{
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *certificate) load() error {
+	t, err := c.lastModified()
+	if err != nil {
+		return errors.Wrap(err, "failed to stat")
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load key pair")
+	}
+
+	var pool *x509.CertPool
+
+	if c.cfg.ClientCAFile != "" {
+		b, err := os.ReadFile(c.cfg.ClientCAFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read client ca")
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return errors.New("invalid client ca")
+		}
+	}
+
+	c.mutex.Lock()
+	c.cert = &cert
+	c.pool = pool
+	c.modTime = t
+	c.mutex.Unlock()
+
+	return nil
+}
+
+func (c *certificate) lastModified() (time.Time, error) {
+	var t time.Time
+
+	for _, item := range []string{c.cfg.CertFile, c.cfg.KeyFile, c.cfg.ClientCAFile} {
+		if item == "" {
+			continue
+		}
+		fi, err := os.Stat(item)
+		if err != nil {
+			return t, err
+		}
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+
+	return t, nil
+}
+
+// reload keeps serving the previous files if the new ones are invalid
+func (c *certificate) reload() {
+	t, err := c.lastModified()
+	if err != nil {
+		c.logger.Error("server: failed to stat certificate", "error", err)
+		return
+	}
+
+	c.mutex.RLock()
+	changed := !t.Equal(c.modTime)
+	c.mutex.RUnlock()
+
+	if !changed {
+		return
+	}
+
+	if err := c.load(); err != nil {
+		c.logger.Error("server: failed to reload certificate", "error", err)
+		return
+	}
+
+	c.logger.Info("server: certificate reloaded")
+}
+
+func (c *certificate) watch(ctx context.Context) {
+	ticker := time.NewTicker(reloadPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.reload()
+		}
+	}
+}
+
+// config negotiates the protos with ALPN, e.g., h2 required by gRPC
+func (c *certificate) config(protos ...string) *tls.Config {
+	v := tlsVersions[c.cfg.MinVersion]
+
+	auth := tls.NoClientCert
+	if c.cfg.ClientCAFile != "" {
+		auth = tls.VerifyClientCertIfGiven
+	}
+
+	if c.cfg.RequireClientCert {
+		auth = tls.RequireAndVerifyClientCert
+	}
+
+	return &tls.Config{
+		MinVersion: v,
+		NextProtos: protos,
+		GetConfigForClient: func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+			c.mutex.RLock()
+			defer c.mutex.RUnlock()
+			return &tls.Config{
+				MinVersion:   v,
+				NextProtos:   protos,
+				Certificates: []tls.Certificate{*c.cert},
+				ClientAuth:   auth,
+				ClientCAs:    c.pool,
+			}, nil
+		},
+	}
+}
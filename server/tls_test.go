@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/gerrittrigger/events/api"
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/config"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, serial int64, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := tmpl, key
+
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	assert.Equal(t, nil, err)
+
+	cert, _ := x509.ParseCertificate(der)
+
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	b, _ := x509.MarshalECPrivateKey(c.key)
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), 0o600)
+
+	return certFile, keyFile
+}
+
+func (c *testCert) pair() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "server",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	ca := newTestCert(t, "ca", 1, nil)
+	caFile, _ := ca.write(t, dir, "ca")
+
+	srv := newTestCert(t, "server", 2, ca)
+	certFile, keyFile := srv.write(t, dir, "server")
+
+	client := newTestCert(t, "ci", 3, ca)
+	unknown := newTestCert(t, "unknown", 4, ca)
+
+	cfg := config.Tls{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		MinVersion:   "1.3",
+		Clients:      []config.Client{{CommonName: "ci", Scopes: []string{auth.ScopeRead}}},
+	}
+
+	_, err := newCertificate(config.Tls{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.0"}, logger)
+	assert.NotEqual(t, nil, err)
+
+	_, err = newCertificate(config.Tls{CertFile: certFile, KeyFile: keyFile, RequireClientCert: true}, logger)
+	assert.NotEqual(t, nil, err)
+
+	_, err = newCertificate(config.Tls{CertFile: "invalid", KeyFile: keyFile}, logger)
+	assert.NotEqual(t, nil, err)
+
+	c, err := newCertificate(cfg, logger)
+	assert.Equal(t, nil, err)
+
+	s := initServer(t)
+	s.cfg.Config.Spec.Server.Tls = cfg
+
+	ac := auth.DefaultConfig()
+	ac.Config = s.cfg.Config
+	ac.Logger = logger
+	s.cfg.Auth = auth.New(context.Background(), ac)
+	_ = s.cfg.Auth.Init(context.Background())
+
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	hs := &nethttp.Server{Handler: s.engine, TLSConfig: c.config(), ReadHeaderTimeout: maxDuration}
+
+	go func() {
+		_ = hs.ServeTLS(l, "", "")
+	}()
+
+	defer func() {
+		_ = hs.Close()
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	get := func(certs []tls.Certificate, version uint16) (*nethttp.Response, error) {
+		cl := &nethttp.Client{Transport: &nethttp.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      pool,
+			Certificates: certs,
+			MaxVersion:   version,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+		return cl.Get("https://" + l.Addr().String() + "/events/?q=")
+	}
+
+	_, err = get(nil, tls.VersionTLS12)
+	assert.NotEqual(t, nil, err)
+
+	rsp, err := get(nil, tls.VersionTLS13)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nethttp.StatusUnauthorized, rsp.StatusCode)
+	assert.Equal(t, big.NewInt(2), rsp.TLS.PeerCertificates[0].SerialNumber)
+	_ = rsp.Body.Close()
+
+	rsp, err = get([]tls.Certificate{unknown.pair()}, tls.VersionTLS13)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nethttp.StatusUnauthorized, rsp.StatusCode)
+	_ = rsp.Body.Close()
+
+	rsp, err = get([]tls.Certificate{client.pair()}, tls.VersionTLS13)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nethttp.StatusNotFound, rsp.StatusCode)
+	_ = rsp.Body.Close()
+
+	_ = os.WriteFile(keyFile, []byte("invalid"), 0o600)
+	c.reload()
+
+	rsp, err = get(nil, tls.VersionTLS13)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, big.NewInt(2), rsp.TLS.PeerCertificates[0].SerialNumber)
+	_ = rsp.Body.Close()
+
+	renewed := newTestCert(t, "server", 5, ca)
+	_, _ = renewed.write(t, dir, "server")
+
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	c.reload()
+
+	rsp, err = get(nil, tls.VersionTLS13)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, big.NewInt(5), rsp.TLS.PeerCertificates[0].SerialNumber)
+	_ = rsp.Body.Close()
+
+	s.certificate = c
+	g := s.initGrpc(context.Background())
+	gl, _ := net.Listen("tcp", "127.0.0.1:0")
+
+	go func() {
+		_ = g.Serve(gl)
+	}()
+
+	defer g.Stop()
+
+	conn, err := grpc.NewClient(gl.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{client.pair()},
+		MinVersion:   tls.VersionTLS13,
+	})))
+	assert.Equal(t, nil, err)
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_, err = api.NewEventsClient(conn).Query(context.Background(), &api.QueryRequest{Since: 1672567200, Until: 1672567300})
+	assert.Equal(t, nil, err)
+}
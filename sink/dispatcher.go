@@ -0,0 +1,238 @@
+package sink
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/trigger"
+)
+
+const (
+	defaultBackoff = time.Second
+	defaultBuffer  = 1000
+	maxBackoff     = time.Minute
+)
+
+var (
+	// ErrDuplicate is returned by the primary sink for events delivered before, which are not fanned out again
+	ErrDuplicate = errors.New("duplicate event")
+)
+
+// Dispatcher to fan out events to the sinks, each with its buffer and workers so that a failing sink does not block the others
+type Dispatcher interface {
+	Init(context.Context) error
+	Deinit(context.Context) error
+	Dispatch(context.Context, Sink, *Event) error
+}
+
+type Config struct {
+	Config  config.Config
+	Logger  hclog.Logger
+	Sinks   []Target
+	Trigger trigger.Trigger
+}
+
+// Target - Sink with its name and delivery settings
+type Target struct {
+	Delivery config.Delivery
+	Name     string
+	Sink     Sink
+}
+
+type target struct {
+	Target
+	events chan *Event
+	mutex  sync.Mutex
+	ready  bool
+}
+
+type dispatcher struct {
+	cfg     *Config
+	mutex   sync.RWMutex
+	stop    chan bool
+	targets []*target
+	wg      sync.WaitGroup
+}
+
+func New(_ context.Context, cfg *Config) Dispatcher {
+	return &dispatcher{
+		cfg: cfg,
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// Init inits the sinks and starts their workers, the sinks failing to init, e.g., whose broker is down,
+// being retried on delivery so that they do not stop the others
+func (d *dispatcher) Init(ctx context.Context) error {
+	d.cfg.Logger.Debug("sink: Init")
+
+	stop := make(chan bool)
+	targets := make([]*target, 0, len(d.cfg.Sinks))
+
+	for _, item := range d.cfg.Sinks {
+		n := item.Delivery.Buffer
+		if n == 0 {
+			n = defaultBuffer
+		}
+		t := &target{Target: item, events: make(chan *Event, n)}
+		if err := t.init(ctx); err != nil {
+			d.cfg.Logger.Error("sink: failed to init, retried on delivery", "sink", t.Name, "error", err)
+		}
+		targets = append(targets, t)
+		// Events may be delivered out of order by concurrent workers
+		for i := 0; i < max(item.Delivery.Concurrency, 1); i++ {
+			d.wg.Add(1)
+			go d.work(ctx, stop, t)
+		}
+	}
+
+	d.mutex.Lock()
+	d.stop = stop
+	d.targets = targets
+	d.mutex.Unlock()
+
+	return nil
+}
+
+// Deinit stops the workers, the events still buffered being dropped, and deinits the sinks
+func (d *dispatcher) Deinit(ctx context.Context) error {
+	d.cfg.Logger.Debug("sink: Deinit")
+
+	d.mutex.Lock()
+	targets := d.targets
+	d.targets = nil
+	if targets != nil {
+		close(d.stop)
+	}
+	d.mutex.Unlock()
+
+	for _, item := range targets {
+		close(item.events)
+	}
+
+	d.wg.Wait()
+
+	for _, item := range targets {
+		if item.ready {
+			_ = item.Sink.Deinit(ctx)
+		}
+	}
+
+	return nil
+}
+
+// Dispatch delivers the event to the primary sink, i.e., the storage, and then queues it to the sinks whose rule matches it,
+// events being dropped by the sinks whose buffer is full
+func (d *dispatcher) Dispatch(ctx context.Context, primary Sink, event *Event) error {
+	d.cfg.Logger.Debug("sink: Dispatch")
+
+	if primary != nil {
+		if err := primary.Deliver(ctx, event); err != nil {
+			if errors.Is(err, ErrDuplicate) {
+				return nil
+			}
+			return errors.Wrap(err, "failed to deliver to primary")
+		}
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	for _, item := range d.targets {
+		if !d.match(ctx, item, event) {
+			continue
+		}
+		select {
+		case item.events <- event:
+		default:
+			d.cfg.Logger.Warn("sink: buffer full, event dropped", "sink", item.Name, "id", event.ID)
+		}
+	}
+
+	return nil
+}
+
+func (d *dispatcher) match(ctx context.Context, t *target, event *Event) bool {
+	if t.Delivery.Rule == "" || d.cfg.Trigger == nil {
+		return true
+	}
+
+	if event.Event == nil {
+		return false
+	}
+
+	ok, _ := d.cfg.Trigger.Match(ctx, t.Delivery.Rule, event.Event)
+
+	return ok
+}
+
+func (d *dispatcher) work(ctx context.Context, stop chan bool, t *target) {
+	defer d.wg.Done()
+
+	for event := range t.events {
+		select {
+		case <-stop:
+			continue
+		default:
+		}
+		if err := d.deliver(ctx, stop, t, event); err != nil {
+			d.cfg.Logger.Error("sink: failed to deliver, event dropped", "sink", t.Name, "id", event.ID, "error", err)
+		}
+	}
+}
+
+// deliver delivers the event, initing the sink first if it failed to, and retrying with exponential backoff on failure
+func (d *dispatcher) deliver(ctx context.Context, stop chan bool, t *target, event *Event) error {
+	backoff := time.Duration(t.Delivery.BackoffSeconds) * time.Second
+	if backoff == 0 {
+		backoff = defaultBackoff
+	}
+
+	var err error
+
+	for i := 0; ; i++ {
+		if err = t.init(ctx); err == nil {
+			if err = t.Sink.Deliver(ctx, event); err == nil {
+				return nil
+			}
+		}
+		if i >= t.Delivery.Retries {
+			return err
+		}
+		d.cfg.Logger.Warn("sink: failed to deliver, retrying", "sink", t.Name, "id", event.ID, "retry", i+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-stop:
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// init inits the sink unless done before
+func (t *target) init(ctx context.Context) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.ready {
+		return nil
+	}
+
+	if err := t.Sink.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to init")
+	}
+
+	t.ready = true
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package sink
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/trigger"
+)
+
+type testSink struct {
+	block    chan bool
+	failures int
+	ids      []string
+	inits    int
+	mutex    sync.Mutex
+}
+
+func (s *testSink) Init(_ context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.inits > 0 {
+		s.inits--
+		return errors.New("failed to init")
+	}
+
+	return nil
+}
+
+func (s *testSink) Deinit(_ context.Context) error {
+	return nil
+}
+
+func (s *testSink) Deliver(_ context.Context, event *Event) error {
+	if s.block != nil {
+		<-s.block
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("failed to deliver")
+	}
+
+	s.ids = append(s.ids, event.ID)
+
+	return nil
+}
+
+func (s *testSink) delivered(n int) []string {
+	for i := 0; i < 100; i++ {
+		s.mutex.Lock()
+		b := append([]string(nil), s.ids...)
+		s.mutex.Unlock()
+		if len(b) >= n {
+			return b
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return nil
+}
+
+func initDispatcher(targets ...Target) *dispatcher {
+	c := DefaultConfig()
+	c.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "sink",
+		Level: hclog.LevelFromString("INFO"),
+	})
+	c.Sinks = targets
+
+	return New(context.Background(), c).(*dispatcher)
+}
+
+func initEvent(id, name string) *Event {
+	return &Event{
+		Event: &events.Event{Type: name},
+		ID:    id,
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	ctx := context.Background()
+
+	primary := &testSink{}
+	s := &testSink{}
+	d := initDispatcher(Target{Name: "test", Sink: s})
+
+	assert.Equal(t, nil, d.Init(ctx))
+
+	assert.Equal(t, nil, d.Dispatch(ctx, primary, initEvent("1", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("2", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, []string{"1", "2"}, s.delivered(2))
+	assert.Equal(t, []string{"1"}, primary.ids)
+
+	// Not fanned out if the primary fails
+	primary.failures = 1
+
+	assert.NotEqual(t, nil, d.Dispatch(ctx, primary, initEvent("3", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, nil, d.Deinit(ctx))
+	assert.Equal(t, []string{"1", "2"}, s.ids)
+
+	// Dropped once stopped
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("4", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, []string{"1", "2"}, s.ids)
+}
+
+func TestDispatchDuplicate(t *testing.T) {
+	ctx := context.Background()
+
+	s := &testSink{}
+	d := initDispatcher(Target{Name: "test", Sink: s})
+
+	assert.Equal(t, nil, d.Init(ctx))
+
+	defer func() {
+		_ = d.Deinit(ctx)
+	}()
+
+	assert.Equal(t, nil, d.Dispatch(ctx, &duplicateSink{}, initEvent("1", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("2", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, []string{"2"}, s.delivered(1))
+}
+
+type duplicateSink struct {
+	testSink
+}
+
+func (s *duplicateSink) Deliver(_ context.Context, _ *Event) error {
+	return ErrDuplicate
+}
+
+func TestDispatchRule(t *testing.T) {
+	ctx := context.Background()
+
+	tc := trigger.DefaultConfig()
+	tc.Config.Spec.Trigger.Rules = []config.Rule{{Name: "merged", Events: []string{events.EVENTS_REF_UPDATED}}}
+	tc.Logger = hclog.NewNullLogger()
+
+	tr := trigger.New(ctx, tc)
+	assert.Equal(t, nil, tr.Init(ctx))
+
+	all := &testSink{}
+	merged := &testSink{}
+
+	d := initDispatcher(Target{Name: "all", Sink: all}, Target{Delivery: config.Delivery{Rule: "merged"}, Name: "merged", Sink: merged})
+	d.cfg.Trigger = tr
+
+	assert.Equal(t, nil, d.Init(ctx))
+
+	defer func() {
+		_ = d.Deinit(ctx)
+	}()
+
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("1", events.EVENTS_PATCHSET_CREATED)))
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("2", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, []string{"1", "2"}, all.delivered(2))
+	assert.Equal(t, []string{"2"}, merged.delivered(1))
+}
+
+func TestDispatchRetry(t *testing.T) {
+	ctx := context.Background()
+
+	retried := &testSink{failures: 1}
+	failed := &testSink{failures: 2}
+
+	d := initDispatcher(
+		Target{Delivery: config.Delivery{Retries: 1}, Name: "retried", Sink: retried},
+		Target{Delivery: config.Delivery{Retries: 1}, Name: "failed", Sink: failed},
+	)
+
+	assert.Equal(t, nil, d.Init(ctx))
+
+	defer func() {
+		_ = d.Deinit(ctx)
+	}()
+
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("1", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("2", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, []string{"1", "2"}, retried.delivered(2))
+
+	// Dropped after 1 retry
+	assert.Equal(t, []string{"2"}, failed.delivered(1))
+}
+
+func TestDispatchInit(t *testing.T) {
+	ctx := context.Background()
+
+	failed := &testSink{inits: 1}
+	s := &testSink{}
+
+	d := initDispatcher(Target{Delivery: config.Delivery{Retries: 1}, Name: "failed", Sink: failed}, Target{Name: "test", Sink: s})
+
+	// Inited again on delivery, without stopping the others
+	assert.Equal(t, nil, d.Init(ctx))
+	assert.Equal(t, false, d.targets[0].ready)
+	assert.Equal(t, true, d.targets[1].ready)
+
+	assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent("1", events.EVENTS_REF_UPDATED)))
+	assert.Equal(t, []string{"1"}, s.delivered(1))
+	assert.Equal(t, []string{"1"}, failed.delivered(1))
+
+	assert.Equal(t, nil, d.Deinit(ctx))
+	assert.Equal(t, nil, d.Deinit(ctx))
+}
+
+func TestDispatchConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	blocked := &testSink{block: make(chan bool)}
+
+	d := initDispatcher(Target{Delivery: config.Delivery{Concurrency: 2}, Name: "blocked", Sink: blocked})
+
+	assert.Equal(t, nil, d.Init(ctx))
+
+	// Both workers deliver while the third event waits in the buffer
+	for _, item := range []string{"1", "2", "3"} {
+		assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent(item, events.EVENTS_REF_UPDATED)))
+	}
+
+	for i := 0; i < 100 && len(d.targets[0].events) != 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, 1, len(d.targets[0].events))
+
+	close(blocked.block)
+
+	assert.Equal(t, 3, len(blocked.delivered(3)))
+	assert.Equal(t, nil, d.Deinit(ctx))
+}
+
+func TestDispatchIsolation(t *testing.T) {
+	ctx := context.Background()
+
+	blocked := &testSink{block: make(chan bool)}
+	s := &testSink{}
+
+	d := initDispatcher(Target{Delivery: config.Delivery{Buffer: 1}, Name: "blocked", Sink: blocked}, Target{Name: "test", Sink: s})
+
+	assert.Equal(t, nil, d.Init(ctx))
+
+	// The blocked sink drops the events beyond its buffer, without blocking the others
+	for _, item := range []string{"1", "2", "3", "4"} {
+		assert.Equal(t, nil, d.Dispatch(ctx, nil, initEvent(item, events.EVENTS_REF_UPDATED)))
+		for item == "1" && len(d.targets[0].events) != 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	assert.Equal(t, []string{"1", "2", "3", "4"}, s.delivered(4))
+
+	blocked.block <- true
+	blocked.block <- true
+	close(blocked.block)
+
+	assert.Equal(t, nil, d.Deinit(ctx))
+	assert.Equal(t, 2, len(blocked.ids))
+}
@@ -0,0 +1,191 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	osExec "os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/sink"
+)
+
+const (
+	defaultTimeout = time.Minute
+	waitDelay      = time.Second
+)
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+// exec - Sink to run a command per event, with the event on stdin and its fields as GERRIT_* variables
+type exec struct {
+	cfg *Config
+}
+
+func New(_ context.Context, cfg *Config) sink.Sink {
+	return &exec{
+		cfg: cfg,
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+func (e *exec) Init(_ context.Context) error {
+	e.cfg.Logger.Debug("exec: Init")
+
+	return nil
+}
+
+func (e *exec) Deinit(_ context.Context) error {
+	e.cfg.Logger.Debug("exec: Deinit")
+
+	return nil
+}
+
+// Deliver runs the command, killed after timeoutSeconds, logging its output line by line,
+// and fails if it exits with a non-zero status so that the delivery is retried
+func (e *exec) Deliver(ctx context.Context, event *sink.Event) error {
+	e.cfg.Logger.Debug("exec: Deliver")
+
+	c := e.cfg.Config.Spec.Exec
+
+	if len(c.Command) == 0 {
+		return nil
+	}
+
+	timeout := time.Duration(c.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	r, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &logWriter{logger: e.cfg.Logger, id: event.ID, name: "stdout"}
+	stderr := &logWriter{logger: e.cfg.Logger, id: event.ID, name: "stderr"}
+
+	cmd := osExec.CommandContext(r, c.Command[0], c.Command[1:]...)
+	cmd.Env = append(os.Environ(), environ(event)...)
+	cmd.Stdin = bytes.NewReader(event.Data)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Not waiting forever for the output of children still running after the command was killed
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+
+	stdout.flush()
+	stderr.flush()
+
+	if r.Err() == context.DeadlineExceeded {
+		return errors.New("timed out after " + timeout.String())
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to run")
+	}
+
+	return nil
+}
+
+// environ returns the fields of the event as variables named after the Gerrit Trigger ones, those empty or zero being skipped
+func environ(event *sink.Event) []string {
+	e := event.Event
+
+	if e == nil {
+		e = &events.Event{}
+		_ = json.Unmarshal(event.Data, e)
+	}
+
+	var project, branch string
+
+	if t, err := events.Decode(event.Data); err == nil {
+		project, branch = t.Project(), t.Branch()
+	}
+
+	vars := []struct {
+		name  string
+		value string
+	}{
+		{"GERRIT_EVENT_ID", event.ID},
+		{"GERRIT_EVENT_TYPE", e.Type},
+		{"GERRIT_EVENT_CREATED_ON", strconv.FormatInt(e.EventCreatedOn, 10)},
+		{"GERRIT_PROJECT", project},
+		{"GERRIT_BRANCH", branch},
+		{"GERRIT_CHANGE_ID", e.Change.ID},
+		{"GERRIT_CHANGE_NUMBER", strconv.Itoa(e.Change.Number)},
+		{"GERRIT_CHANGE_URL", e.Change.URL},
+		{"GERRIT_PATCHSET_NUMBER", strconv.Itoa(e.PatchSet.Number)},
+		{"GERRIT_PATCHSET_REVISION", e.PatchSet.Revision},
+		{"GERRIT_REFNAME", e.RefUpdate.RefName},
+		{"GERRIT_OLDREV", e.RefUpdate.OldRev},
+		{"GERRIT_NEWREV", e.RefUpdate.NewRev},
+	}
+
+	env := make([]string, 0, len(vars))
+
+	for _, item := range vars {
+		if item.value != "" && item.value != "0" {
+			env = append(env, item.name+"="+item.value)
+		}
+	}
+
+	return env
+}
+
+// logWriter logs the output of the command line by line
+type logWriter struct {
+	buf    []byte
+	id     string
+	logger hclog.Logger
+	mutex  sync.Mutex
+	name   string
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.buf = append(l.buf, p...)
+
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.log(l.buf[:i])
+		l.buf = l.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (l *logWriter) flush() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.buf) != 0 {
+		l.log(l.buf)
+		l.buf = nil
+	}
+}
+
+func (l *logWriter) log(line []byte) {
+	if s := strings.TrimRight(string(line), "\r"); s != "" {
+		l.logger.Info("exec: "+l.name, "id", l.id, "line", s)
+	}
+}
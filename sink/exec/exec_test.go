@@ -0,0 +1,98 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/sink"
+)
+
+const (
+	event = `{"type":"patchset-created",` +
+		`"change":{"project":"platform/build","branch":"main","id":"I8473b95934b5732ac55d26311a706c9c2bde9940","number":1000},` +
+		`"patchSet":{"number":2,"revision":"b1fb8d8bf0e5c5a4e1a5e7dfbc5d9d7c0e1b2f3a"},"eventCreatedOn":1672567200}`
+	id = "8c4b4c7a"
+)
+
+func initExec(out *bytes.Buffer, command ...string) *exec {
+	cfg := DefaultConfig()
+	cfg.Config = *config.New()
+	cfg.Config.Spec.Exec.Command = command
+	cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:   "exec",
+		Level:  hclog.LevelFromString("INFO"),
+		Output: out,
+	})
+
+	return &exec{
+		cfg: cfg,
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	var out bytes.Buffer
+
+	e := initExec(&out)
+	assert.Equal(t, nil, e.Init(ctx))
+	assert.Equal(t, nil, e.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+
+	name := filepath.Join(dir, "event.json")
+	script := `cat > ` + name + `; echo "$GERRIT_EVENT_TYPE $GERRIT_PROJECT $GERRIT_BRANCH $GERRIT_CHANGE_NUMBER $GERRIT_PATCHSET_NUMBER"; ` +
+		`echo "$GERRIT_EVENT_ID" >&2; printf "${GERRIT_REFNAME:-none}"`
+
+	e = initExec(&out, "sh", "-c", script)
+	assert.Equal(t, nil, e.Init(ctx))
+	assert.Equal(t, nil, e.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	assert.Equal(t, nil, e.Deinit(ctx))
+
+	buf, err := os.ReadFile(name)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, event, string(buf))
+
+	assert.Equal(t, true, strings.Contains(out.String(), "exec: stdout: id="+id+` line="patchset-created platform/build main 1000 2"`))
+	assert.Equal(t, true, strings.Contains(out.String(), "exec: stderr: id="+id+" line="+id))
+	assert.Equal(t, true, strings.Contains(out.String(), "exec: stdout: id="+id+" line=none"))
+}
+
+func TestDeliverFailure(t *testing.T) {
+	ctx := context.Background()
+
+	var out bytes.Buffer
+
+	e := initExec(&out, "sh", "-c", "exit 3")
+	assert.Equal(t, "failed to run: exit status 3", e.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}).Error())
+
+	e = initExec(&out, filepath.Join(t.TempDir(), "missing"))
+	assert.NotEqual(t, nil, e.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+
+	e = initExec(&out, "sleep", "10")
+	e.cfg.Config.Spec.Exec.TimeoutSeconds = 1
+	assert.Equal(t, "timed out after 1s", e.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}).Error())
+}
+
+func TestEnviron(t *testing.T) {
+	env := environ(&sink.Event{
+		ID:   id,
+		Data: []byte(`{"type":"ref-updated","refUpdate":{"project":"platform/build","refName":"refs/heads/main","newRev":"b1fb8d8b"}}`),
+	})
+
+	assert.Equal(t, []string{
+		"GERRIT_EVENT_ID=" + id,
+		"GERRIT_EVENT_TYPE=ref-updated",
+		"GERRIT_PROJECT=platform/build",
+		"GERRIT_BRANCH=main",
+		"GERRIT_REFNAME=refs/heads/main",
+		"GERRIT_NEWREV=b1fb8d8b",
+	}, env)
+}
@@ -0,0 +1,355 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/sink"
+)
+
+const (
+	currentName  = "events.ndjson"
+	dirPerm      = 0o750
+	filePerm     = 0o600
+	gzipSuffix   = ".gz"
+	namePrefix   = "events-"
+	nameLayout   = "20060102T150405Z"
+	ndjsonSuffix = ".ndjson"
+	tempSuffix   = ".tmp"
+
+	checkPeriod = time.Second
+)
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+// file - Sink to write the raw events to NDJSON files, rotated by size and time to events-<since>-<until>.ndjson(.gz)
+type file struct {
+	cfg    *Config
+	count  int
+	fi     *os.File
+	mutex  sync.Mutex
+	opened time.Time
+	since  int64
+	size   int64
+	stop   chan bool
+	until  int64
+	wg     sync.WaitGroup
+}
+
+func New(_ context.Context, cfg *Config) sink.Sink {
+	return &file{
+		cfg: cfg,
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+func (f *file) Init(_ context.Context) error {
+	f.cfg.Logger.Debug("file: Init")
+
+	dir := f.cfg.Config.Spec.File.Directory
+
+	if dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	if err := f.open(); err != nil {
+		return errors.Wrap(err, "failed to open")
+	}
+
+	if f.cfg.Config.Spec.File.RotateSeconds > 0 {
+		f.stop = make(chan bool)
+		f.wg.Add(1)
+		go f.watch(f.stop)
+	}
+
+	return nil
+}
+
+func (f *file) Deinit(_ context.Context) error {
+	f.cfg.Logger.Debug("file: Deinit")
+
+	if f.stop != nil {
+		close(f.stop)
+		f.wg.Wait()
+		f.stop = nil
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.fi != nil {
+		_ = f.fi.Close()
+		f.fi = nil
+	}
+
+	return nil
+}
+
+// Deliver appends the event to the current file, which is rotated first if it would exceed the size
+func (f *file) Deliver(_ context.Context, event *sink.Event) error {
+	f.cfg.Logger.Debug("file: Deliver")
+
+	if f.cfg.Config.Spec.File.Directory == "" {
+		return nil
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// Reopened if a rotation failed
+	if f.fi == nil {
+		if err := f.open(); err != nil {
+			return errors.Wrap(err, "failed to open")
+		}
+	}
+
+	n := int64(len(event.Data) + 1)
+
+	if m := int64(f.cfg.Config.Spec.File.RotateBytes); m > 0 && f.count != 0 && f.size+n > m {
+		if err := f.rotate(); err != nil {
+			return errors.Wrap(err, "failed to rotate")
+		}
+	}
+
+	if _, err := f.fi.Write(append(append([]byte(nil), event.Data...), '\n')); err != nil {
+		return errors.Wrap(err, "failed to write")
+	}
+
+	f.add(createdOn(event.Data), n)
+
+	return nil
+}
+
+// open opens the current file to append, recovering its range if written before a restart
+func (f *file) open() error {
+	name := filepath.Join(f.cfg.Config.Spec.File.Directory, currentName)
+
+	f.count, f.since, f.until, f.size = 0, 0, 0, 0
+
+	_, err := readFile(name, func(buf []byte) error {
+		f.add(createdOn(buf), int64(len(buf)+1))
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to read")
+	}
+
+	fi, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePerm)
+	if err != nil {
+		return errors.Wrap(err, "failed to open")
+	}
+
+	f.fi = fi
+	f.opened = time.Now()
+
+	return nil
+}
+
+func (f *file) add(t, n int64) {
+	if f.count == 0 || t < f.since {
+		f.since = t
+	}
+
+	if t > f.until {
+		f.until = t
+	}
+
+	f.count++
+	f.size += n
+}
+
+// watch rotates the current file once older than rotateSeconds
+func (f *file) watch(stop chan bool) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+
+	age := time.Duration(f.cfg.Config.Spec.File.RotateSeconds) * time.Second
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			f.mutex.Lock()
+			if f.fi != nil && f.count != 0 && time.Since(f.opened) >= age {
+				if err := f.rotate(); err != nil {
+					f.cfg.Logger.Error("file: failed to rotate", "error", err)
+				}
+			}
+			f.mutex.Unlock()
+		}
+	}
+}
+
+// rotate renames the current file after the range of its events, compresses it if set,
+// adds it to the index, removes the files beyond the retention, and opens a new current file
+func (f *file) rotate() error {
+	f.cfg.Logger.Debug("file: rotate")
+
+	c := f.cfg.Config.Spec.File
+	current := filepath.Join(c.Directory, currentName)
+
+	if err := f.fi.Close(); err != nil {
+		return errors.Wrap(err, "failed to close")
+	}
+
+	f.fi = nil
+
+	name := rangeName(c.Directory, f.since, f.until, c.Compress)
+	plain := strings.TrimSuffix(name, gzipSuffix)
+
+	if err := os.Rename(current, filepath.Join(c.Directory, plain)); err != nil {
+		return errors.Wrap(err, "failed to rename")
+	}
+
+	if c.Compress {
+		if err := compress(filepath.Join(c.Directory, plain), filepath.Join(c.Directory, name)); err != nil {
+			// Listed uncompressed, and compressed again on the next rotation
+			f.cfg.Logger.Warn("file: failed to compress", "name", plain, "error", err)
+			name = plain
+		}
+	}
+
+	sum, err := readFile(filepath.Join(c.Directory, name), func([]byte) error { return nil })
+	if err != nil {
+		return errors.Wrap(err, "failed to hash")
+	}
+
+	i, err := ReadIndex(c.Directory)
+	if err != nil {
+		return err
+	}
+
+	if c.Compress {
+		f.compressIndex(c.Directory, i)
+	}
+
+	i.Files = append(i.Files, Entry{Name: name, Since: f.since, Until: f.until, Count: f.count, Sha256: sum})
+
+	if c.Retention > 0 && len(i.Files) > c.Retention {
+		for _, item := range i.Files[:len(i.Files)-c.Retention] {
+			if err := os.Remove(filepath.Join(c.Directory, item.Name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				f.cfg.Logger.Warn("file: failed to remove", "name", item.Name, "error", err)
+			}
+		}
+		i.Files = i.Files[len(i.Files)-c.Retention:]
+	}
+
+	if err := writeIndex(c.Directory, i); err != nil {
+		return err
+	}
+
+	f.cfg.Logger.Info("file: rotated", "name", name, "count", f.count)
+
+	return f.open()
+}
+
+// compressIndex compresses the files of the index left uncompressed after failing to, and updates their entries
+func (f *file) compressIndex(dir string, i *Index) {
+	for n := range i.Files {
+		e := &i.Files[n]
+		if strings.HasSuffix(e.Name, gzipSuffix) {
+			continue
+		}
+		name := e.Name + gzipSuffix
+		if err := compress(filepath.Join(dir, e.Name), filepath.Join(dir, name)); err != nil {
+			f.cfg.Logger.Warn("file: failed to compress", "name", e.Name, "error", err)
+			continue
+		}
+		e.Name = name
+		sum, err := readFile(filepath.Join(dir, name), func([]byte) error { return nil })
+		if err != nil {
+			f.cfg.Logger.Warn("file: failed to hash", "name", name, "error", err)
+			continue
+		}
+		e.Sha256 = sum
+	}
+}
+
+// rangeName names the file after the creation time of its first and last events, suffixed if taken
+func rangeName(dir string, since, until int64, gz bool) string {
+	base := namePrefix + time.Unix(since, 0).UTC().Format(nameLayout) + "-" + time.Unix(until, 0).UTC().Format(nameLayout)
+
+	suffix := ndjsonSuffix
+	if gz {
+		suffix += gzipSuffix
+	}
+
+	name := base + suffix
+
+	for n := 1; ; n++ {
+		// Taken uncompressed as well, if compressing it failed
+		_, err := os.Stat(filepath.Join(dir, name))
+		_, plainErr := os.Stat(filepath.Join(dir, strings.TrimSuffix(name, gzipSuffix)))
+		if errors.Is(err, os.ErrNotExist) && errors.Is(plainErr, os.ErrNotExist) {
+			return name
+		}
+		name = base + "-" + strconv.Itoa(n) + suffix
+	}
+}
+
+// compress writes the file compressed with gzip, and then removes it
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "failed to open")
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	out, err := os.OpenFile(dst+tempSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, filePerm)
+	if err != nil {
+		return errors.Wrap(err, "failed to create")
+	}
+
+	defer func() {
+		_ = out.Close()
+	}()
+
+	gz := gzip.NewWriter(out)
+
+	if _, err := io.Copy(gz, bufio.NewReader(in)); err != nil {
+		return errors.Wrap(err, "failed to write")
+	}
+
+	if err := gz.Close(); err != nil {
+		return errors.Wrap(err, "failed to compress")
+	}
+
+	if err := out.Sync(); err != nil {
+		return errors.Wrap(err, "failed to sync")
+	}
+
+	if err := os.Rename(dst+tempSuffix, dst); err != nil {
+		return errors.Wrap(err, "failed to rename")
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,198 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/sink"
+)
+
+// 2023-01-01 10:00:00 UTC
+const (
+	createdOn0 = 1672567200
+)
+
+func initFile(dir string) *file {
+	cfg := DefaultConfig()
+	cfg.Config = *config.New()
+	cfg.Config.Spec.File.Directory = dir
+	cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "file",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return &file{
+		cfg: cfg,
+	}
+}
+
+func initEvent(n int) *sink.Event {
+	return &sink.Event{
+		Data: []byte(fmt.Sprintf(`{"type":"ref-updated","eventCreatedOn":%d}`, createdOn0+n)),
+		ID:   fmt.Sprint(n),
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	f := initFile("")
+	assert.Equal(t, nil, f.Init(ctx))
+	assert.Equal(t, nil, f.Deliver(ctx, initEvent(0)))
+
+	f = initFile(dir)
+	assert.Equal(t, nil, f.Init(ctx))
+	assert.Equal(t, nil, f.Deliver(ctx, initEvent(0)))
+	assert.Equal(t, nil, f.Deliver(ctx, initEvent(1)))
+	assert.Equal(t, nil, f.Deinit(ctx))
+
+	buf, err := os.ReadFile(filepath.Join(dir, currentName))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, string(initEvent(0).Data)+"\n"+string(initEvent(1).Data)+"\n", string(buf))
+
+	// The range of the current file is recovered on restart
+	f = initFile(dir)
+	assert.Equal(t, nil, f.Init(ctx))
+	assert.Equal(t, 2, f.count)
+	assert.Equal(t, int64(createdOn0), f.since)
+	assert.Equal(t, int64(createdOn0+1), f.until)
+	assert.Equal(t, int64(len(buf)), f.size)
+	assert.Equal(t, nil, f.Deinit(ctx))
+}
+
+func TestRotateSize(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	f := initFile(dir)
+	f.cfg.Config.Spec.File.RotateBytes = 2 * (len(initEvent(0).Data) + 1)
+	f.cfg.Config.Spec.File.Retention = 2
+
+	assert.Equal(t, nil, f.Init(ctx))
+
+	for i := 0; i < 7; i++ {
+		assert.Equal(t, nil, f.Deliver(ctx, initEvent(i)))
+	}
+
+	assert.Equal(t, nil, f.Deinit(ctx))
+
+	// Rotated every 2 events, the oldest file being removed
+	i, err := ReadIndex(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(i.Files))
+	assert.Equal(t, "events-20230101T100002Z-20230101T100003Z.ndjson", i.Files[0].Name)
+	assert.Equal(t, "events-20230101T100004Z-20230101T100005Z.ndjson", i.Files[1].Name)
+	assert.Equal(t, int64(createdOn0+4), i.Files[1].Since)
+	assert.Equal(t, int64(createdOn0+5), i.Files[1].Until)
+	assert.Equal(t, 2, i.Files[1].Count)
+
+	b, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "events-20230101T100002Z-20230101T100003Z.ndjson"),
+		filepath.Join(dir, "events-20230101T100004Z-20230101T100005Z.ndjson"),
+		filepath.Join(dir, currentName),
+		filepath.Join(dir, indexName),
+	}, b)
+}
+
+func TestRotateTime(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	f := initFile(dir)
+	f.cfg.Config.Spec.File.Compress = true
+	f.cfg.Config.Spec.File.RotateSeconds = 1
+
+	assert.Equal(t, nil, f.Init(ctx))
+
+	defer func() {
+		_ = f.Deinit(ctx)
+	}()
+
+	assert.Equal(t, nil, f.Deliver(ctx, initEvent(0)))
+	assert.Equal(t, nil, f.Deliver(ctx, initEvent(0)))
+
+	var i *Index
+
+	for n := 0; n < 50; n++ {
+		i, _ = ReadIndex(dir)
+		if len(i.Files) != 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	assert.Equal(t, 1, len(i.Files))
+	assert.Equal(t, "events-20230101T100000Z-20230101T100000Z.ndjson.gz", i.Files[0].Name)
+	assert.Equal(t, 2, i.Files[0].Count)
+
+	_, err := os.Stat(filepath.Join(dir, "events-20230101T100000Z-20230101T100000Z.ndjson"))
+	assert.Equal(t, true, os.IsNotExist(err))
+
+	// Names taken are suffixed
+	assert.Equal(t, "events-20230101T100000Z-20230101T100000Z-1.ndjson.gz", rangeName(dir, createdOn0, createdOn0, true))
+	assert.Equal(t, "events-20230101T100000Z-20230101T100000Z.ndjson", rangeName(dir, createdOn0, createdOn0, false))
+}
+
+func TestRotateCompress(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	f := initFile(dir)
+	f.cfg.Config.Spec.File.Compress = true
+	f.cfg.Config.Spec.File.RotateBytes = 2 * (len(initEvent(0).Data) + 1)
+
+	// Compressing the first file fails as its temporary file is taken
+	temp := filepath.Join(dir, "events-20230101T100000Z-20230101T100001Z.ndjson.gz"+tempSuffix)
+	assert.Equal(t, nil, os.MkdirAll(filepath.Join(temp, "taken"), dirPerm))
+
+	assert.Equal(t, nil, f.Init(ctx))
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, nil, f.Deliver(ctx, initEvent(i)))
+	}
+
+	i, err := ReadIndex(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(i.Files))
+	assert.Equal(t, "events-20230101T100000Z-20230101T100001Z.ndjson", i.Files[0].Name)
+	assert.Equal(t, 2, i.Files[0].Count)
+
+	// Compressed on the next rotation
+	assert.Equal(t, nil, os.RemoveAll(temp))
+
+	for i := 3; i < 5; i++ {
+		assert.Equal(t, nil, f.Deliver(ctx, initEvent(i)))
+	}
+
+	assert.Equal(t, nil, f.Deinit(ctx))
+
+	i, err = ReadIndex(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(i.Files))
+	assert.Equal(t, "events-20230101T100000Z-20230101T100001Z.ndjson.gz", i.Files[0].Name)
+	assert.Equal(t, "events-20230101T100002Z-20230101T100003Z.ndjson.gz", i.Files[1].Name)
+
+	b, _ := filepath.Glob(filepath.Join(dir, "events-*"))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "events-20230101T100000Z-20230101T100001Z.ndjson.gz"),
+		filepath.Join(dir, "events-20230101T100002Z-20230101T100003Z.ndjson.gz"),
+	}, b)
+
+	// Read with the checksums of the compressed files
+	n := 0
+	assert.Equal(t, nil, Read(dir, createdOn0, createdOn0+5, func([]byte) error {
+		n++
+		return nil
+	}))
+	assert.Equal(t, 5, n)
+}
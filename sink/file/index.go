@@ -0,0 +1,175 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	indexName    = "index.json"
+	indexVersion = "v1"
+)
+
+// Index - Files rotated by the sink, from the oldest, written to index.json in the directory
+type Index struct {
+	Version string  `json:"version"`
+	Files   []Entry `json:"files"`
+}
+
+// Entry - Rotated file, with the creation time of its first and last events
+type Entry struct {
+	Name   string `json:"name"`
+	Since  int64  `json:"since"`
+	Until  int64  `json:"until"`
+	Count  int    `json:"count"`
+	Sha256 string `json:"sha256"`
+}
+
+// ReadIndex reads the index of the directory, which is empty if nothing was rotated yet
+func ReadIndex(dir string) (*Index, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, indexName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Index{Version: indexVersion}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read index")
+	}
+
+	i := &Index{}
+
+	if err := json.Unmarshal(buf, i); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal index")
+	}
+
+	if i.Version != indexVersion {
+		return nil, errors.New("invalid version " + i.Version)
+	}
+
+	return i, nil
+}
+
+// writeIndex replaces the index at once, so that readers never see it partially written
+func writeIndex(dir string, i *Index) error {
+	buf, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal index")
+	}
+
+	name := filepath.Join(dir, indexName)
+
+	if err := os.WriteFile(name+tempSuffix, append(buf, '\n'), filePerm); err != nil {
+		return errors.Wrap(err, "failed to write index")
+	}
+
+	if err := os.Rename(name+tempSuffix, name); err != nil {
+		return errors.Wrap(err, "failed to rename index")
+	}
+
+	return nil
+}
+
+// Read calls fn with the events of the directory created between since and until, from the rotated files
+// overlapping the range, checked against their SHA-256, and then from the current file
+func Read(dir string, since, until int64, fn func([]byte) error) error {
+	i, err := ReadIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	filter := func(buf []byte) error {
+		if t := createdOn(buf); t >= since && t < until {
+			return fn(buf)
+		}
+		return nil
+	}
+
+	for _, item := range i.Files {
+		if item.Until < since || item.Since >= until {
+			continue
+		}
+		sum, err := readFile(filepath.Join(dir, item.Name), filter)
+		if err != nil {
+			return errors.Wrap(err, "failed to read "+item.Name)
+		}
+		if sum != item.Sha256 {
+			return errors.New("invalid checksum of " + item.Name)
+		}
+	}
+
+	if _, err := readFile(filepath.Join(dir, currentName), filter); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to read "+currentName)
+	}
+
+	return nil
+}
+
+// readFile calls fn with the lines of the file, decompressed if its name ends with .gz, and returns its SHA-256
+func readFile(name string, fn func([]byte) error) (string, error) {
+	fi, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = fi.Close()
+	}()
+
+	h := sha256.New()
+
+	var r io.Reader = io.TeeReader(fi, h)
+
+	if strings.HasSuffix(name, gzipSuffix) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to decompress")
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+
+	for {
+		buf, err := br.ReadBytes('\n')
+		if line := strings.TrimSpace(string(buf)); line != "" {
+			if err := fn([]byte(line)); err != nil {
+				return "", err
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", errors.Wrap(err, "failed to read")
+		}
+	}
+
+	// Trailing bytes of the file not read by the decompressor
+	if _, err := io.Copy(h, fi); err != nil {
+		return "", errors.Wrap(err, "failed to read")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// createdOn returns the creation time of the event, or 0 if invalid
+func createdOn(buf []byte) int64 {
+	var e struct {
+		EventCreatedOn int64 `json:"eventCreatedOn"`
+	}
+
+	if err := json.Unmarshal(buf, &e); err != nil {
+		return 0
+	}
+
+	return e.EventCreatedOn
+}
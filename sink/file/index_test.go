@@ -0,0 +1,80 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadIndex(t *testing.T) {
+	dir := t.TempDir()
+
+	i, err := ReadIndex(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Index{Version: indexVersion}, i)
+
+	i.Files = []Entry{{Name: "events.ndjson", Count: 1}}
+	assert.Equal(t, nil, writeIndex(dir, i))
+
+	n, err := ReadIndex(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, i, n)
+
+	_ = os.WriteFile(filepath.Join(dir, indexName), []byte(`{"version":"v0"}`), filePerm)
+
+	_, err = ReadIndex(dir)
+	assert.Equal(t, "invalid version v0", err.Error())
+}
+
+func TestRead(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	f := initFile(dir)
+	f.cfg.Config.Spec.File.Compress = true
+	f.cfg.Config.Spec.File.RotateBytes = 2 * (len(initEvent(0).Data) + 1)
+
+	assert.Equal(t, nil, f.Init(ctx))
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, nil, f.Deliver(ctx, initEvent(i)))
+	}
+
+	assert.Equal(t, nil, f.Deinit(ctx))
+
+	read := func(since, until int64) ([]string, error) {
+		var b []string
+		err := Read(dir, since, until, func(buf []byte) error {
+			b = append(b, string(buf))
+			return nil
+		})
+		return b, err
+	}
+
+	// From the rotated files and the current one
+	b, err := read(createdOn0+1, createdOn0+5)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{
+		string(initEvent(1).Data),
+		string(initEvent(2).Data),
+		string(initEvent(3).Data),
+		string(initEvent(4).Data),
+	}, b)
+
+	b, err = read(0, createdOn0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(b))
+
+	// Checked against the index
+	i, _ := ReadIndex(dir)
+	_ = os.WriteFile(filepath.Join(dir, i.Files[1].Name), []byte("{}\n"), filePerm)
+
+	_, err = read(createdOn0, createdOn0+2)
+	assert.Equal(t, nil, err)
+
+	_, err = read(createdOn0, createdOn0+5)
+	assert.NotEqual(t, nil, err)
+}
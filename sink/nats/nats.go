@@ -0,0 +1,262 @@
+package nats
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	natsGo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/sink"
+)
+
+const (
+	clientName    = "events"
+	defaultServer = "gerrit"
+	defaultPrefix = "gerrit"
+	placeholder   = "_"
+	timeout       = 10 * time.Second
+)
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+// nats - Sink to publish events to subjects derived from them, i.e., <subject>.<server>.<project>.<type>
+type nats struct {
+	cfg   *Config
+	conn  *natsGo.Conn
+	js    jetstream.JetStream
+	mutex sync.Mutex
+}
+
+func New(_ context.Context, cfg *Config) sink.Sink {
+	return &nats{
+		cfg: cfg,
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+func (n *nats) Init(ctx context.Context) error {
+	n.cfg.Logger.Debug("nats: Init")
+
+	c := n.cfg.Config.Spec.Nats
+
+	if c.Url == "" {
+		return nil
+	}
+
+	// Connected in the background if the server is unreachable, events published meanwhile being buffered or retried
+	opts := []natsGo.Option{
+		natsGo.Name(clientName),
+		natsGo.MaxReconnects(-1),
+		natsGo.RetryOnFailedConnect(true),
+		natsGo.ConnectHandler(func(conn *natsGo.Conn) {
+			n.cfg.Logger.Info("nats: connected", "url", conn.ConnectedUrlRedacted())
+		}),
+		natsGo.DisconnectErrHandler(func(_ *natsGo.Conn, err error) {
+			if err != nil {
+				n.cfg.Logger.Warn("nats: disconnected", "error", err)
+			}
+		}),
+		natsGo.ReconnectHandler(func(conn *natsGo.Conn) {
+			n.cfg.Logger.Info("nats: reconnected", "url", conn.ConnectedUrlRedacted())
+		}),
+	}
+
+	if c.CredentialsFile != "" {
+		opts = append(opts, natsGo.UserCredentials(c.CredentialsFile))
+	}
+
+	if c.Token != "" {
+		opts = append(opts, natsGo.Token(string(c.Token)))
+	}
+
+	conn, err := natsGo.Connect(c.Url, opts...)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect")
+	}
+
+	n.conn = conn
+
+	if !conn.IsConnected() {
+		n.cfg.Logger.Warn("nats: failed to connect, retried in the background", "url", c.Url)
+		return nil
+	}
+
+	if c.JetStream.Stream == "" {
+		return nil
+	}
+
+	if err := n.initStream(ctx); err != nil {
+		n.conn.Close()
+		n.conn = nil
+		return errors.Wrap(err, "failed to init stream")
+	}
+
+	return nil
+}
+
+func (n *nats) Deinit(_ context.Context) error {
+	n.cfg.Logger.Debug("nats: Deinit")
+
+	if n.conn != nil {
+		_ = n.conn.Drain()
+		n.conn = nil
+	}
+
+	n.mutex.Lock()
+	n.js = nil
+	n.mutex.Unlock()
+
+	return nil
+}
+
+// Deliver publishes the event, deduplicated by JetStream within the duplicate window with its ID
+func (n *nats) Deliver(ctx context.Context, event *sink.Event) error {
+	n.cfg.Logger.Debug("nats: Deliver")
+
+	if n.conn == nil {
+		return nil
+	}
+
+	e, err := events.Decode(event.Data)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode event")
+	}
+
+	msg := natsGo.NewMsg(n.subject(e.Project(), e.Type()))
+	msg.Data = event.Data
+
+	js, err := n.stream(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to init stream")
+	}
+
+	if js == nil {
+		if event.ID != "" {
+			msg.Header.Set(natsGo.MsgIdHdr, event.ID)
+		}
+		if err := n.conn.PublishMsg(msg); err != nil {
+			return errors.Wrap(err, "failed to publish")
+		}
+		return nil
+	}
+
+	var opts []jetstream.PublishOpt
+
+	if event.ID != "" {
+		opts = append(opts, jetstream.WithMsgID(event.ID))
+	}
+
+	c, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ack, err := js.PublishMsg(c, msg, opts...)
+	if err != nil {
+		return errors.Wrap(err, "failed to publish")
+	}
+
+	if ack.Duplicate {
+		n.cfg.Logger.Debug("nats: duplicate", "id", event.ID)
+	}
+
+	return nil
+}
+
+// stream returns the JetStream context, the stream being created once connected if unreachable on init
+func (n *nats) stream(ctx context.Context) (jetstream.JetStream, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.js != nil || n.cfg.Config.Spec.Nats.JetStream.Stream == "" {
+		return n.js, nil
+	}
+
+	if !n.conn.IsConnected() {
+		return nil, errors.New("not connected")
+	}
+
+	if err := n.initStream(ctx); err != nil {
+		return nil, err
+	}
+
+	return n.js, nil
+}
+
+// initStream creates the stream capturing the subjects unless it exists, which is kept as configured on the server
+func (n *nats) initStream(ctx context.Context) error {
+	js, err := jetstream.New(n.conn)
+	if err != nil {
+		return errors.Wrap(err, "failed to init jetstream")
+	}
+
+	c, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	name := n.cfg.Config.Spec.Nats.JetStream.Stream
+
+	_, err = js.Stream(c, name)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = js.CreateStream(c, jetstream.StreamConfig{
+			Name:       name,
+			Subjects:   []string{n.prefix() + ".>"},
+			Duplicates: time.Duration(n.cfg.Config.Spec.Nats.JetStream.DuplicateWindowSeconds) * time.Second,
+		})
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to get stream "+name)
+	}
+
+	n.js = js
+
+	return nil
+}
+
+func (n *nats) prefix() string {
+	if n.cfg.Config.Spec.Nats.Subject != "" {
+		return n.cfg.Config.Spec.Nats.Subject
+	}
+
+	return defaultPrefix
+}
+
+func (n *nats) subject(project, name string) string {
+	server := n.cfg.Config.Spec.Nats.Server
+
+	if server == "" {
+		server = n.cfg.Config.Spec.Connect.Hostname
+	}
+
+	if server == "" {
+		server = defaultServer
+	}
+
+	return strings.Join([]string{n.prefix(), token(server), token(project), token(name)}, ".")
+}
+
+// token replaces the separators and wildcards of subjects, e.g., the dots of hostnames, the slashes of projects being kept
+func token(s string) string {
+	if s == "" {
+		return placeholder
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
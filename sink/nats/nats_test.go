@@ -0,0 +1,209 @@
+package nats
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	natsServer "github.com/nats-io/nats-server/v2/server"
+	natsGo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/sink"
+)
+
+const (
+	event = `{"type":"ref-updated","refUpdate":{"project":"platform/build","refName":"refs/heads/main"},"eventCreatedOn":1672567200}`
+	id    = "8c4b4c7a"
+)
+
+func initServer(t *testing.T) *natsServer.Server {
+	return initServerPort(t, -1)
+}
+
+func initServerPort(t *testing.T, port int) *natsServer.Server {
+	s, err := natsServer.NewServer(&natsServer.Options{
+		Host:      "127.0.0.1",
+		Port:      port,
+		JetStream: true,
+		NoLog:     true,
+		NoSigs:    true,
+		StoreDir:  t.TempDir(),
+	})
+	assert.Equal(t, nil, err)
+
+	go s.Start()
+
+	assert.Equal(t, true, s.ReadyForConnections(5*time.Second))
+
+	t.Cleanup(s.Shutdown)
+
+	return s
+}
+
+func initNats(url string) *nats {
+	cfg := DefaultConfig()
+	cfg.Config = *config.New()
+	cfg.Config.Spec.Connect.Hostname = "gerrit.example.com"
+	cfg.Config.Spec.Nats.Url = url
+	cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "nats",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return &nats{
+		cfg: cfg,
+	}
+}
+
+func TestSubject(t *testing.T) {
+	n := initNats("")
+
+	assert.Equal(t, "gerrit.gerrit_example_com.platform/build.ref-updated", n.subject("platform/build", "ref-updated"))
+	assert.Equal(t, "gerrit.gerrit_example_com._.project-created", n.subject("", "project-created"))
+
+	n.cfg.Config.Spec.Nats.Server = "review"
+	n.cfg.Config.Spec.Nats.Subject = "ci.events"
+
+	assert.Equal(t, "ci.events.review.a_b_c_.patchset-created", n.subject("a.b*c>", "patchset-created"))
+}
+
+func TestDeliver(t *testing.T) {
+	s := initServer(t)
+
+	n := initNats("")
+	ctx := context.Background()
+
+	assert.Equal(t, nil, n.Init(ctx))
+	assert.Equal(t, nil, n.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+
+	n = initNats(s.ClientURL())
+
+	conn, err := natsGo.Connect(s.ClientURL())
+	assert.Equal(t, nil, err)
+
+	defer conn.Close()
+
+	sub, err := conn.SubscribeSync("gerrit.>")
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, nil, n.Init(ctx))
+	assert.Equal(t, nil, n.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+
+	msg, err := sub.NextMsg(5 * time.Second)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "gerrit.gerrit_example_com.platform/build.ref-updated", msg.Subject)
+	assert.Equal(t, event, string(msg.Data))
+	assert.Equal(t, id, msg.Header.Get(natsGo.MsgIdHdr))
+
+	assert.NotEqual(t, nil, n.Deliver(ctx, &sink.Event{ID: id, Data: []byte("{")}))
+	assert.Equal(t, nil, n.Deinit(ctx))
+}
+
+func TestDeliverJetStream(t *testing.T) {
+	s := initServer(t)
+
+	n := initNats(s.ClientURL())
+	n.cfg.Config.Spec.Nats.JetStream.Stream = "GERRIT"
+	n.cfg.Config.Spec.Nats.JetStream.DuplicateWindowSeconds = 60
+
+	ctx := context.Background()
+
+	assert.Equal(t, nil, n.Init(ctx))
+
+	// Published twice, e.g., by two replicas
+	assert.Equal(t, nil, n.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	assert.Equal(t, nil, n.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	assert.Equal(t, nil, n.Deliver(ctx, &sink.Event{ID: id + "0", Data: []byte(event)}))
+
+	conn, err := natsGo.Connect(s.ClientURL())
+	assert.Equal(t, nil, err)
+
+	defer conn.Close()
+
+	js, err := jetstream.New(conn)
+	assert.Equal(t, nil, err)
+
+	stream, err := js.Stream(ctx, "GERRIT")
+	assert.Equal(t, nil, err)
+
+	info, err := stream.Info(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"gerrit.>"}, info.Config.Subjects)
+	assert.Equal(t, time.Minute, info.Config.Duplicates)
+	assert.Equal(t, uint64(2), info.State.Msgs)
+
+	msg, err := stream.GetMsg(ctx, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "gerrit.gerrit_example_com.platform/build.ref-updated", msg.Subject)
+	assert.Equal(t, event, string(msg.Data))
+
+	assert.Equal(t, nil, n.Deinit(ctx))
+
+	// The existing stream is kept
+	n.cfg.Config.Spec.Nats.JetStream.DuplicateWindowSeconds = 0
+
+	assert.Equal(t, nil, n.Init(ctx))
+	assert.Equal(t, nil, n.Deinit(ctx))
+
+	info, err = stream.Info(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, time.Minute, info.Config.Duplicates)
+}
+
+func TestInitInvalid(t *testing.T) {
+	s := initServer(t)
+
+	n := initNats("nats://invalid url")
+	ctx := context.Background()
+
+	assert.NotEqual(t, nil, n.Init(ctx))
+
+	n = initNats(s.ClientURL())
+	n.cfg.Config.Spec.Nats.CredentialsFile = "/path/to/invalid.creds"
+
+	assert.NotEqual(t, nil, n.Init(ctx))
+}
+
+func TestInitUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+
+	port := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	n := initNats("nats://127.0.0.1:" + strconv.Itoa(port))
+	n.cfg.Config.Spec.Nats.JetStream.Stream = "GERRIT"
+
+	ctx := context.Background()
+
+	// Not stopping the server when the broker is down, the delivery being retried
+	assert.Equal(t, nil, n.Init(ctx))
+	assert.Equal(t, "failed to init stream: not connected", n.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}).Error())
+
+	s := initServerPort(t, port)
+
+	for i := 0; i < 100 && !n.conn.IsConnected(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	assert.Equal(t, nil, n.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	assert.Equal(t, nil, n.Deinit(ctx))
+
+	conn, err := natsGo.Connect(s.ClientURL())
+	assert.Equal(t, nil, err)
+
+	defer conn.Close()
+
+	js, _ := jetstream.New(conn)
+	stream, err := js.Stream(ctx, "GERRIT")
+	assert.Equal(t, nil, err)
+
+	info, _ := stream.Info(ctx)
+	assert.Equal(t, uint64(1), info.State.Msgs)
+}
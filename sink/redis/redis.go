@@ -0,0 +1,140 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	redisGo "github.com/redis/go-redis/v9"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+	"github.com/gerrittrigger/events/sink"
+)
+
+const (
+	defaultStream = "gerrit:events"
+	layoutProject = "project"
+	separator     = ":"
+	timeout       = 10 * time.Second
+)
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+// redis - Sink to add events to streams, i.e., <stream> or <stream>:<project> with the project layout
+type redis struct {
+	cfg    *Config
+	client *redisGo.Client
+}
+
+func New(_ context.Context, cfg *Config) sink.Sink {
+	return &redis{
+		cfg: cfg,
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+func (r *redis) Init(ctx context.Context) error {
+	r.cfg.Logger.Debug("redis: Init")
+
+	c := r.cfg.Config.Spec.Redis
+
+	if c.Url == "" {
+		return nil
+	}
+
+	opts, err := redisGo.ParseURL(c.Url)
+	if err != nil {
+		return errors.Wrap(err, "invalid url")
+	}
+
+	if c.Password != "" {
+		opts.Password = string(c.Password)
+	}
+
+	client := redisGo.NewClient(opts)
+
+	p, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Not stopping the server when Redis is down, deliveries failing meanwhile being retried
+	if err := client.Ping(p).Err(); err != nil {
+		r.cfg.Logger.Warn("redis: failed to ping", "error", err)
+	}
+
+	r.client = client
+
+	return nil
+}
+
+func (r *redis) Deinit(_ context.Context) error {
+	r.cfg.Logger.Debug("redis: Deinit")
+
+	if r.client != nil {
+		_ = r.client.Close()
+		r.client = nil
+	}
+
+	return nil
+}
+
+// Deliver adds the event with its ID, type, project and branch, trimming the stream to about maxLen entries if set
+func (r *redis) Deliver(ctx context.Context, event *sink.Event) error {
+	r.cfg.Logger.Debug("redis: Deliver")
+
+	if r.client == nil {
+		return nil
+	}
+
+	e, err := events.Decode(event.Data)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode event")
+	}
+
+	args := redisGo.XAddArgs{
+		Stream: r.stream(e.Project()),
+		MaxLen: int64(r.cfg.Config.Spec.Redis.MaxLen),
+		Approx: true,
+		Values: []string{
+			"id", event.ID,
+			"type", e.Type(),
+			"project", e.Project(),
+			"branch", e.Branch(),
+			"event", string(event.Data),
+		},
+	}
+
+	c, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := r.client.XAdd(c, &args).Err(); err != nil {
+		return errors.Wrap(err, "failed to add")
+	}
+
+	return nil
+}
+
+func (r *redis) stream(project string) string {
+	name := r.cfg.Config.Spec.Redis.Stream
+
+	if name == "" {
+		name = defaultStream
+	}
+
+	if r.cfg.Config.Spec.Redis.Layout != layoutProject {
+		return name
+	}
+
+	if project == "" {
+		return name
+	}
+
+	return name + separator + project
+}
@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/sink"
+)
+
+const (
+	event   = `{"type":"ref-updated","refUpdate":{"project":"platform/build","refName":"refs/heads/main"},"eventCreatedOn":1672567200}`
+	created = `{"type":"project-created","projectName":"platform/tools","projectHead":"refs/heads/main","eventCreatedOn":1672567201}`
+	id      = "8c4b4c7a"
+)
+
+func initRedis(url string) *redis {
+	cfg := DefaultConfig()
+	cfg.Config = *config.New()
+	cfg.Config.Spec.Redis.Url = url
+	cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "redis",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return &redis{
+		cfg: cfg,
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := initRedis("")
+
+	assert.Equal(t, "gerrit:events", r.stream("platform/build"))
+
+	r.cfg.Config.Spec.Redis.Layout = "project"
+	r.cfg.Config.Spec.Redis.Stream = "events"
+
+	assert.Equal(t, "events:platform/build", r.stream("platform/build"))
+	assert.Equal(t, "events", r.stream(""))
+}
+
+func TestDeliver(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	r := initRedis("")
+	ctx := context.Background()
+
+	assert.Equal(t, nil, r.Init(ctx))
+	assert.Equal(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+
+	r = initRedis("redis://" + s.Addr())
+
+	assert.Equal(t, nil, r.Init(ctx))
+	assert.Equal(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+
+	b, err := s.Stream("gerrit:events")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, []string{
+		"id", id,
+		"type", "ref-updated",
+		"project", "platform/build",
+		"branch", "main",
+		"event", event,
+	}, b[0].Values)
+
+	assert.NotEqual(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte("{")}))
+	assert.Equal(t, nil, r.Deinit(ctx))
+}
+
+func TestDeliverLayout(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	r := initRedis("redis://" + s.Addr())
+	r.cfg.Config.Spec.Redis.Layout = "project"
+	r.cfg.Config.Spec.Redis.MaxLen = 2
+
+	ctx := context.Background()
+
+	assert.Equal(t, nil, r.Init(ctx))
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	}
+
+	assert.Equal(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(created)}))
+
+	b, _ := s.Stream("gerrit:events:platform/build")
+	assert.Equal(t, 2, len(b))
+
+	b, _ = s.Stream("gerrit:events:platform/tools")
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, "project-created", b[0].Values[3])
+
+	assert.Equal(t, nil, r.Deinit(ctx))
+}
+
+func TestInitInvalid(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireAuth("s3cret")
+
+	ctx := context.Background()
+
+	r := initRedis("http://" + s.Addr())
+	assert.NotEqual(t, nil, r.Init(ctx))
+
+	r = initRedis("redis://" + s.Addr())
+	assert.Equal(t, nil, r.Init(ctx))
+	assert.NotEqual(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	assert.Equal(t, nil, r.Deinit(ctx))
+
+	r.cfg.Config.Spec.Redis.Password = "s3cret"
+	assert.Equal(t, nil, r.Init(ctx))
+	assert.Equal(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	assert.Equal(t, nil, r.Deinit(ctx))
+}
+
+func TestInitUnreachable(t *testing.T) {
+	s := miniredis.RunT(t)
+	addr := s.Addr()
+	s.Close()
+
+	r := initRedis("redis://" + addr)
+	ctx := context.Background()
+
+	// Not stopping the server when Redis is down, the delivery being retried
+	assert.Equal(t, nil, r.Init(ctx))
+	assert.NotEqual(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+
+	assert.Equal(t, nil, s.Restart())
+	assert.Equal(t, nil, r.Deliver(ctx, &sink.Event{ID: id, Data: []byte(event)}))
+	assert.Equal(t, nil, r.Deinit(ctx))
+
+	b, err := s.Stream("gerrit:events")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+}
@@ -0,0 +1,21 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/gerrittrigger/events/events"
+)
+
+// Sink to deliver events to, e.g., the storage, NATS or Redis
+type Sink interface {
+	Init(context.Context) error
+	Deinit(context.Context) error
+	Deliver(context.Context, *Event) error
+}
+
+// Event - Event to deliver, with its ID and raw JSON
+type Event struct {
+	Data  []byte
+	Event *events.Event
+	ID    string
+}
@@ -2,7 +2,11 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -10,13 +14,16 @@ import (
 	"github.com/robfig/cron/v3"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
 )
 
 const (
 	BatchSize    = 100
 	PrimaryKey   = "event_created_on"
+	busyTimeout  = 5000
 	timeLocation = "Local"
 )
 
@@ -25,8 +32,20 @@ type Storage interface {
 	Deinit(context.Context) error
 	Create(context.Context, []Model) error
 	Delete(context.Context, int64, int64) error
+	Insert(context.Context, *Model) (bool, error)
 	Read(context.Context, int64, int64) ([]Model, error)
+	Purge(context.Context) (int64, error)
+	ReadPage(context.Context, int64, int64, uint, int) ([]Model, error)
+	ReadLastID(context.Context) (uint, error)
+	Reload(context.Context, *config.Config) error
 	Update(context.Context, *Model) error
+	CreateGap(context.Context, *Gap) error
+	ReadGap(context.Context, int64, int64) ([]Gap, error)
+	ReadPendingGap(context.Context) ([]Gap, error)
+	UpdateGap(context.Context, *Gap) error
+	AcquireLease(context.Context, string, string, time.Duration) (bool, error)
+	ReadLease(context.Context, string) (*Lease, error)
+	ReleaseLease(context.Context, string, string) error
 }
 
 type Config struct {
@@ -38,11 +57,30 @@ type Model struct {
 	gorm.Model
 	EventBase64    string `json:"event_base64"`
 	EventCreatedOn int64  `json:"event_created_on"`
+	EventID        string `gorm:"uniqueIndex;default:null" json:"event_id,omitempty"`
+}
+
+// Gap - Window in which events were dropped by Gerrit, unrecoverable if it cannot be replayed, e.g., without the events-log plugin
+type Gap struct {
+	gorm.Model
+	Since         int64 `json:"since"`
+	Until         int64 `json:"until"`
+	Replayed      bool  `json:"replayed"`
+	Unrecoverable bool  `json:"unrecoverable"`
+}
+
+// Lease - Leadership held by a replica sharing the database, until expired unless renewed
+type Lease struct {
+	Name    string `gorm:"primaryKey" json:"name"`
+	Holder  string `json:"holder"`
+	Expires int64  `json:"expires"`
 }
 
 type storage struct {
 	cfg      *Config
+	cron     *cron.Cron
 	database *gorm.DB
+	mutex    sync.Mutex
 }
 
 func New(_ context.Context, cfg *Config) Storage {
@@ -61,16 +99,21 @@ func (s *storage) Init(ctx context.Context) error {
 
 	var err error
 
-	s.database, err = gorm.Open(sqlite.Open(s.cfg.Config.Spec.Storage.Sqlite.Filename), &gorm.Config{})
+	s.database, err = gorm.Open(sqlite.Open(dsn(s.cfg.Config.Spec.Storage.Sqlite.Filename)), &gorm.Config{})
 	if err != nil {
 		return errors.Wrap(err, "failed to connect database")
 	}
 
-	if err = s.database.AutoMigrate(&Model{}); err != nil {
+	if err = s.database.AutoMigrate(&Model{}, &Gap{}, &Lease{}); err != nil {
 		_ = s.Deinit(ctx)
 		return errors.Wrap(err, "failed to migrate database")
 	}
 
+	if err = s.migrateEventID(); err != nil {
+		_ = s.Deinit(ctx)
+		return errors.Wrap(err, "failed to migrate event id")
+	}
+
 	if s.cfg.Config.Spec.Storage.Autoclean != "" {
 		if err := s.autoclean(ctx); err != nil {
 			return errors.Wrap(err, "failed to autoclean database")
@@ -83,6 +126,13 @@ func (s *storage) Init(ctx context.Context) error {
 func (s *storage) Deinit(_ context.Context) error {
 	s.cfg.Logger.Debug("storage: Deinit")
 
+	s.mutex.Lock()
+	if s.cron != nil {
+		s.cron.Stop()
+		s.cron = nil
+	}
+	s.mutex.Unlock()
+
 	if s.database == nil {
 		return nil
 	}
@@ -94,6 +144,55 @@ func (s *storage) Deinit(_ context.Context) error {
 	return nil
 }
 
+// migrateEventID sets the event ID of the events stored before it was added,
+// those invalid or duplicated being left without
+func (s *storage) migrateEventID() error {
+	var b []Model
+
+	r := s.database.Unscoped().Where("event_id IS NULL").FindInBatches(&b, BatchSize, func(_ *gorm.DB, _ int) error {
+		ids := make([]string, len(b))
+		keys := make([]string, 0, len(b))
+
+		for i := range b {
+			ids[i] = EventID(b[i].EventBase64)
+			if ids[i] != "" {
+				keys = append(keys, ids[i])
+			}
+		}
+
+		if len(keys) == 0 {
+			return nil
+		}
+
+		var stored []string
+
+		if r := s.database.Unscoped().Model(&Model{}).Where("event_id IN ?", keys).Pluck("event_id", &stored); r.Error != nil {
+			return errors.Wrap(r.Error, "failed to read")
+		}
+
+		skip := make(map[string]bool, len(stored))
+
+		for _, item := range stored {
+			skip[item] = true
+		}
+
+		for i := range b {
+			if ids[i] == "" || skip[ids[i]] {
+				continue
+			}
+			skip[ids[i]] = true
+			if r := s.database.Unscoped().Model(&b[i]).UpdateColumn("event_id", ids[i]); r.Error != nil {
+				return errors.Wrap(r.Error, "failed to update")
+			}
+		}
+
+		return nil
+	})
+
+	return r.Error
+}
+
+// Create stores the events, deriving their event ID if not set, and skips those already stored with the same one
 func (s *storage) Create(_ context.Context, data []Model) error {
 	s.cfg.Logger.Debug("storage: Create")
 
@@ -101,13 +200,97 @@ func (s *storage) Create(_ context.Context, data []Model) error {
 		return errors.New("invalid data length")
 	}
 
-	if r := s.database.CreateInBatches(data, BatchSize); r.Error != nil {
+	ids := make([]string, 0, len(data))
+
+	for i := range data {
+		if data[i].EventID == "" {
+			data[i].EventID = EventID(data[i].EventBase64)
+		}
+		if data[i].EventID != "" {
+			ids = append(ids, data[i].EventID)
+		}
+	}
+
+	var stored []string
+
+	if len(ids) != 0 {
+		if r := s.database.Unscoped().Model(&Model{}).Where("event_id IN ?", ids).Pluck("event_id", &stored); r.Error != nil {
+			return errors.Wrap(r.Error, "failed to read")
+		}
+	}
+
+	skip := make(map[string]bool, len(stored))
+
+	for _, item := range stored {
+		skip[item] = true
+	}
+
+	// Pointers for the IDs of the stored events to be set to data
+	b := make([]*Model, 0, len(data))
+
+	for i := range data {
+		if id := data[i].EventID; id != "" {
+			if skip[id] {
+				continue
+			}
+			skip[id] = true
+		}
+		b = append(b, &data[i])
+	}
+
+	if len(b) == 0 {
+		return nil
+	}
+
+	if r := s.database.CreateInBatches(b, BatchSize); r.Error != nil {
 		return errors.Wrap(r.Error, "failed to create")
 	}
 
 	return nil
 }
 
+// Insert stores the event unless already stored with the same event ID, e.g., by another replica,
+// and returns whether stored, data being set to the one stored before otherwise
+func (s *storage) Insert(_ context.Context, data *Model) (bool, error) {
+	s.cfg.Logger.Debug("storage: Insert")
+
+	if data == nil || data.EventID == "" {
+		return false, errors.New("invalid data")
+	}
+
+	r := s.database.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_id"}}, DoNothing: true}).Create(data)
+	if r.Error != nil {
+		return false, errors.Wrap(r.Error, "failed to create")
+	}
+
+	if r.RowsAffected != 0 {
+		return true, nil
+	}
+
+	// Purged events are kept to skip them when replayed again
+	if r := s.database.Unscoped().Where("event_id = ?", data.EventID).Limit(1).Find(data); r.Error != nil {
+		return false, errors.Wrap(r.Error, "failed to read")
+	}
+
+	return false, nil
+}
+
+// EventID derives the event ID of the stored event, or returns an empty one if invalid
+func EventID(eventBase64 string) string {
+	buf, err := base64.StdEncoding.DecodeString(eventBase64)
+	if err != nil {
+		return ""
+	}
+
+	e := events.Event{}
+
+	if err := json.Unmarshal(buf, &e); err != nil || e.Type == "" {
+		return ""
+	}
+
+	return events.ID(&e)
+}
+
 func (s *storage) Delete(_ context.Context, since, until int64) error {
 	s.cfg.Logger.Debug("storage: Delete")
 
@@ -125,6 +308,24 @@ func (s *storage) Delete(_ context.Context, since, until int64) error {
 	return nil
 }
 
+// Purge deletes the events as autoclean does, and returns the number of them
+func (s *storage) Purge(_ context.Context) (int64, error) {
+	s.cfg.Logger.Debug("storage: Purge")
+
+	var b Model
+
+	since := int64(0)
+	loc, _ := time.LoadLocation(timeLocation)
+	until := time.Now().In(loc).Unix()
+
+	r := s.database.Where(fmt.Sprintf("%s >= ? AND %s < ?", PrimaryKey, PrimaryKey), since, until).Delete(&b)
+	if r.Error != nil {
+		return 0, errors.Wrap(r.Error, "failed to delete")
+	}
+
+	return r.RowsAffected, nil
+}
+
 func (s *storage) Read(_ context.Context, since, until int64) ([]Model, error) {
 	s.cfg.Logger.Debug("storage: Read")
 
@@ -142,6 +343,43 @@ func (s *storage) Read(_ context.Context, since, until int64) ([]Model, error) {
 	return b, nil
 }
 
+// ReadPage reads up to limit events created between since and until, whose ID is greater than after, ordered by ID
+func (s *storage) ReadPage(_ context.Context, since, until int64, after uint, limit int) ([]Model, error) {
+	s.cfg.Logger.Debug("storage: ReadPage")
+
+	var b []Model
+
+	if since < 0 || until < 0 {
+		return nil, errors.New("invalid date")
+	}
+
+	if limit <= 0 || limit > BatchSize {
+		return nil, errors.New("invalid limit")
+	}
+
+	r := s.database.Where(fmt.Sprintf("%s >= ? AND %s < ? AND id > ?", PrimaryKey, PrimaryKey), since, until, after).
+		Order("id").Limit(limit).Find(&b)
+	if r.Error != nil {
+		return nil, errors.Wrap(r.Error, "failed to read")
+	}
+
+	return b, nil
+}
+
+// ReadLastID returns the highest ID stored, deleted events included, 0 if none
+func (s *storage) ReadLastID(_ context.Context) (uint, error) {
+	s.cfg.Logger.Debug("storage: ReadLastID")
+
+	var id uint
+
+	r := s.database.Unscoped().Model(&Model{}).Select("COALESCE(MAX(id), 0)").Scan(&id)
+	if r.Error != nil {
+		return 0, errors.Wrap(r.Error, "failed to read")
+	}
+
+	return id, nil
+}
+
 func (s *storage) Update(_ context.Context, data *Model) error {
 	s.cfg.Logger.Debug("storage: Update")
 
@@ -159,23 +397,177 @@ func (s *storage) Update(_ context.Context, data *Model) error {
 	return nil
 }
 
+func (s *storage) CreateGap(_ context.Context, data *Gap) error {
+	s.cfg.Logger.Debug("storage: CreateGap")
+
+	if data == nil || data.Since < 0 || data.Until < data.Since {
+		return errors.New("invalid data")
+	}
+
+	if r := s.database.Create(data); r.Error != nil {
+		return errors.Wrap(r.Error, "failed to create")
+	}
+
+	return nil
+}
+
+func (s *storage) ReadGap(_ context.Context, since, until int64) ([]Gap, error) {
+	s.cfg.Logger.Debug("storage: ReadGap")
+
+	var b []Gap
+
+	if since < 0 || until < 0 {
+		return nil, errors.New("invalid date")
+	}
+
+	r := s.database.Where("until >= ? AND since < ?", since, until).Find(&b)
+	if r.Error != nil {
+		return nil, errors.Wrap(r.Error, "failed to read")
+	}
+
+	return b, nil
+}
+
+// ReadPendingGap returns the gaps neither replayed nor unrecoverable
+func (s *storage) ReadPendingGap(_ context.Context) ([]Gap, error) {
+	s.cfg.Logger.Debug("storage: ReadPendingGap")
+
+	var b []Gap
+
+	r := s.database.Where("replayed = ? AND unrecoverable = ?", false, false).Order("id").Find(&b)
+	if r.Error != nil {
+		return nil, errors.Wrap(r.Error, "failed to read")
+	}
+
+	return b, nil
+}
+
+func (s *storage) UpdateGap(_ context.Context, data *Gap) error {
+	s.cfg.Logger.Debug("storage: UpdateGap")
+
+	if data == nil || data.ID == 0 {
+		return errors.New("invalid data")
+	}
+
+	if r := s.database.Save(data); r.Error != nil {
+		return errors.Wrap(r.Error, "failed to update")
+	}
+
+	return nil
+}
+
+// AcquireLease takes the lease if free or expired, or renews it if already held, and returns whether it is held
+func (s *storage) AcquireLease(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.cfg.Logger.Debug("storage: AcquireLease")
+
+	if name == "" || holder == "" || ttl <= 0 {
+		return false, errors.New("invalid lease")
+	}
+
+	now := time.Now().UnixMilli()
+	expires := now + ttl.Milliseconds()
+
+	r := s.database.Model(&Lease{}).Where("name = ? AND (holder = ? OR expires < ?)", name, holder, now).
+		Updates(map[string]any{"holder": holder, "expires": expires})
+	if r.Error != nil {
+		return false, errors.Wrap(r.Error, "failed to update")
+	}
+
+	if r.RowsAffected != 0 {
+		return true, nil
+	}
+
+	// Taken by the first replica inserting it, if not held yet
+	r = s.database.Clauses(clause.OnConflict{DoNothing: true}).Create(&Lease{Name: name, Holder: holder, Expires: expires})
+	if r.Error != nil {
+		return false, errors.Wrap(r.Error, "failed to create")
+	}
+
+	return r.RowsAffected != 0, nil
+}
+
+func (s *storage) ReadLease(_ context.Context, name string) (*Lease, error) {
+	s.cfg.Logger.Debug("storage: ReadLease")
+
+	var b Lease
+
+	r := s.database.Where("name = ?", name).Limit(1).Find(&b)
+	if r.Error != nil {
+		return nil, errors.Wrap(r.Error, "failed to read")
+	}
+
+	if r.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &b, nil
+}
+
+// ReleaseLease expires the lease if held, for another replica to take it without waiting
+func (s *storage) ReleaseLease(_ context.Context, name, holder string) error {
+	s.cfg.Logger.Debug("storage: ReleaseLease")
+
+	r := s.database.Model(&Lease{}).Where("name = ? AND holder = ?", name, holder).Update("expires", 0)
+	if r.Error != nil {
+		return errors.Wrap(r.Error, "failed to update")
+	}
+
+	return nil
+}
+
 func (s *storage) autoclean(ctx context.Context) error {
 	s.cfg.Logger.Debug("storage: autoclean")
 
 	helper := func() {
-		since := int64(0)
-		loc, _ := time.LoadLocation(timeLocation)
-		until := time.Now().In(loc).Unix()
-		_ = s.Delete(ctx, since, until)
+		_, _ = s.Purge(ctx)
 	}
 
-	c := cron.New()
+	var c *cron.Cron
 
-	if _, err := c.AddFunc(s.cfg.Config.Spec.Storage.Autoclean, helper); err != nil {
-		return errors.Wrap(err, "failed to add func")
+	if spec := s.cfg.Config.Spec.Storage.Autoclean; spec != "" {
+		c = cron.New()
+		if _, err := c.AddFunc(spec, helper); err != nil {
+			return errors.Wrap(err, "failed to add func")
+		}
+		c.Start()
 	}
 
-	c.Start()
+	// Replaces the previous schedule, if any
+	s.mutex.Lock()
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+	s.cron = c
+	s.mutex.Unlock()
 
 	return nil
 }
+
+// Reload reschedules autoclean if changed, the database being kept until restarted
+func (s *storage) Reload(ctx context.Context, cfg *config.Config) error {
+	s.cfg.Logger.Debug("storage: Reload")
+
+	spec := s.cfg.Config.Spec.Storage.Autoclean
+	if cfg.Spec.Storage.Autoclean == spec {
+		return nil
+	}
+
+	s.cfg.Config.Spec.Storage.Autoclean = cfg.Spec.Storage.Autoclean
+
+	if err := s.autoclean(ctx); err != nil {
+		s.cfg.Config.Spec.Storage.Autoclean = spec
+		return errors.Wrap(err, "failed to autoclean database")
+	}
+
+	return nil
+}
+
+// dsn waits for the database locked by another replica, e.g., sharing it on disk
+func dsn(filename string) string {
+	sep := "?"
+	if strings.Contains(filename, "?") {
+		sep = "&"
+	}
+
+	return fmt.Sprintf("%s%s_busy_timeout=%d", filename, sep, busyTimeout)
+}
@@ -2,12 +2,17 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"math"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/robfig/cron/v3"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
 )
 
 const (
@@ -60,9 +65,59 @@ func TestCreate(t *testing.T) {
 	err = s.Create(ctx, data)
 	assert.Equal(t, nil, err)
 
+	// Derived event ID, the event stored once
+	event := base64.StdEncoding.EncodeToString([]byte(`{"type":"ref-updated","eventCreatedOn":1672567201}`))
+
+	for i := 0; i < 2; i++ {
+		err = s.Create(ctx, []Model{{EventBase64: event, EventCreatedOn: 1672567201}})
+		assert.Equal(t, nil, err)
+	}
+
+	m, _ := s.Read(ctx, 1672567201, 1672567202)
+	assert.Equal(t, 1, len(m))
+	assert.Equal(t, EventID(event), m[0].EventID)
+	assert.Equal(t, 64, len(m[0].EventID))
+	assert.Equal(t, "", EventID(data[0].EventBase64))
+
+	_ = s.Deinit(ctx)
 	_ = os.Remove(name)
 }
 
+func TestMigrateEventID(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	defer func() {
+		_ = s.Deinit(ctx)
+		_ = os.Remove(name)
+	}()
+
+	// Stored before the event ID was added, twice and invalid
+	event := base64.StdEncoding.EncodeToString([]byte(`{"type":"ref-updated","eventCreatedOn":1672567201}`))
+
+	b := []Model{
+		{EventBase64: event, EventCreatedOn: 1672567201},
+		{EventBase64: event, EventCreatedOn: 1672567201},
+		{EventBase64: data[0].EventBase64, EventCreatedOn: 1672567201},
+	}
+
+	assert.Equal(t, nil, s.database.Create(&b).Error)
+
+	_ = s.Deinit(ctx)
+	assert.Equal(t, nil, s.Init(ctx))
+
+	m, _ := s.Read(ctx, 1672567201, 1672567202)
+	assert.Equal(t, 3, len(m))
+	assert.Equal(t, EventID(event), m[0].EventID)
+	assert.Equal(t, "", m[1].EventID)
+	assert.Equal(t, "", m[2].EventID)
+
+	// Skipped once inserted again
+	ok, err := s.Insert(ctx, &Model{EventBase64: event, EventCreatedOn: 1672567201, EventID: EventID(event)})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+}
+
 func TestDelete(t *testing.T) {
 	ctx := context.Background()
 	s := initStorage()
@@ -84,6 +139,26 @@ func TestDelete(t *testing.T) {
 	_ = os.Remove(name)
 }
 
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	_ = s.Create(ctx, data)
+
+	n, err := s.Purge(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), n)
+
+	b, _ := s.Read(ctx, 0, time.Now().Unix())
+	assert.Equal(t, 0, len(b))
+
+	n, err = s.Purge(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(0), n)
+
+	_ = os.Remove(name)
+}
+
 func TestRead(t *testing.T) {
 	ctx := context.Background()
 	s := initStorage()
@@ -101,6 +176,60 @@ func TestRead(t *testing.T) {
 	_ = os.Remove(name)
 }
 
+func TestReadPage(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	_ = s.Create(ctx, []Model{
+		{EventBase64: "MQ==", EventCreatedOn: 1672567201},
+		{EventBase64: "Mg==", EventCreatedOn: 1672567202},
+		{EventBase64: "Mw==", EventCreatedOn: 1672567203},
+	})
+
+	_, err := s.ReadPage(ctx, -1, -1, 0, 1)
+	assert.NotEqual(t, nil, err)
+
+	_, err = s.ReadPage(ctx, 0, 1, 0, 0)
+	assert.NotEqual(t, nil, err)
+
+	b, err := s.ReadPage(ctx, 1672567201, 1672567204, 0, 2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(b))
+	assert.Equal(t, "MQ==", b[0].EventBase64)
+	assert.Equal(t, "Mg==", b[1].EventBase64)
+
+	b, _ = s.ReadPage(ctx, 1672567201, 1672567204, b[1].ID, 2)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, "Mw==", b[0].EventBase64)
+
+	b, _ = s.ReadPage(ctx, 1672567201, 1672567203, 0, 2)
+	assert.Equal(t, 2, len(b))
+
+	_ = os.Remove(name)
+}
+
+func TestReadLastID(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	id, err := s.ReadLastID(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint(0), id)
+
+	_ = s.Create(ctx, []Model{
+		{EventBase64: "MQ==", EventCreatedOn: 1672567201},
+		{EventBase64: "Mg==", EventCreatedOn: 1672567202},
+	})
+
+	b, _ := s.ReadPage(ctx, 0, math.MaxInt64, 0, BatchSize)
+
+	id, err = s.ReadLastID(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, b[1].ID, id)
+
+	_ = os.Remove(name)
+}
+
 func TestUpdate(t *testing.T) {
 	ctx := context.Background()
 	s := initStorage()
@@ -144,3 +273,197 @@ func TestAutoclean(t *testing.T) {
 
 	_ = os.Remove(name)
 }
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	defer func() {
+		_ = s.Deinit(ctx)
+		_ = os.Remove(name)
+	}()
+
+	c := config.New()
+	c.Spec.Storage.Autoclean = "invalid"
+
+	err := s.Reload(ctx, c)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, "", s.cfg.Config.Spec.Storage.Autoclean)
+
+	c.Spec.Storage.Autoclean = "@every 0h0m1s"
+
+	err = s.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, s.cron)
+
+	_ = s.Create(ctx, data)
+
+	time.Sleep(2 * time.Second)
+
+	b, err := s.Read(ctx, 0, time.Now().Unix())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(b))
+
+	c.Spec.Storage.Autoclean = ""
+
+	err = s.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*cron.Cron)(nil), s.cron)
+}
+
+func TestGap(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	err := s.CreateGap(ctx, nil)
+	assert.NotEqual(t, nil, err)
+
+	err = s.CreateGap(ctx, &Gap{Since: 2, Until: 1})
+	assert.NotEqual(t, nil, err)
+
+	g := &Gap{Since: data[0].EventCreatedOn, Until: data[0].EventCreatedOn + 60}
+
+	err = s.CreateGap(ctx, g)
+	assert.Equal(t, nil, err)
+
+	_, err = s.ReadGap(ctx, -1, -1)
+	assert.NotEqual(t, nil, err)
+
+	b, err := s.ReadGap(ctx, data[0].EventCreatedOn+30, data[0].EventCreatedOn+90)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, false, b[0].Replayed)
+
+	err = s.CreateGap(ctx, &Gap{Since: data[0].EventCreatedOn, Until: data[0].EventCreatedOn + 60, Unrecoverable: true})
+	assert.Equal(t, nil, err)
+
+	b, err = s.ReadPendingGap(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, g.ID, b[0].ID)
+
+	err = s.UpdateGap(ctx, &Gap{})
+	assert.NotEqual(t, nil, err)
+
+	g.Replayed = true
+
+	err = s.UpdateGap(ctx, g)
+	assert.Equal(t, nil, err)
+
+	b, err = s.ReadGap(ctx, 0, data[0].EventCreatedOn+1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(b))
+	assert.Equal(t, true, b[0].Replayed)
+
+	b, err = s.ReadPendingGap(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(b))
+
+	b, err = s.ReadGap(ctx, data[0].EventCreatedOn+61, data[0].EventCreatedOn+90)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(b))
+
+	_ = os.Remove(name)
+}
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	// Replicas sharing the database
+	r := initStorage()
+
+	defer func() {
+		_ = r.Deinit(ctx)
+		_ = s.Deinit(ctx)
+		_ = os.Remove(name)
+	}()
+
+	_, err := s.AcquireLease(ctx, "events", "", time.Second)
+	assert.NotEqual(t, nil, err)
+
+	l, err := s.ReadLease(ctx, "events")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*Lease)(nil), l)
+
+	ok, err := s.AcquireLease(ctx, "events", "a", time.Second)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	ok, err = r.AcquireLease(ctx, "events", "b", time.Second)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+
+	ok, err = s.AcquireLease(ctx, "events", "a", time.Second)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	l, err = r.ReadLease(ctx, "events")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a", l.Holder)
+
+	// Taken once expired
+	ok, err = s.AcquireLease(ctx, "events", "a", time.Millisecond)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	time.Sleep(10 * time.Millisecond)
+
+	ok, err = r.AcquireLease(ctx, "events", "b", time.Second)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	// Taken once released, but not by others
+	err = s.ReleaseLease(ctx, "events", "a")
+	assert.Equal(t, nil, err)
+
+	ok, _ = s.AcquireLease(ctx, "events", "a", time.Second)
+	assert.Equal(t, false, ok)
+
+	err = r.ReleaseLease(ctx, "events", "b")
+	assert.Equal(t, nil, err)
+
+	ok, _ = s.AcquireLease(ctx, "events", "a", time.Second)
+	assert.Equal(t, true, ok)
+}
+
+func TestInsert(t *testing.T) {
+	ctx := context.Background()
+	s := initStorage()
+
+	// Replicas sharing the database
+	r := initStorage()
+
+	defer func() {
+		_ = r.Deinit(ctx)
+		_ = s.Deinit(ctx)
+		_ = os.Remove(name)
+	}()
+
+	// Stored before without event ID
+	for i := 0; i < 2; i++ {
+		err := s.Create(ctx, []Model{{EventBase64: data[0].EventBase64, EventCreatedOn: data[0].EventCreatedOn}})
+		assert.Equal(t, nil, err)
+	}
+
+	_, err := s.Insert(ctx, &Model{EventBase64: data[0].EventBase64})
+	assert.NotEqual(t, nil, err)
+
+	a := &Model{EventBase64: data[0].EventBase64, EventCreatedOn: data[0].EventCreatedOn, EventID: "a"}
+
+	ok, err := s.Insert(ctx, a)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	b := &Model{EventBase64: data[0].EventBase64, EventCreatedOn: data[0].EventCreatedOn, EventID: "a"}
+
+	ok, err = r.Insert(ctx, b)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, a.ID, b.ID)
+
+	m, _ := s.Read(ctx, 0, time.Now().Unix())
+	assert.Equal(t, 3, len(m))
+	assert.Equal(t, "", m[0].EventID)
+	assert.Equal(t, "a", m[2].EventID)
+}
@@ -0,0 +1,380 @@
+package trigger
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+)
+
+const (
+	PatternAnt    = "ant"
+	PatternPlain  = "plain"
+	PatternRegexp = "regexp"
+
+	refsHeads = "refs/heads/"
+)
+
+type Trigger interface {
+	Init(context.Context) error
+	Deinit(context.Context) error
+	Match(context.Context, string, *events.Event) (bool, error)
+	Reload(context.Context, *config.Config) error
+	Rules(context.Context) []string
+}
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+type trigger struct {
+	cfg   *Config
+	mutex sync.RWMutex
+	names []string
+	rules map[string]*rule
+}
+
+type rule struct {
+	events        map[string]bool
+	projects      []project
+	comment       config.Comment
+	commitMessage *regexp.Regexp
+}
+
+type project struct {
+	pattern  matcher
+	branches []matcher
+	files    []matcher
+	topics   []matcher
+}
+
+type matcher func(string) bool
+
+func New(_ context.Context, cfg *Config) Trigger {
+	return &trigger{
+		cfg:   cfg,
+		names: []string{},
+		rules: map[string]*rule{},
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+func (t *trigger) Init(_ context.Context) error {
+	t.cfg.Logger.Debug("trigger: Init")
+
+	names, rules, err := compileRules(t.cfg.Config.Spec.Trigger.Rules)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.names = names
+	t.rules = rules
+	t.mutex.Unlock()
+
+	return nil
+}
+
+// Reload compiles the rules of the config, and keeps the previous ones if invalid
+func (t *trigger) Reload(_ context.Context, cfg *config.Config) error {
+	t.cfg.Logger.Debug("trigger: Reload")
+
+	names, rules, err := compileRules(cfg.Spec.Trigger.Rules)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.cfg.Config.Spec.Trigger = cfg.Spec.Trigger
+	t.names = names
+	t.rules = rules
+	t.mutex.Unlock()
+
+	return nil
+}
+
+func compileRules(rules []config.Rule) ([]string, map[string]*rule, error) {
+	names := []string{}
+	m := map[string]*rule{}
+
+	for i := range rules {
+		r := &rules[i]
+		if r.Name == "" {
+			return nil, nil, errors.New("invalid rule name")
+		}
+		if _, ok := m[r.Name]; ok {
+			return nil, nil, errors.New("duplicate rule " + r.Name)
+		}
+		b, err := compileRule(r)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to compile rule "+r.Name)
+		}
+		names = append(names, r.Name)
+		m[r.Name] = b
+	}
+
+	return names, m, nil
+}
+
+func (t *trigger) Deinit(_ context.Context) error {
+	t.cfg.Logger.Debug("trigger: Deinit")
+
+	return nil
+}
+
+func (t *trigger) Match(_ context.Context, name string, event *events.Event) (bool, error) {
+	t.cfg.Logger.Debug("trigger: Match")
+
+	t.mutex.RLock()
+	r, ok := t.rules[name]
+	t.mutex.RUnlock()
+
+	if !ok {
+		return false, errors.New("invalid rule " + name)
+	}
+
+	if event == nil {
+		return false, errors.New("invalid event")
+	}
+
+	return r.match(event), nil
+}
+
+func (t *trigger) Rules(_ context.Context) []string {
+	t.cfg.Logger.Debug("trigger: Rules")
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.names
+}
+
+func (r *rule) match(event *events.Event) bool {
+	if len(r.events) != 0 && !r.events[event.Type] {
+		return false
+	}
+
+	if r.comment.Label != "" && !matchComment(r.comment, event) {
+		return false
+	}
+
+	if r.commitMessage != nil && !r.commitMessage.MatchString(event.Change.CommitMessage) {
+		return false
+	}
+
+	if len(r.projects) == 0 {
+		return true
+	}
+
+	for i := range r.projects {
+		if r.projects[i].match(event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *project) match(event *events.Event) bool {
+	if !p.pattern(projectOf(event)) {
+		return false
+	}
+
+	if !matchAny(p.branches, branchOf(event)) {
+		return false
+	}
+
+	if len(p.topics) != 0 && !matchAny(p.topics, event.Change.Topic) {
+		return false
+	}
+
+	if len(p.files) != 0 {
+		for _, item := range event.PatchSet.Files {
+			if matchAny(p.files, item.File) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func matchAny(matchers []matcher, name string) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+
+	for _, m := range matchers {
+		if m(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchComment checks the comment-added approvals for a vote on the label, ignoring votes that did not change
+func matchComment(comment config.Comment, event *events.Event) bool {
+	if event.Type != events.EVENTS_COMMENT_ADDED {
+		return false
+	}
+
+	for _, item := range event.Approvals {
+		if item.Type != comment.Label {
+			continue
+		}
+		if comment.Value != "" && strings.TrimPrefix(item.Value, "+") != strings.TrimPrefix(comment.Value, "+") {
+			continue
+		}
+		if item.OldValue != "" && item.OldValue == item.Value {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+func projectOf(event *events.Event) string {
+	switch {
+	case event.Change.Project != "":
+		return event.Change.Project
+	case event.RefUpdate.Project != "":
+		return event.RefUpdate.Project
+	case event.ProjectName != "":
+		return event.ProjectName
+	default:
+		return event.Project
+	}
+}
+
+func branchOf(event *events.Event) string {
+	switch {
+	case event.Change.Branch != "":
+		return event.Change.Branch
+	case event.RefUpdate.RefName != "":
+		return strings.TrimPrefix(event.RefUpdate.RefName, refsHeads)
+	case event.Ref != "":
+		return strings.TrimPrefix(event.Ref, refsHeads)
+	default:
+		return strings.TrimPrefix(event.RefName, refsHeads)
+	}
+}
+
+func compileRule(r *config.Rule) (*rule, error) {
+	var err error
+
+	b := &rule{
+		events:   map[string]bool{},
+		projects: make([]project, len(r.Projects)),
+		comment:  r.Comment,
+	}
+
+	for _, item := range r.Events {
+		b.events[item] = true
+	}
+
+	if r.CommitMessage != "" {
+		if b.commitMessage, err = regexp.Compile(r.CommitMessage); err != nil {
+			return nil, errors.Wrap(err, "invalid commit message")
+		}
+	}
+
+	for i := range r.Projects {
+		p := &r.Projects[i]
+		if b.projects[i].pattern, err = compilePattern(p.Pattern); err != nil {
+			return nil, errors.Wrap(err, "invalid project")
+		}
+		if b.projects[i].branches, err = compilePatterns(p.Branches); err != nil {
+			return nil, errors.Wrap(err, "invalid branch")
+		}
+		if b.projects[i].files, err = compilePatterns(p.Files); err != nil {
+			return nil, errors.Wrap(err, "invalid file")
+		}
+		if b.projects[i].topics, err = compilePatterns(p.Topics); err != nil {
+			return nil, errors.Wrap(err, "invalid topic")
+		}
+	}
+
+	return b, nil
+}
+
+func compilePatterns(patterns []config.Pattern) ([]matcher, error) {
+	b := make([]matcher, len(patterns))
+
+	for i := range patterns {
+		m, err := compilePattern(patterns[i])
+		if err != nil {
+			return nil, err
+		}
+		b[i] = m
+	}
+
+	return b, nil
+}
+
+func compilePattern(pattern config.Pattern) (matcher, error) {
+	switch pattern.Type {
+	case "", PatternPlain:
+		return func(name string) bool {
+			return name == pattern.Pattern
+		}, nil
+	case PatternAnt:
+		r, err := regexp.Compile(antToRegexp(pattern.Pattern))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid ant pattern")
+		}
+		return r.MatchString, nil
+	case PatternRegexp:
+		r, err := regexp.Compile("^(?:" + pattern.Pattern + ")$")
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid regexp pattern")
+		}
+		return r.MatchString, nil
+	default:
+		return nil, errors.New("invalid pattern type " + pattern.Type)
+	}
+}
+
+// antToRegexp converts an ANT style pattern (e.g., src/**/*.go) to a regular expression
+// https://ant.apache.org/manual/dirtasks.html#patterns
+func antToRegexp(pattern string) string {
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			b.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package trigger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+)
+
+var (
+	rules = []config.Rule{
+		{
+			Name:   "build",
+			Events: []string{events.EVENTS_PATCHSET_CREATED},
+			Projects: []config.Project{
+				{
+					Pattern:  config.Pattern{Type: PatternAnt, Pattern: "platform/**"},
+					Branches: []config.Pattern{{Type: PatternRegexp, Pattern: "main|release-.*"}},
+					Files:    []config.Pattern{{Type: PatternAnt, Pattern: "**/*.go"}},
+				},
+			},
+			CommitMessage: "(?m)^Change-Id: I",
+		},
+		{
+			Name: "review",
+			Projects: []config.Project{
+				{
+					Pattern: config.Pattern{Type: PatternPlain, Pattern: "platform/build"},
+					Topics:  []config.Pattern{{Pattern: "feature"}},
+				},
+			},
+			Comment: config.Comment{Label: "Code-Review", Value: "+2"},
+		},
+		{
+			Name:     "merged",
+			Events:   []string{events.EVENTS_REF_UPDATED},
+			Projects: []config.Project{{Pattern: config.Pattern{Type: PatternRegexp, Pattern: "platform/.+"}}},
+		},
+	}
+)
+
+func initTrigger(r []config.Rule) *trigger {
+	t := New(context.Background(), DefaultConfig()).(*trigger)
+
+	t.cfg.Config.Spec.Trigger.Rules = r
+
+	t.cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "trigger",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return t
+}
+
+func TestInit(t *testing.T) {
+	ctx := context.Background()
+
+	err := initTrigger([]config.Rule{{Name: ""}}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	err = initTrigger([]config.Rule{{Name: "a"}, {Name: "a"}}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	err = initTrigger([]config.Rule{{Name: "a", CommitMessage: "("}}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	err = initTrigger([]config.Rule{{Name: "a", Projects: []config.Project{{Pattern: config.Pattern{Type: "invalid"}}}}}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	tr := initTrigger(rules)
+	err = tr.Init(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"build", "review", "merged"}, tr.Rules(ctx))
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	tr := initTrigger(rules)
+	_ = tr.Init(ctx)
+
+	c := config.New()
+	c.Spec.Trigger.Rules = []config.Rule{{Name: "a", CommitMessage: "("}}
+
+	err := tr.Reload(ctx, c)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, []string{"build", "review", "merged"}, tr.Rules(ctx))
+
+	c.Spec.Trigger.Rules = []config.Rule{{Name: "merged", Events: []string{events.EVENTS_CHANGE_MERGED}}}
+
+	err = tr.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"merged"}, tr.Rules(ctx))
+
+	ok, err := tr.Match(ctx, "merged", &events.Event{Type: events.EVENTS_CHANGE_MERGED})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	_, err = tr.Match(ctx, "build", &events.Event{Type: events.EVENTS_PATCHSET_CREATED})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestMatch(t *testing.T) {
+	ctx := context.Background()
+	tr := initTrigger(rules)
+	_ = tr.Init(ctx)
+
+	e := &events.Event{
+		Type: events.EVENTS_PATCHSET_CREATED,
+		Change: events.Change{
+			Project:       "platform/build",
+			Branch:        "release-1.0",
+			Topic:         "feature",
+			CommitMessage: "Subject\n\nChange-Id: I0123\n",
+		},
+		PatchSet: events.PatchSet{
+			Files: []events.File{{File: "/COMMIT_MSG"}, {File: "cmd/main.go"}},
+		},
+	}
+
+	_, err := tr.Match(ctx, "invalid", e)
+	assert.NotEqual(t, nil, err)
+
+	_, err = tr.Match(ctx, "build", nil)
+	assert.NotEqual(t, nil, err)
+
+	ok, _ := tr.Match(ctx, "build", e)
+	assert.Equal(t, true, ok)
+
+	e.PatchSet.Files = []events.File{{File: "README.md"}}
+	ok, _ = tr.Match(ctx, "build", e)
+	assert.Equal(t, false, ok)
+
+	e.PatchSet.Files = []events.File{{File: "main.go"}}
+	e.Change.Branch = "dev"
+	ok, _ = tr.Match(ctx, "build", e)
+	assert.Equal(t, false, ok)
+
+	ok, _ = tr.Match(ctx, "review", e)
+	assert.Equal(t, false, ok)
+
+	e.Type = events.EVENTS_COMMENT_ADDED
+	e.Approvals = []events.Approval{{Type: "Code-Review", Value: "2", OldValue: "2"}}
+	ok, _ = tr.Match(ctx, "review", e)
+	assert.Equal(t, false, ok)
+
+	e.Approvals = []events.Approval{{Type: "Verified", Value: "1"}, {Type: "Code-Review", Value: "2", OldValue: "1"}}
+	ok, _ = tr.Match(ctx, "review", e)
+	assert.Equal(t, true, ok)
+
+	e.Change.Topic = "other"
+	ok, _ = tr.Match(ctx, "review", e)
+	assert.Equal(t, false, ok)
+
+	e = &events.Event{
+		Type:      events.EVENTS_REF_UPDATED,
+		RefUpdate: events.RefUpdate{Project: "platform/build", RefName: "refs/heads/main"},
+	}
+
+	ok, _ = tr.Match(ctx, "merged", e)
+	assert.Equal(t, true, ok)
+
+	e.RefUpdate.Project = "platform"
+	ok, _ = tr.Match(ctx, "merged", e)
+	assert.Equal(t, false, ok)
+}
+
+func TestBranchOf(t *testing.T) {
+	e := &events.Event{Type: events.EVENTS_REF_REPLICATED, Project: "platform/build", Ref: "refs/heads/release-1.0"}
+
+	assert.Equal(t, "platform/build", projectOf(e))
+	assert.Equal(t, "release-1.0", branchOf(e))
+
+	e = &events.Event{Type: events.EVENTS_REF_UPDATED, RefUpdate: events.RefUpdate{RefName: "refs/heads/main"}}
+
+	assert.Equal(t, "main", branchOf(e))
+}
+
+func TestAntToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"platform/**", "platform", true},
+		{"platform/**", "platform/build/tools", true},
+		{"platform/**", "platforms", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/main.go", true},
+		{"**/*.go", "cmd/main.gox", false},
+		{"src/*.c", "src/a.c", true},
+		{"src/*.c", "src/a/b.c", false},
+		{"src/?.c", "src/a.c", true},
+		{"src/?.c", "src/ab.c", false},
+		{"a.b", "axb", false},
+	}
+
+	for _, item := range tests {
+		m, err := compilePattern(config.Pattern{Type: PatternAnt, Pattern: item.pattern})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, item.match, m(item.name), item.pattern+" "+item.name)
+	}
+}
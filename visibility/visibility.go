@@ -0,0 +1,281 @@
+package visibility
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/events"
+)
+
+const (
+	ActionHide   = "hide"
+	ActionRedact = "redact"
+	ActionShow   = "show"
+
+	defaultPrivate = ActionHide
+	defaultWip     = ActionShow
+)
+
+var (
+	// Actions ordered from the most restrictive
+	actions = map[string]int{
+		ActionHide:   0,
+		ActionRedact: 1,
+		ActionShow:   2,
+	}
+
+	// Fields kept in redacted events, enough to tell which change was updated
+	redactedEvent = map[string]bool{
+		"change":         true,
+		"changeKey":      true,
+		"eventCreatedOn": true,
+		"project":        true,
+		"refName":        true,
+		"type":           true,
+	}
+
+	redactedChange = map[string]bool{
+		"branch":      true,
+		"createdOn":   true,
+		"id":          true,
+		"lastUpdated": true,
+		"number":      true,
+		"open":        true,
+		"private":     true,
+		"project":     true,
+		"status":      true,
+		"url":         true,
+		"wip":         true,
+	}
+)
+
+type Visibility interface {
+	Init(context.Context) error
+	Deinit(context.Context) error
+	Filter(context.Context, *auth.Identity, []byte) ([]byte, bool, error)
+	Reload(context.Context, *config.Config) error
+}
+
+type Config struct {
+	Config config.Config
+	Logger hclog.Logger
+}
+
+type visibility struct {
+	cfg     *Config
+	mutex   sync.RWMutex
+	private string
+	wip     string
+	groups  map[string][]*config.Group
+}
+
+func New(_ context.Context, cfg *Config) Visibility {
+	return &visibility{
+		cfg:    cfg,
+		groups: map[string][]*config.Group{},
+	}
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+func (v *visibility) Init(_ context.Context) error {
+	v.cfg.Logger.Debug("visibility: Init")
+
+	private, wip, groups, err := compile(&v.cfg.Config.Spec.Server.Visibility)
+	if err != nil {
+		return err
+	}
+
+	v.mutex.Lock()
+	v.private, v.wip, v.groups = private, wip, groups
+	v.mutex.Unlock()
+
+	return nil
+}
+
+// Reload resolves the actions of the config, and keeps the previous ones if invalid
+func (v *visibility) Reload(_ context.Context, cfg *config.Config) error {
+	v.cfg.Logger.Debug("visibility: Reload")
+
+	c := cfg.Spec.Server.Visibility
+
+	private, wip, groups, err := compile(&c)
+	if err != nil {
+		return err
+	}
+
+	v.mutex.Lock()
+	v.cfg.Config.Spec.Server.Visibility = c
+	v.private, v.wip, v.groups = private, wip, groups
+	v.mutex.Unlock()
+
+	return nil
+}
+
+// compile resolves the default actions, and the groups by member
+func compile(c *config.Visibility) (private, wip string, groups map[string][]*config.Group, err error) {
+	private = defaultAction(c.Private, defaultPrivate)
+	wip = defaultAction(c.Wip, defaultWip)
+
+	if err := validAction(private, wip); err != nil {
+		return "", "", nil, err
+	}
+
+	groups = map[string][]*config.Group{}
+	names := map[string]bool{}
+
+	for i := range c.Groups {
+		g := &c.Groups[i]
+		if g.Name == "" {
+			return "", "", nil, errors.New("invalid group name")
+		}
+		if names[g.Name] {
+			return "", "", nil, errors.New("duplicate group " + g.Name)
+		}
+		if err := validAction(g.Private, g.Wip); err != nil {
+			return "", "", nil, errors.Wrap(err, "invalid group "+g.Name)
+		}
+		names[g.Name] = true
+		for _, item := range g.Members {
+			groups[item] = append(groups[item], g)
+		}
+	}
+
+	return private, wip, groups, nil
+}
+
+func (v *visibility) Deinit(_ context.Context) error {
+	v.cfg.Logger.Debug("visibility: Deinit")
+
+	return nil
+}
+
+// Filter returns the event as visible to the caller, which is redacted or hidden for private and WIP changes
+func (v *visibility) Filter(_ context.Context, id *auth.Identity, data []byte) ([]byte, bool, error) {
+	v.cfg.Logger.Debug("visibility: Filter")
+
+	e := events.Event{}
+
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false, errors.Wrap(err, "failed to unmarshal")
+	}
+
+	if !e.Change.Private && !e.Change.WIP {
+		return data, true, nil
+	}
+
+	switch v.action(id, &e) {
+	case ActionHide:
+		return nil, false, nil
+	case ActionRedact:
+		b, err := redact(data)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "failed to redact")
+		}
+		return b, true, nil
+	default:
+		return data, true, nil
+	}
+}
+
+// action resolves the most permissive action granted to the caller, admins and change owners seeing everything
+func (v *visibility) action(id *auth.Identity, event *events.Event) string {
+	if id != nil && (id.HasScope(auth.ScopeAdmin) || isOwner(id.Name, &event.Change.Owner)) {
+		return ActionShow
+	}
+
+	v.mutex.RLock()
+	private, wip := v.private, v.wip
+
+	if id != nil {
+		for _, g := range v.groups[id.Name] {
+			private = permissive(private, g.Private)
+			wip = permissive(wip, g.Wip)
+		}
+	}
+	v.mutex.RUnlock()
+
+	switch {
+	case event.Change.Private && event.Change.WIP:
+		if actions[private] < actions[wip] {
+			return private
+		}
+		return wip
+	case event.Change.Private:
+		return private
+	default:
+		return wip
+	}
+}
+
+func isOwner(name string, owner *events.Account) bool {
+	return name != "" && (name == owner.Username || name == owner.Email)
+}
+
+func permissive(action, other string) string {
+	if other != "" && actions[other] > actions[action] {
+		return other
+	}
+
+	return action
+}
+
+func defaultAction(action, value string) string {
+	if action == "" {
+		return value
+	}
+
+	return action
+}
+
+func validAction(items ...string) error {
+	for _, item := range items {
+		if _, ok := actions[item]; item != "" && !ok {
+			return errors.New("invalid action " + item)
+		}
+	}
+
+	return nil
+}
+
+// redact keeps the fields telling which change was updated, dropping its content (e.g., subject, files and comments)
+func redact(data []byte) ([]byte, error) {
+	var e map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+
+	for key := range e {
+		if !redactedEvent[key] {
+			delete(e, key)
+		}
+	}
+
+	if buf, ok := e["change"]; ok {
+		var c map[string]json.RawMessage
+		if err := json.Unmarshal(buf, &c); err != nil {
+			return nil, err
+		}
+		for key := range c {
+			if !redactedChange[key] {
+				delete(c, key)
+			}
+		}
+		b, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		e["change"] = b
+	}
+
+	return json.Marshal(e)
+}
@@ -0,0 +1,139 @@
+package visibility
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/auth"
+	"github.com/gerrittrigger/events/config"
+)
+
+const (
+	private = `{"type":"comment-added","change":{"project":"platform/build","branch":"main","number":1,"subject":"Secret",` +
+		`"commitMessage":"Secret\n","owner":{"username":"owner","email":"owner@example.com"},"private":true},` +
+		`"comment":"Secret","eventCreatedOn":1672567200}`
+	public = `{"type":"comment-added","change":{"project":"platform/build","subject":"Public"},"eventCreatedOn":1672567200}`
+	wip    = `{"type":"patchset-created","change":{"project":"platform/build","subject":"Draft","wip":true},"eventCreatedOn":1672567200}`
+	both   = `{"type":"patchset-created","change":{"project":"platform/build","private":true,"wip":true},"eventCreatedOn":1672567200}`
+)
+
+func initVisibility(v config.Visibility) *visibility {
+	b := New(context.Background(), DefaultConfig()).(*visibility)
+
+	b.cfg.Config.Spec.Server.Visibility = v
+
+	b.cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "visibility",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return b
+}
+
+func TestInit(t *testing.T) {
+	ctx := context.Background()
+
+	err := initVisibility(config.Visibility{Private: "invalid"}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	err = initVisibility(config.Visibility{Groups: []config.Group{{Name: ""}}}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	err = initVisibility(config.Visibility{Groups: []config.Group{{Name: "a"}, {Name: "a"}}}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	err = initVisibility(config.Visibility{Groups: []config.Group{{Name: "a", Wip: "invalid"}}}).Init(ctx)
+	assert.NotEqual(t, nil, err)
+
+	v := initVisibility(config.Visibility{Groups: []config.Group{{Name: "a", Members: []string{"ci"}}}})
+	err = v.Init(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ActionHide, v.private)
+	assert.Equal(t, ActionShow, v.wip)
+	assert.Equal(t, 1, len(v.groups["ci"]))
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	v := initVisibility(config.Visibility{})
+	_ = v.Init(ctx)
+
+	c := config.New()
+	c.Spec.Server.Visibility = config.Visibility{Private: "invalid"}
+
+	err := v.Reload(ctx, c)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, ActionHide, v.private)
+
+	c.Spec.Server.Visibility = config.Visibility{
+		Private: ActionRedact,
+		Groups:  []config.Group{{Name: "a", Members: []string{"ci"}, Private: ActionShow}},
+	}
+
+	err = v.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+
+	_, ok, _ := v.Filter(ctx, nil, []byte(private))
+	assert.Equal(t, true, ok)
+
+	b, ok, _ := v.Filter(ctx, &auth.Identity{Name: "ci"}, []byte(private))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, private, string(b))
+}
+
+func TestFilter(t *testing.T) {
+	ctx := context.Background()
+
+	v := initVisibility(config.Visibility{
+		Wip: ActionRedact,
+		Groups: []config.Group{
+			{Name: "reviewers", Members: []string{"dev"}, Private: ActionRedact},
+			{Name: "builders", Members: []string{"dev"}, Wip: ActionShow},
+		},
+	})
+	_ = v.Init(ctx)
+
+	_, _, err := v.Filter(ctx, nil, []byte("invalid"))
+	assert.NotEqual(t, nil, err)
+
+	b, ok, err := v.Filter(ctx, nil, []byte(public))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, public, string(b))
+
+	_, ok, _ = v.Filter(ctx, nil, []byte(private))
+	assert.Equal(t, false, ok)
+
+	_, ok, _ = v.Filter(ctx, &auth.Identity{Name: "ci", Scopes: []string{auth.ScopeRead}}, []byte(private))
+	assert.Equal(t, false, ok)
+
+	b, ok, _ = v.Filter(ctx, &auth.Identity{Name: "ops", Scopes: []string{auth.ScopeAdmin}}, []byte(private))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, private, string(b))
+
+	b, ok, _ = v.Filter(ctx, &auth.Identity{Name: "owner@example.com"}, []byte(private))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, private, string(b))
+
+	b, ok, _ = v.Filter(ctx, &auth.Identity{Name: "dev"}, []byte(private))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, `{"change":{"branch":"main","number":1,"private":true,"project":"platform/build"},`+
+		`"eventCreatedOn":1672567200,"type":"comment-added"}`, string(b))
+
+	b, ok, _ = v.Filter(ctx, nil, []byte(wip))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, `{"change":{"project":"platform/build","wip":true},"eventCreatedOn":1672567200,"type":"patchset-created"}`, string(b))
+
+	b, ok, _ = v.Filter(ctx, &auth.Identity{Name: "dev"}, []byte(wip))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, wip, string(b))
+
+	_, ok, _ = v.Filter(ctx, nil, []byte(both))
+	assert.Equal(t, false, ok)
+
+	_, ok, _ = v.Filter(ctx, &auth.Identity{Name: "dev"}, []byte(both))
+	assert.Equal(t, true, ok)
+}
@@ -3,6 +3,7 @@ package watchdog
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -19,6 +20,7 @@ const (
 type Watchdog interface {
 	Init(context.Context) error
 	Deinit(context.Context) error
+	Reload(context.Context, *config.Config) error
 	Run(context.Context, connect.Ssh, chan bool, chan bool) error
 }
 
@@ -28,12 +30,15 @@ type Config struct {
 }
 
 type watchdog struct {
-	cfg *Config
+	cfg    *Config
+	mutex  sync.Mutex
+	reload chan bool
 }
 
 func New(_ context.Context, cfg *Config) Watchdog {
 	return &watchdog{
-		cfg: cfg,
+		cfg:    cfg,
+		reload: make(chan bool, 1),
 	}
 }
 
@@ -53,22 +58,64 @@ func (w *watchdog) Deinit(_ context.Context) error {
 	return nil
 }
 
+// Reload applies the period and timeout of the config to the running watchdog
+func (w *watchdog) Reload(_ context.Context, cfg *config.Config) error {
+	w.cfg.Logger.Debug("watchdog: Reload")
+
+	w.mutex.Lock()
+	w.cfg.Config.Spec.Watchdog = cfg.Spec.Watchdog
+	w.mutex.Unlock()
+
+	select {
+	case w.reload <- true:
+	default:
+	}
+
+	return nil
+}
+
 func (w *watchdog) Run(ctx context.Context, ssh connect.Ssh, reconn, start chan bool) error {
 	w.cfg.Logger.Debug("watchdog: Run")
 
-	p := time.Duration(w.cfg.Config.Spec.Watchdog.PeriodSeconds)
-	t := time.Duration(w.cfg.Config.Spec.Watchdog.TimeoutSeconds)
+	started := false
 
-	if p == 0 || t == 0 {
-		start <- true
-		return nil
+	for {
+		w.mutex.Lock()
+		p := time.Duration(w.cfg.Config.Spec.Watchdog.PeriodSeconds)
+		t := time.Duration(w.cfg.Config.Spec.Watchdog.TimeoutSeconds)
+		w.mutex.Unlock()
+
+		if p == 0 || t == 0 {
+			if !started {
+				start <- true
+				started = true
+			}
+			// Turned off until reloaded
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-w.reload:
+				continue
+			}
+		}
+
+		if !w.watch(ctx, ssh, reconn, p, t) {
+			return nil
+		}
 	}
+}
 
+// watch checks the connection every period, and returns true once reloaded
+func (w *watchdog) watch(ctx context.Context, ssh connect.Ssh, reconn chan bool, p, t time.Duration) bool {
 	ticker := time.NewTicker(p * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
+		case <-ctx.Done():
+			return false
+		case <-w.reload:
+			return true
 		case <-ticker.C:
 			if err := w.check(ctx, ssh); err != nil {
 				time.Sleep(t)
@@ -1,12 +1,160 @@
 package watchdog
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/gerrittrigger/events/config"
+	"github.com/gerrittrigger/events/connect"
+	"github.com/gerrittrigger/events/connect/gerrittest"
+)
+
+const (
+	timeout = 5 * time.Second
 )
 
-func TestWatchdog(t *testing.T) {
-	// TODO: TestWatchdog
-	assert.Equal(t, nil, nil)
+func initWatchdog() *watchdog {
+	w := &watchdog{
+		cfg:    DefaultConfig(),
+		reload: make(chan bool, 1),
+	}
+
+	w.cfg.Config.Spec.Watchdog.PeriodSeconds = 1
+	w.cfg.Config.Spec.Watchdog.TimeoutSeconds = 1
+
+	w.cfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "watchdog",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	return w
+}
+
+func initSsh(t *testing.T) (connect.Ssh, *gerrittest.Server) {
+	srv, err := gerrittest.New(gerrittest.Config{})
+	assert.Equal(t, nil, err)
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	keyfile, err := gerrittest.Keyfile(t.TempDir())
+	assert.Equal(t, nil, err)
+
+	c := connect.DefaultSshConfig()
+	c.Config = config.Config{}
+	c.Config.Spec.Connect.Hostname = srv.Hostname()
+	c.Config.Spec.Connect.Ssh.Keyfile = keyfile
+	c.Config.Spec.Connect.Ssh.Port = srv.Port()
+	c.Config.Spec.Connect.Ssh.Username = gerrittest.Username
+
+	c.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:  "ssh",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	s := connect.SshNew(context.Background(), c)
+	assert.Equal(t, nil, s.Init(context.Background()))
+
+	t.Cleanup(func() {
+		_ = s.Deinit(context.Background())
+	})
+
+	return s, srv
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	w := initWatchdog()
+	s, srv := initSsh(t)
+
+	err := w.check(ctx, s)
+	assert.Equal(t, nil, err)
+
+	srv.Disconnect()
+
+	err = w.check(ctx, s)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := initWatchdog()
+	s, srv := initSsh(t)
+
+	reconn := make(chan bool, 1)
+	start := make(chan bool, 1)
+
+	go func() {
+		_ = w.Run(ctx, s, reconn, start)
+	}()
+
+	srv.Disconnect()
+
+	select {
+	case <-reconn:
+	case <-time.After(timeout):
+		assert.Fail(t, "reconnect timeout")
+	}
+
+	w.cfg.Config.Spec.Watchdog.PeriodSeconds = 0
+
+	c, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- w.Run(c, s, reconn, start)
+	}()
+
+	assert.Equal(t, true, <-start)
+
+	stop()
+	assert.Equal(t, nil, <-done)
+}
+
+func TestReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := initWatchdog()
+	w.cfg.Config.Spec.Watchdog.PeriodSeconds = 0
+
+	s, srv := initSsh(t)
+
+	reconn := make(chan bool, 1)
+	start := make(chan bool, 1)
+
+	go func() {
+		_ = w.Run(ctx, s, reconn, start)
+	}()
+
+	assert.Equal(t, true, <-start)
+
+	srv.Disconnect()
+
+	// Turned on while running
+	c := config.New()
+	c.Spec.Watchdog.PeriodSeconds = 1
+	c.Spec.Watchdog.TimeoutSeconds = 1
+
+	err := w.Reload(ctx, c)
+	assert.Equal(t, nil, err)
+
+	select {
+	case <-reconn:
+	case <-time.After(timeout):
+		assert.Fail(t, "reconnect timeout")
+	}
+
+	select {
+	case <-start:
+		assert.Fail(t, "started again")
+	default:
+	}
 }